@@ -3,6 +3,7 @@ package v1beta1
 import (
 	corev1 "k8s.io/api/core/v1"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // Annotation for settings in svc object
@@ -29,6 +30,72 @@ const (
 	ServiceTCPProxyProtocolPortsFilter = "yawol.stackit.cloud/tcpProxyProtocolPortsFilter"
 	// ServiceExistingFloatingIP enables usage of existing Floating IP
 	ServiceExistingFloatingIP = "yawol.stackit.cloud/existingFloatingIP"
+	// ServicePodEndpoints uses the ready pods from the EndpointSlices as endpoints instead of the NodePorts of all nodes
+	ServicePodEndpoints = "yawol.stackit.cloud/podEndpoints"
+	// ServiceSubnetID sets the openstack subnet of the VIP port
+	ServiceSubnetID = "yawol.stackit.cloud/subnetID"
+	// ServiceFixedIP sets the fixed IP of the VIP port
+	ServiceFixedIP = "yawol.stackit.cloud/fixedIP"
+	// ServiceAllowSharedIP is a sharing key, services in the same namespace with the same key share one LoadBalancer
+	ServiceAllowSharedIP = "yawol.stackit.cloud/allowSharedIP"
+	// ServiceTLSPorts defines the ports on which TLS is terminated (comma separated list)
+	ServiceTLSPorts = "yawol.stackit.cloud/tlsPorts"
+	// ServiceTLSSecretName is the name of the TLS secret in the namespace of the service used for the TLS ports
+	ServiceTLSSecretName = "yawol.stackit.cloud/tlsSecretName"
+	// ServiceSNIHostnames defines the server names (SNI) that are routed to the TCP ports of the service (comma separated list)
+	ServiceSNIHostnames = "yawol.stackit.cloud/sniHostnames"
+	// ServiceLoadBalancingPolicy sets the load balancing policy of the ports (comma separated list).
+	// An entry without port (e.g. LEAST_REQUEST) applies to all ports, an entry with port (e.g. 443:MAGLEV) to a single port.
+	ServiceLoadBalancingPolicy = "yawol.stackit.cloud/loadBalancingPolicy"
+	// ServiceHealthCheckType sets the type of the health checks of the endpoints (TCP or HTTP)
+	ServiceHealthCheckType = "yawol.stackit.cloud/healthCheckType"
+	// ServiceHealthCheckTimeoutSeconds sets the timeout of a health check
+	ServiceHealthCheckTimeoutSeconds = "yawol.stackit.cloud/healthCheckTimeoutSeconds"
+	// ServiceHealthCheckIntervalSeconds sets the interval between health checks
+	ServiceHealthCheckIntervalSeconds = "yawol.stackit.cloud/healthCheckIntervalSeconds"
+	// ServiceHealthCheckUnhealthyThreshold sets the number of failed health checks until an endpoint is unhealthy
+	ServiceHealthCheckUnhealthyThreshold = "yawol.stackit.cloud/healthCheckUnhealthyThreshold"
+	// ServiceHealthCheckHealthyThreshold sets the number of successful health checks until an endpoint is healthy
+	ServiceHealthCheckHealthyThreshold = "yawol.stackit.cloud/healthCheckHealthyThreshold"
+	// ServiceHealthCheckHTTPPath sets the path of HTTP health checks
+	ServiceHealthCheckHTTPPath = "yawol.stackit.cloud/healthCheckHTTPPath"
+	// ServiceHealthCheckHTTPExpectedStatuses sets the healthy status codes of HTTP health checks (comma separated list)
+	ServiceHealthCheckHTTPExpectedStatuses = "yawol.stackit.cloud/healthCheckHTTPExpectedStatuses"
+	// ServiceHealthCheckTCPSend sets the payload that is sent by TCP health checks
+	ServiceHealthCheckTCPSend = "yawol.stackit.cloud/healthCheckTCPSend"
+	// ServiceHealthCheckTCPReceive sets the payload that TCP health checks expect in the response
+	ServiceHealthCheckTCPReceive = "yawol.stackit.cloud/healthCheckTCPReceive"
+	// ServiceHealthCheckUDPPort sets the TCP or HTTP port of the endpoints that is checked for UDP ports
+	ServiceHealthCheckUDPPort = "yawol.stackit.cloud/healthCheckUDPPort"
+	// ServiceConnectTimeout sets the timeout for connecting to an endpoint (comma separated list).
+	// An entry without port (e.g. 10s) applies to all ports, an entry with port (e.g. 5432:30s) to a single port.
+	ServiceConnectTimeout = "yawol.stackit.cloud/connectTimeout"
+	// ServiceTCPIdleTimeout sets the idle timeout of TCP connections, same format as ServiceConnectTimeout
+	ServiceTCPIdleTimeout = "yawol.stackit.cloud/tcpIdleTimeout"
+	// ServiceUDPIdleTimeout sets the idle timeout of UDP sessions, same format as ServiceConnectTimeout
+	ServiceUDPIdleTimeout = "yawol.stackit.cloud/udpIdleTimeout"
+	// ServiceTCPProxyProtocolVersion sets the version of the HAProxy TCP Proxy Protocol (comma separated list).
+	// An entry without port (e.g. 1) applies to all ports, an entry with port (e.g. 80:1) to a single port.
+	ServiceTCPProxyProtocolVersion = "yawol.stackit.cloud/tcpProxyProtocolVersion"
+	// ServiceDownstreamProxyProtocol accepts the HAProxy TCP Proxy Protocol on all TCP ports of the LoadBalancer
+	ServiceDownstreamProxyProtocol = "yawol.stackit.cloud/downstreamProxyProtocol"
+	// ServiceDownstreamProxyProtocolPortsFilter accepts the HAProxy TCP Proxy Protocol on the specified ports (comma separated list)
+	ServiceDownstreamProxyProtocolPortsFilter = "yawol.stackit.cloud/downstreamProxyProtocolPortsFilter"
+)
+
+// LoadBalancingPolicy is the policy that selects the endpoint for a new connection
+// +kubebuilder:validation:Enum=ROUND_ROBIN;LEAST_REQUEST;RING_HASH;MAGLEV
+type LoadBalancingPolicy string
+
+const (
+	// LoadBalancingPolicyRoundRobin selects the endpoints in turns
+	LoadBalancingPolicyRoundRobin LoadBalancingPolicy = "ROUND_ROBIN"
+	// LoadBalancingPolicyLeastRequest selects the endpoint with the fewest active connections
+	LoadBalancingPolicyLeastRequest LoadBalancingPolicy = "LEAST_REQUEST"
+	// LoadBalancingPolicyRingHash selects the endpoint by a consistent hash of the source IP
+	LoadBalancingPolicyRingHash LoadBalancingPolicy = "RING_HASH"
+	// LoadBalancingPolicyMaglev selects the endpoint by a consistent hash of the source IP with Maglev hashing
+	LoadBalancingPolicyMaglev LoadBalancingPolicy = "MAGLEV"
 )
 
 // +kubebuilder:object:root=true
@@ -71,6 +138,12 @@ type LoadBalancerSpec struct {
 	// ExistingFloatingIP uses a existing Floating IP as FIP
 	// +optional
 	ExistingFloatingIP *string `json:"existingFloatingIP,omitempty"`
+	// LoadBalancerIP is the requested IP of the LoadBalancer (copy of the service spec.loadBalancerIP).
+	// For external LoadBalancers the FloatingIP with this address is claimed or allocated,
+	// for internal LoadBalancers it is used as fixed IP of the port.
+	// Changing it re-associates the LoadBalancer to the new address.
+	// +optional
+	LoadBalancerIP *string `json:"loadBalancerIP,omitempty"`
 	// Debug are settings for debugging an loadbalancer.
 	// +optional
 	DebugSettings LoadBalancerDebugSettings `json:"debugSettings,omitempty"`
@@ -78,11 +151,67 @@ type LoadBalancerSpec struct {
 	Endpoints []LoadBalancerEndpoint `json:"endpoints,omitempty"`
 	// Ports defines the Ports for the LoadBalancer (copy from service)
 	Ports []corev1.ServicePort `json:"ports,omitempty"`
+	// SNIRoutes route TLS connections on a TCP port to different NodePorts depending on the
+	// requested server name (SNI) without terminating TLS. A route without hostnames is the
+	// default route of the port, connections with an unknown server name are rejected if a
+	// port has routes but no default route.
+	// +optional
+	SNIRoutes []LoadBalancerSNIRoute `json:"sniRoutes,omitempty"`
 	// Infrastructure defines parameters for the Infrastructure
 	Infrastructure LoadBalancerInfrastructure `json:"infrastructure"`
 	// Options for additional LoadBalancer settings
 	// +optional
 	Options LoadBalancerOptions `json:"options,omitempty"`
+	// Strategy defines how LoadBalancerMachines are replaced if the LoadBalancerMachine spec changes.
+	// +optional
+	Strategy *LoadBalancerStrategy `json:"strategy,omitempty"`
+	// RevisionHistoryLimit is the number of old LoadBalancerSets without replicas to retain to allow a rollback.
+	// Defaults to 2.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	RevisionHistoryLimit *int `json:"revisionHistoryLimit,omitempty"`
+	// RollbackTo pins the LoadBalancer to the LoadBalancerSet of an earlier revision.
+	// As long as it is set, changes to the infrastructure are not rolled out.
+	// +optional
+	RollbackTo *LoadBalancerRollbackConfig `json:"rollbackTo,omitempty"`
+	// Paused stops the reconciliation of the LoadBalancer and its LoadBalancerSets and LoadBalancerMachines.
+	// No openstack resources are changed and no LoadBalancerMachines are replaced. Deletion is not affected.
+	// +optional
+	Paused bool `json:"paused,omitempty"`
+}
+
+// LoadBalancerSNIRoute defines the NodePort for the server names on a port of the LoadBalancer.
+type LoadBalancerSNIRoute struct {
+	// Port is the TCP port of the LoadBalancer.
+	Port int32 `json:"port"`
+	// Hostnames are the server names of the route, wildcards like *.example.com are supported.
+	// If empty, the route is the default route of the port.
+	// +optional
+	Hostnames []string `json:"hostnames,omitempty"`
+	// NodePort is the NodePort to which the connections are proxied.
+	NodePort int32 `json:"nodePort"`
+}
+
+// LoadBalancerRollbackConfig defines the revision a LoadBalancer is rolled back to.
+type LoadBalancerRollbackConfig struct {
+	// Revision is the revision of the LoadBalancerSet which is scaled up again.
+	// +kubebuilder:validation:Minimum:=1
+	Revision int `json:"revision"`
+}
+
+// LoadBalancerStrategy defines the rollout strategy for a LoadBalancer.
+// Old LoadBalancerMachines are replaced step by step, the keepalived master is replaced last.
+type LoadBalancerStrategy struct {
+	// MaxSurge is the maximum number of LoadBalancerMachines that can be created over the desired replicas
+	// during a rollout. Value can be an absolute number or a percentage of the desired replicas (rounded up).
+	// Defaults to 100%.
+	// +optional
+	MaxSurge *intstr.IntOrString `json:"maxSurge,omitempty"`
+	// MaxUnavailable is the maximum number of LoadBalancerMachines that can be unavailable during a rollout.
+	// Value can be an absolute number or a percentage of the desired replicas (rounded down).
+	// Defaults to 0.
+	// +optional
+	MaxUnavailable *intstr.IntOrString `json:"maxUnavailable,omitempty"`
 }
 
 type LoadBalancerOptions struct {
@@ -100,6 +229,169 @@ type LoadBalancerOptions struct {
 	// If empty it is enabled for all ports. Only has an affect if TCPProxyProtocol is enabled.
 	// +optional
 	TCPProxyProtocolPortsFilter []int32 `json:"tcpProxyProtocolPortFilter,omitempty"`
+	// TCPProxyProtocolVersion is the version of the HAProxy TCP Proxy Protocol for all ports without an own
+	// version in TCPProxyProtocolPortVersions. Defaults to 2.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=2
+	// +optional
+	TCPProxyProtocolVersion int32 `json:"tcpProxyProtocolVersion,omitempty"`
+	// TCPProxyProtocolPortVersions overwrite the TCPProxyProtocolVersion for single ports.
+	// +optional
+	TCPProxyProtocolPortVersions []LoadBalancerPortProxyProtocolVersion `json:"tcpProxyProtocolPortVersions,omitempty"`
+	// DownstreamProxyProtocol accepts the HAProxy TCP Proxy Protocol (v1 and v2) on the TCP ports,
+	// so the LoadBalancer can be used behind another proxy. Connections without the header are rejected.
+	// +optional
+	DownstreamProxyProtocol bool `json:"downstreamProxyProtocol,omitempty"`
+	// DownstreamProxyProtocolPortsFilter accepts the HAProxy TCP Proxy Protocol only on the specified ports.
+	// If empty it is accepted on all ports. Only has an affect if DownstreamProxyProtocol is enabled.
+	// +optional
+	DownstreamProxyProtocolPortsFilter []int32 `json:"downstreamProxyProtocolPortFilter,omitempty"`
+	// IPFamilies defines the IP families of the LoadBalancer VIPs (copy from service).
+	// The first IP family is the primary one. Defaults to IPv4.
+	// +optional
+	IPFamilies []corev1.IPFamily `json:"ipFamilies,omitempty"`
+	// IPFamilyPolicy defines if the LoadBalancer requires a VIP for every IP family (copy from service).
+	// Only RequireDualStack fails if the port has no IP for a secondary IP family.
+	// +optional
+	IPFamilyPolicy *corev1.IPFamilyPolicyType `json:"ipFamilyPolicy,omitempty"`
+	// HealthCheckNodePort is the node port of the kube-proxy health check (copy from service).
+	// It is only set if the externalTrafficPolicy of the service is Local. If set, the nodes are checked
+	// with an HTTP health check on this port, so only nodes with local endpoints receive traffic.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=65535
+	// +optional
+	HealthCheckNodePort int32 `json:"healthCheckNodePort,omitempty"`
+	// PodEndpoints uses the pod IPs and target ports from the EndpointSlices of the service as endpoints
+	// instead of the NodePorts of all nodes. The pod IPs must be routable from the LoadBalancer network.
+	// +optional
+	PodEndpoints bool `json:"podEndpoints,omitempty"`
+	// SubnetID is the openstack subnet of the network in which the VIP port gets its fixed IP.
+	// If not set, openstack chooses the subnet.
+	// +optional
+	SubnetID string `json:"subnetID,omitempty"`
+	// FixedIP is the fixed IP of the VIP port. It must be free and in a subnet of the network.
+	// For internal LoadBalancers it takes precedence over the LoadBalancerIP.
+	// +optional
+	FixedIP string `json:"fixedIP,omitempty"`
+	// TLSPorts are the TCP ports on which TLS is terminated by the LoadBalancer.
+	// The traffic is proxied as plain TCP to the endpoints. The certificate is read from the
+	// TLS secret of the LoadBalancer (<name>-tls) in the namespace of the LoadBalancer.
+	// +optional
+	TLSPorts []int32 `json:"tlsPorts,omitempty"`
+	// LoadBalancingPolicy is the load balancing policy of all ports without an own policy in PortLoadBalancingPolicies.
+	// RING_HASH and MAGLEV hash the source IP, so connections of a client are sticky to an endpoint.
+	// Defaults to ROUND_ROBIN.
+	// +optional
+	LoadBalancingPolicy LoadBalancingPolicy `json:"loadBalancingPolicy,omitempty"`
+	// PortLoadBalancingPolicies overwrite the LoadBalancingPolicy for single ports.
+	// +optional
+	PortLoadBalancingPolicies []LoadBalancerPortPolicy `json:"portLoadBalancingPolicies,omitempty"`
+	// HealthCheck overwrites the default health checks of the endpoints.
+	// +optional
+	HealthCheck *LoadBalancerHealthCheck `json:"healthCheck,omitempty"`
+	// Timeouts are the timeouts of all ports without an own value in PortTimeouts.
+	// +optional
+	Timeouts *LoadBalancerTimeouts `json:"timeouts,omitempty"`
+	// PortTimeouts overwrite single values of the Timeouts for single ports.
+	// +optional
+	PortTimeouts []LoadBalancerPortTimeouts `json:"portTimeouts,omitempty"`
+}
+
+// LoadBalancerTimeouts defines the timeouts of the proxied connections
+type LoadBalancerTimeouts struct {
+	// ConnectTimeout is the timeout for connecting to an endpoint. Defaults to 5s.
+	// +optional
+	ConnectTimeout *metav1.Duration `json:"connectTimeout,omitempty"`
+	// TCPIdleTimeout closes TCP connections without traffic after the timeout, 0s disables it. Defaults to 1h.
+	// +optional
+	TCPIdleTimeout *metav1.Duration `json:"tcpIdleTimeout,omitempty"`
+	// UDPIdleTimeout removes UDP sessions without traffic after the timeout. Defaults to 1m.
+	// +optional
+	UDPIdleTimeout *metav1.Duration `json:"udpIdleTimeout,omitempty"`
+}
+
+// LoadBalancerPortTimeouts defines the timeouts of a port
+type LoadBalancerPortTimeouts struct {
+	// Port is the port of the LoadBalancer, the timeouts are used for all protocols of the port.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	Port                 int32 `json:"port"`
+	LoadBalancerTimeouts `json:",inline"`
+}
+
+// LoadBalancerHealthCheckType is the type of the health checks of the endpoints
+// +kubebuilder:validation:Enum=TCP;HTTP
+type LoadBalancerHealthCheckType string
+
+const (
+	// HealthCheckTypeTCP connects to the endpoint and optionally sends and receives a payload
+	HealthCheckTypeTCP LoadBalancerHealthCheckType = "TCP"
+	// HealthCheckTypeHTTP sends a GET request to the endpoint
+	HealthCheckTypeHTTP LoadBalancerHealthCheckType = "HTTP"
+)
+
+// LoadBalancerHealthCheck defines the active health checks of the endpoints.
+// If the HealthCheckNodePort is set, the kube-proxy health check is used instead of the type, but with the same timings.
+type LoadBalancerHealthCheck struct {
+	// Type is the type of the health checks. Defaults to TCP.
+	// +optional
+	Type LoadBalancerHealthCheckType `json:"type,omitempty"`
+	// TimeoutSeconds is the time to wait for a health check response. Defaults to 5.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	TimeoutSeconds int32 `json:"timeoutSeconds,omitempty"`
+	// IntervalSeconds is the time between two health checks. Defaults to 5.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	IntervalSeconds int32 `json:"intervalSeconds,omitempty"`
+	// UnhealthyThreshold is the number of failed health checks until an endpoint is unhealthy. Defaults to 3.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	UnhealthyThreshold int32 `json:"unhealthyThreshold,omitempty"`
+	// HealthyThreshold is the number of successful health checks until an endpoint is healthy. Defaults to 2.
+	// +kubebuilder:validation:Minimum:=0
+	// +optional
+	HealthyThreshold int32 `json:"healthyThreshold,omitempty"`
+	// HTTPPath is the path of HTTP health checks. Defaults to /.
+	// +optional
+	HTTPPath string `json:"httpPath,omitempty"`
+	// HTTPExpectedStatuses are the status codes of healthy HTTP health checks. Defaults to 200.
+	// +optional
+	HTTPExpectedStatuses []int32 `json:"httpExpectedStatuses,omitempty"`
+	// TCPSend is the payload that is sent by TCP health checks.
+	// +optional
+	TCPSend string `json:"tcpSend,omitempty"`
+	// TCPReceive is the payload that TCP health checks expect in the response.
+	// +optional
+	TCPReceive string `json:"tcpReceive,omitempty"`
+	// UDPPort is a TCP port of the endpoints that is checked with the type of the health checks for UDP ports.
+	// UDP ports can not be checked directly and are only checked if it is set.
+	// +kubebuilder:validation:Minimum:=0
+	// +kubebuilder:validation:Maximum:=65535
+	// +optional
+	UDPPort int32 `json:"udpPort,omitempty"`
+}
+
+// LoadBalancerPortProxyProtocolVersion defines the version of the HAProxy TCP Proxy Protocol of a port
+type LoadBalancerPortProxyProtocolVersion struct {
+	// Port is the port of the LoadBalancer.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	Port int32 `json:"port"`
+	// Version is the version of the HAProxy TCP Proxy Protocol of the port.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=2
+	Version int32 `json:"version"`
+}
+
+// LoadBalancerPortPolicy defines the load balancing policy of a port
+type LoadBalancerPortPolicy struct {
+	// Port is the port of the LoadBalancer, the policy is used for all protocols of the port.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=65535
+	Port int32 `json:"port"`
+	// Policy is the load balancing policy of the port.
+	Policy LoadBalancingPolicy `json:"policy"`
 }
 
 // LoadBalancerDebugSettings defines debug settings for the LoadBalancer
@@ -118,6 +410,21 @@ type LoadBalancerEndpoint struct {
 	Name string `json:"name"`
 	// Addresses is a list of addresses for the endpoint, they can contain IPv4 and IPv6 addresses.
 	Addresses []string `json:"addresses,omitempty"`
+	// Ports are the target ports of the endpoint. They are only set for pod endpoints
+	// and are matched by name and protocol with the ports of the LoadBalancer.
+	// +optional
+	Ports []LoadBalancerEndpointPort `json:"ports,omitempty"`
+}
+
+// LoadBalancerEndpointPort defines a target port of a pod endpoint.
+type LoadBalancerEndpointPort struct {
+	// Name is the name of the service port.
+	// +optional
+	Name string `json:"name,omitempty"`
+	// Protocol is the protocol of the port.
+	Protocol corev1.Protocol `json:"protocol"`
+	// Port is the target port of the pod.
+	Port int32 `json:"port"`
 }
 
 // LoadBalancerInfrastructure defines infrastructure defaults for the LoadBalancer
@@ -150,7 +457,8 @@ type OpenstackImageRef struct {
 	// +optional
 	ImageName *string `json:"image_name,omitempty"`
 	// ImageSearch is a search string to find the image used for requesting virtual machines.
-	// Search will be performed in metadata of the images.
+	// ImageSearch must equal a tag or a property in the form key=value of the image.
+	// If multiple images match, the newest one is used.
 	// ImageSearch is only used if ImageName and ImageID are not defined.
 	// +optional
 	ImageSearch *string `json:"image_search,omitempty"`
@@ -192,6 +500,9 @@ type LoadBalancerStatus struct {
 	// ExternalIP is the current externalIP (FIP or private). If not defined, no ExternalIP is bound yet.
 	// +optional
 	ExternalIP *string `json:"externalIP,omitempty"`
+	// ExternalIPs contains the current externalIPs of all IP families (FIP or private for IPv4, private for IPv6).
+	// +optional
+	ExternalIPs []string `json:"externalIPs,omitempty"`
 	// FloatingID is the current openstack ID from the FloatingIP.
 	// +optional
 	FloatingID *string `json:"floatingID,omitempty"`
@@ -216,6 +527,29 @@ type LoadBalancerStatus struct {
 	// OpenstackReconcileHash contains a hash of openstack related settings to reset the LastOpenstackReconcile timer if needed.
 	// +optional
 	OpenstackReconcileHash *string `json:"openstackReconcileHash,omitempty"`
+	// ImageID is the openstack image ID the image name or image search currently resolves to.
+	// It is not set if the image is referenced by ID.
+	// +optional
+	ImageID *string `json:"imageID,omitempty"`
+	// FlavorID is the openstack flavor ID the flavor name or flavor search currently resolves to.
+	// It is not set if the flavor is referenced by ID.
+	// +optional
+	FlavorID *string `json:"flavorID,omitempty"`
+	// VRRPRouterID is the keepalived virtual router ID of the LoadBalancer, unique among the LoadBalancers of the network.
+	// +optional
+	VRRPRouterID *int32 `json:"vrrpRouterID,omitempty"`
+	// KeepalivedSecretName is the name of the secret with the VRRP auth password of the LoadBalancer.
+	// +optional
+	KeepalivedSecretName *string `json:"keepalivedSecretName,omitempty"`
+	// Conditions contains condition information for a LoadBalancer.
+	// Known condition types are Paused, FloatingIPReady, PortReady, SecurityGroupReady, RolloutInProgress and Available.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the most recent generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 func init() {
@@ -39,6 +39,15 @@ type LoadBalancerMachineSpec struct {
 	PortID string `json:"portID"`
 	// LoadBalancerRef defines a reference to the LoadBalancer Object.
 	LoadBalancerRef LoadBalancerRef `json:"loadBalancerRef"`
+	// VRRPRouterID is the virtual router ID of the keepalived VRRP instance. Defaults to 100.
+	// +kubebuilder:validation:Minimum:=1
+	// +kubebuilder:validation:Maximum:=255
+	// +optional
+	VRRPRouterID int32 `json:"vrrpRouterID,omitempty"`
+	// KeepalivedSecretRef references the secret with the VRRP auth password of keepalived.
+	// A default password is used if it is not set.
+	// +optional
+	KeepalivedSecretRef *corev1.SecretReference `json:"keepalivedSecretRef,omitempty"`
 }
 
 // LoadBalancerMachineTemplateSpec defines the desired state of LoadBalancerSet.
@@ -69,6 +78,9 @@ type LoadBalancerMachineStatus struct {
 	// PortID contains the openstack port ID for a LoadBalancerMachine.
 	// +optional
 	PortID *string `json:"portID,omitempty"`
+	// ImageID contains the openstack image ID the server of a LoadBalancerMachine was created with.
+	// +optional
+	ImageID *string `json:"imageID,omitempty"`
 	// ServiceAccountName contains the namespacedName from the ServiceAccount for a LoadBalancerMachine.
 	// +optional
 	ServiceAccountName *string `json:"serviceAccountName,omitempty"`
@@ -78,6 +90,19 @@ type LoadBalancerMachineStatus struct {
 	// RoleBindingName contains the namespacedName from the RoleBinding for a LoadBalancerMachine.
 	// +optional
 	RoleBindingName *string `json:"roleBindingName,omitempty"`
+	// VIPs contains the fixed IPs of the LoadBalancer port that keepalived assigns to the master LoadBalancerMachine.
+	// +optional
+	VIPs *[]string `json:"vips,omitempty"`
+	// VRRPUnicastSrcIP contains the fixed IP of the port of a LoadBalancerMachine that keepalived sends VRRP adverts from.
+	// +optional
+	VRRPUnicastSrcIP *string `json:"vrrpUnicastSrcIP,omitempty"`
+	// VRRPUnicastPeers contains the VRRP unicast source IPs of the other LoadBalancerMachines of the LoadBalancerSet.
+	// +optional
+	VRRPUnicastPeers *[]string `json:"vrrpUnicastPeers,omitempty"`
+	// KeepalivedSecretVersion contains the resource version of the keepalived secret
+	// the keepalived config of a LoadBalancerMachine is generated from.
+	// +optional
+	KeepalivedSecretVersion *string `json:"keepalivedSecretVersion,omitempty"`
 }
 
 // LoadBalancerMachineMetric describes a metric of the LoadBalancerMachine
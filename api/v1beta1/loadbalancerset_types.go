@@ -54,6 +54,15 @@ type LoadBalancerSetStatus struct {
 	// Replicas are the desired replicas.
 	// +optional
 	Replicas *int `json:"replicas,omitempty"`
+	// Conditions contains condition information for a LoadBalancerSet.
+	// Known condition types are Available.
+	// +optional
+	// +listType=map
+	// +listMapKey=type
+	Conditions []metav1.Condition `json:"conditions,omitempty"`
+	// ObservedGeneration is the most recent generation observed by the controller.
+	// +optional
+	ObservedGeneration int64 `json:"observedGeneration,omitempty"`
 }
 
 func init() {
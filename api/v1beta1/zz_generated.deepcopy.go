@@ -7,7 +7,9 @@ package v1beta1
 
 import (
 	"k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	runtime "k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 )
 
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
@@ -60,6 +62,11 @@ func (in *LoadBalancerEndpoint) DeepCopyInto(out *LoadBalancerEndpoint) {
 		*out = make([]string, len(*in))
 		copy(*out, *in)
 	}
+	if in.Ports != nil {
+		in, out := &in.Ports, &out.Ports
+		*out = make([]LoadBalancerEndpointPort, len(*in))
+		copy(*out, *in)
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerEndpoint.
@@ -72,6 +79,41 @@ func (in *LoadBalancerEndpoint) DeepCopy() *LoadBalancerEndpoint {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerEndpointPort) DeepCopyInto(out *LoadBalancerEndpointPort) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerEndpointPort.
+func (in *LoadBalancerEndpointPort) DeepCopy() *LoadBalancerEndpointPort {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerEndpointPort)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerHealthCheck) DeepCopyInto(out *LoadBalancerHealthCheck) {
+	*out = *in
+	if in.HTTPExpectedStatuses != nil {
+		in, out := &in.HTTPExpectedStatuses, &out.HTTPExpectedStatuses
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerHealthCheck.
+func (in *LoadBalancerHealthCheck) DeepCopy() *LoadBalancerHealthCheck {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerHealthCheck)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadBalancerInfrastructure) DeepCopyInto(out *LoadBalancerInfrastructure) {
 	*out = *in
@@ -215,6 +257,11 @@ func (in *LoadBalancerMachineSpec) DeepCopyInto(out *LoadBalancerMachineSpec) {
 	*out = *in
 	in.Infrastructure.DeepCopyInto(&out.Infrastructure)
 	out.LoadBalancerRef = in.LoadBalancerRef
+	if in.KeepalivedSecretRef != nil {
+		in, out := &in.KeepalivedSecretRef, &out.KeepalivedSecretRef
+		*out = new(v1.SecretReference)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerMachineSpec.
@@ -270,6 +317,11 @@ func (in *LoadBalancerMachineStatus) DeepCopyInto(out *LoadBalancerMachineStatus
 		*out = new(string)
 		**out = **in
 	}
+	if in.ImageID != nil {
+		in, out := &in.ImageID, &out.ImageID
+		*out = new(string)
+		**out = **in
+	}
 	if in.ServiceAccountName != nil {
 		in, out := &in.ServiceAccountName, &out.ServiceAccountName
 		*out = new(string)
@@ -285,6 +337,34 @@ func (in *LoadBalancerMachineStatus) DeepCopyInto(out *LoadBalancerMachineStatus
 		*out = new(string)
 		**out = **in
 	}
+	if in.VIPs != nil {
+		in, out := &in.VIPs, &out.VIPs
+		*out = new([]string)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+	}
+	if in.VRRPUnicastSrcIP != nil {
+		in, out := &in.VRRPUnicastSrcIP, &out.VRRPUnicastSrcIP
+		*out = new(string)
+		**out = **in
+	}
+	if in.VRRPUnicastPeers != nil {
+		in, out := &in.VRRPUnicastPeers, &out.VRRPUnicastPeers
+		*out = new([]string)
+		if **in != nil {
+			in, out := *in, *out
+			*out = make([]string, len(*in))
+			copy(*out, *in)
+		}
+	}
+	if in.KeepalivedSecretVersion != nil {
+		in, out := &in.KeepalivedSecretVersion, &out.KeepalivedSecretVersion
+		*out = new(string)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerMachineStatus.
@@ -333,6 +413,53 @@ func (in *LoadBalancerOptions) DeepCopyInto(out *LoadBalancerOptions) {
 		*out = make([]int32, len(*in))
 		copy(*out, *in)
 	}
+	if in.TCPProxyProtocolPortVersions != nil {
+		in, out := &in.TCPProxyProtocolPortVersions, &out.TCPProxyProtocolPortVersions
+		*out = make([]LoadBalancerPortProxyProtocolVersion, len(*in))
+		copy(*out, *in)
+	}
+	if in.DownstreamProxyProtocolPortsFilter != nil {
+		in, out := &in.DownstreamProxyProtocolPortsFilter, &out.DownstreamProxyProtocolPortsFilter
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPFamilies != nil {
+		in, out := &in.IPFamilies, &out.IPFamilies
+		*out = make([]v1.IPFamily, len(*in))
+		copy(*out, *in)
+	}
+	if in.IPFamilyPolicy != nil {
+		in, out := &in.IPFamilyPolicy, &out.IPFamilyPolicy
+		*out = new(v1.IPFamilyPolicyType)
+		**out = **in
+	}
+	if in.TLSPorts != nil {
+		in, out := &in.TLSPorts, &out.TLSPorts
+		*out = make([]int32, len(*in))
+		copy(*out, *in)
+	}
+	if in.PortLoadBalancingPolicies != nil {
+		in, out := &in.PortLoadBalancingPolicies, &out.PortLoadBalancingPolicies
+		*out = make([]LoadBalancerPortPolicy, len(*in))
+		copy(*out, *in)
+	}
+	if in.HealthCheck != nil {
+		in, out := &in.HealthCheck, &out.HealthCheck
+		*out = new(LoadBalancerHealthCheck)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.Timeouts != nil {
+		in, out := &in.Timeouts, &out.Timeouts
+		*out = new(LoadBalancerTimeouts)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.PortTimeouts != nil {
+		in, out := &in.PortTimeouts, &out.PortTimeouts
+		*out = make([]LoadBalancerPortTimeouts, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerOptions.
@@ -345,6 +472,52 @@ func (in *LoadBalancerOptions) DeepCopy() *LoadBalancerOptions {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerPortPolicy) DeepCopyInto(out *LoadBalancerPortPolicy) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerPortPolicy.
+func (in *LoadBalancerPortPolicy) DeepCopy() *LoadBalancerPortPolicy {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerPortPolicy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerPortProxyProtocolVersion) DeepCopyInto(out *LoadBalancerPortProxyProtocolVersion) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerPortProxyProtocolVersion.
+func (in *LoadBalancerPortProxyProtocolVersion) DeepCopy() *LoadBalancerPortProxyProtocolVersion {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerPortProxyProtocolVersion)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerPortTimeouts) DeepCopyInto(out *LoadBalancerPortTimeouts) {
+	*out = *in
+	in.LoadBalancerTimeouts.DeepCopyInto(&out.LoadBalancerTimeouts)
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerPortTimeouts.
+func (in *LoadBalancerPortTimeouts) DeepCopy() *LoadBalancerPortTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerPortTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadBalancerRef) DeepCopyInto(out *LoadBalancerRef) {
 	*out = *in
@@ -360,6 +533,41 @@ func (in *LoadBalancerRef) DeepCopy() *LoadBalancerRef {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerRollbackConfig) DeepCopyInto(out *LoadBalancerRollbackConfig) {
+	*out = *in
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerRollbackConfig.
+func (in *LoadBalancerRollbackConfig) DeepCopy() *LoadBalancerRollbackConfig {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerRollbackConfig)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerSNIRoute) DeepCopyInto(out *LoadBalancerSNIRoute) {
+	*out = *in
+	if in.Hostnames != nil {
+		in, out := &in.Hostnames, &out.Hostnames
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSNIRoute.
+func (in *LoadBalancerSNIRoute) DeepCopy() *LoadBalancerSNIRoute {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerSNIRoute)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *LoadBalancerSet) DeepCopyInto(out *LoadBalancerSet) {
 	*out = *in
@@ -454,6 +662,13 @@ func (in *LoadBalancerSetStatus) DeepCopyInto(out *LoadBalancerSetStatus) {
 		*out = new(int)
 		**out = **in
 	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSetStatus.
@@ -475,6 +690,11 @@ func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.LoadBalancerIP != nil {
+		in, out := &in.LoadBalancerIP, &out.LoadBalancerIP
+		*out = new(string)
+		**out = **in
+	}
 	out.DebugSettings = in.DebugSettings
 	if in.Endpoints != nil {
 		in, out := &in.Endpoints, &out.Endpoints
@@ -490,8 +710,30 @@ func (in *LoadBalancerSpec) DeepCopyInto(out *LoadBalancerSpec) {
 			(*in)[i].DeepCopyInto(&(*out)[i])
 		}
 	}
+	if in.SNIRoutes != nil {
+		in, out := &in.SNIRoutes, &out.SNIRoutes
+		*out = make([]LoadBalancerSNIRoute, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 	in.Infrastructure.DeepCopyInto(&out.Infrastructure)
 	in.Options.DeepCopyInto(&out.Options)
+	if in.Strategy != nil {
+		in, out := &in.Strategy, &out.Strategy
+		*out = new(LoadBalancerStrategy)
+		(*in).DeepCopyInto(*out)
+	}
+	if in.RevisionHistoryLimit != nil {
+		in, out := &in.RevisionHistoryLimit, &out.RevisionHistoryLimit
+		*out = new(int)
+		**out = **in
+	}
+	if in.RollbackTo != nil {
+		in, out := &in.RollbackTo, &out.RollbackTo
+		*out = new(LoadBalancerRollbackConfig)
+		**out = **in
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerSpec.
@@ -522,6 +764,11 @@ func (in *LoadBalancerStatus) DeepCopyInto(out *LoadBalancerStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ExternalIPs != nil {
+		in, out := &in.ExternalIPs, &out.ExternalIPs
+		*out = make([]string, len(*in))
+		copy(*out, *in)
+	}
 	if in.FloatingID != nil {
 		in, out := &in.FloatingID, &out.FloatingID
 		*out = new(string)
@@ -561,6 +808,33 @@ func (in *LoadBalancerStatus) DeepCopyInto(out *LoadBalancerStatus) {
 		*out = new(string)
 		**out = **in
 	}
+	if in.ImageID != nil {
+		in, out := &in.ImageID, &out.ImageID
+		*out = new(string)
+		**out = **in
+	}
+	if in.FlavorID != nil {
+		in, out := &in.FlavorID, &out.FlavorID
+		*out = new(string)
+		**out = **in
+	}
+	if in.VRRPRouterID != nil {
+		in, out := &in.VRRPRouterID, &out.VRRPRouterID
+		*out = new(int32)
+		**out = **in
+	}
+	if in.KeepalivedSecretName != nil {
+		in, out := &in.KeepalivedSecretName, &out.KeepalivedSecretName
+		*out = new(string)
+		**out = **in
+	}
+	if in.Conditions != nil {
+		in, out := &in.Conditions, &out.Conditions
+		*out = make([]metav1.Condition, len(*in))
+		for i := range *in {
+			(*in)[i].DeepCopyInto(&(*out)[i])
+		}
+	}
 }
 
 // DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerStatus.
@@ -573,6 +847,61 @@ func (in *LoadBalancerStatus) DeepCopy() *LoadBalancerStatus {
 	return out
 }
 
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerStrategy) DeepCopyInto(out *LoadBalancerStrategy) {
+	*out = *in
+	if in.MaxSurge != nil {
+		in, out := &in.MaxSurge, &out.MaxSurge
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+	if in.MaxUnavailable != nil {
+		in, out := &in.MaxUnavailable, &out.MaxUnavailable
+		*out = new(intstr.IntOrString)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerStrategy.
+func (in *LoadBalancerStrategy) DeepCopy() *LoadBalancerStrategy {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerStrategy)
+	in.DeepCopyInto(out)
+	return out
+}
+
+// DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
+func (in *LoadBalancerTimeouts) DeepCopyInto(out *LoadBalancerTimeouts) {
+	*out = *in
+	if in.ConnectTimeout != nil {
+		in, out := &in.ConnectTimeout, &out.ConnectTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.TCPIdleTimeout != nil {
+		in, out := &in.TCPIdleTimeout, &out.TCPIdleTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+	if in.UDPIdleTimeout != nil {
+		in, out := &in.UDPIdleTimeout, &out.UDPIdleTimeout
+		*out = new(metav1.Duration)
+		**out = **in
+	}
+}
+
+// DeepCopy is an autogenerated deepcopy function, copying the receiver, creating a new LoadBalancerTimeouts.
+func (in *LoadBalancerTimeouts) DeepCopy() *LoadBalancerTimeouts {
+	if in == nil {
+		return nil
+	}
+	out := new(LoadBalancerTimeouts)
+	in.DeepCopyInto(out)
+	return out
+}
+
 // DeepCopyInto is an autogenerated deepcopy function, copying the receiver, writing into out. in must be non-nil.
 func (in *OpenstackFlavorRef) DeepCopyInto(out *OpenstackFlavorRef) {
 	*out = *in
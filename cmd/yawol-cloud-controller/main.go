@@ -71,6 +71,7 @@ func main() {
 	var targetKubeconfig string
 	var controlKubeconfig string
 	var className string
+	var loadBalancerClass string
 	// settings for leases
 	var leasesDurationInt int
 	var leasesRenewDeadlineInt int
@@ -94,7 +95,11 @@ func main() {
 		"K8s credentials for deploying the LoadBalancer resources.")
 	flag.StringVar(&className, "classname", "",
 		"Only listen to Services with the given className. "+
-			"Default is empty and listen to all services with out className annotation")
+			"Default is empty and listen to all services with out className annotation. "+
+			"Ignored for Services with spec.loadBalancerClass.")
+	flag.StringVar(&loadBalancerClass, "load-balancer-class", "",
+		"Only listen to Services with the given spec.loadBalancerClass, it takes precedence over the className annotation. "+
+			"Default is empty and ignore all services with a loadBalancerClass")
 	flag.IntVar(&leasesDurationInt, "leases-duration", 60,
 		"Is the time in seconds a non-leader will wait until forcing to acquire leadership.")
 	flag.IntVar(&leasesRenewDeadlineInt, "leases-renew-deadline", 50,
@@ -168,11 +173,13 @@ func main() {
 	if err = (&targetcontroller.ServiceReconciler{
 		TargetClient:           targetClient,
 		ControlClient:          controlClient,
+		TargetAPIReader:        targetMgr.GetAPIReader(),
 		InfrastructureDefaults: infrastructureDefaults,
 		Log:                    ctrl.Log.WithName("controller").WithName("Service"),
 		Scheme:                 targetMgr.GetScheme(),
 		Recorder:               targetMgr.GetEventRecorderFor("yawol-cloud-controller"),
 		ClassName:              className,
+		LoadBalancerClass:      loadBalancerClass,
 	}).SetupWithManager(targetMgr); err != nil {
 		setupLog.Error(err, "unable to create controller", "controller", "Service")
 		os.Exit(1)
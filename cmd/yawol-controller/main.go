@@ -144,6 +144,7 @@ func main() {
 
 		if err = (&loadbalancer.Reconciler{
 			Client:           loadBalancerMgr.GetClient(),
+			APIReader:        loadBalancerMgr.GetAPIReader(),
 			Log:              ctrl.Log.WithName("controller").WithName("LoadBalancer"),
 			Scheme:           loadBalancerMgr.GetScheme(),
 			WorkerCount:      concurrentWorkersPerReconciler,
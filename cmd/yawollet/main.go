@@ -7,6 +7,7 @@ import (
 	"log"
 	"net"
 	"os"
+	"strings"
 
 	// Import all Kubernetes client auth plugins (e.g. Azure, GCP, OIDC, etc.)
 	// to ensure that exec-entrypoint and run can make use of them.
@@ -59,6 +60,12 @@ func main() {
 	var listenInterface string
 	var requeueTime int
 	var keepalivedStatsFile string
+	var nftablesCommand string
+	var keepalivedConfig string
+	var keepalivedInterface string
+	var keepalivedReloadCommand string
+	var keepalivedTrackFile string
+	var keepalivedNotifyFIFO string
 
 	flag.StringVar(&metricsAddr, "metrics-bind-address", "0", "The address the metric endpoint binds to. Default is disabled.")
 	flag.StringVar(&probeAddr, "health-probe-bind-address", "0", "The address the probe endpoint binds to. Default is disabled.")
@@ -69,14 +76,31 @@ func main() {
 	flag.StringVar(&namespace, "namespace", "", "The namespace from lb und lbm object.")
 	flag.StringVar(&loadbalancerName, "loadbalancer-name", "", "Name of lb object.")
 	flag.StringVar(&loadbalancerMachineName, "loadbalancer-machine-name", "", "Name of lbm object.")
-	flag.StringVar(&listenAddress, "listen-address", "", "Address that envoy should listen.")
-	flag.StringVar(&listenInterface, "listen-interface", "", "Interface that envoy should listen on. Ignored if listen-address is set.")
+	flag.StringVar(&listenAddress, "listen-address", "", "Comma separated addresses that envoy should listen (one per IP family).")
+	flag.StringVar(&listenInterface, "listen-interface", "", "Interface that envoy should listen on (first IPv4 and global IPv6 address). "+
+		"Ignored if listen-address is set.")
 	flag.IntVar(&requeueTime, "requeue-time", 30, "Requeue Time for reconcile if object was successful reconciled. "+
 		"Values less than 5 are set to 5 and greater than 50 are set to 50")
 
 	flag.StringVar(&keepalivedStatsFile, "keepalived-stats-file", "/tmp/keepalived.stats",
 		"Stats file for keepalived (default: /tmp/keepalived.stats). "+
 			"If set to empty no keepalived stats will be used for conditions and metrics.")
+	flag.StringVar(&nftablesCommand, "nftables-command", "",
+		"nft command to enforce the loadBalancerSourceRanges of UDP ports on the machine (needs CAP_NET_ADMIN). "+
+			"If set to empty the source ranges of UDP ports are only enforced by the OpenStack security group.")
+	flag.StringVar(&keepalivedConfig, "keepalived-config", "",
+		"Keepalived config file that is generated from the lb and lbm objects and reloaded if it changes. "+
+			"If set to empty the keepalived config is not updated by the yawollet.")
+	flag.StringVar(&keepalivedInterface, "keepalived-interface", "",
+		"Interface for the keepalived vrrp instance. If set to empty the interface with the VRRP unicast source IP is used.")
+	flag.StringVar(&keepalivedReloadCommand, "keepalived-reload-command", "pkill -HUP -o -x keepalived",
+		"Command to reload the keepalived config (needs CAP_KILL).")
+	flag.StringVar(&keepalivedTrackFile, "keepalived-track-file", "",
+		"Keepalived track file that lowers the VRRP priority if envoy or the backends are not healthy. "+
+			"If set to empty the VRRP priority only depends on the envoy process.")
+	flag.StringVar(&keepalivedNotifyFIFO, "keepalived-notify-fifo", "",
+		"Keepalived notify fifo that streams the VRRP state transitions. "+
+			"If set to empty only the keepalived stats file is used for the keepalived status.")
 
 	opts := zap.Options{
 		Development: true,
@@ -99,34 +123,18 @@ func main() {
 		requeueTime = 50
 	}
 
-	// set listen address
-	if listenAddress == "" {
-		if listenInterface != "" {
-			ifaces, _ := net.Interfaces()
-			for _, i := range ifaces {
-				if i.Name == listenInterface {
-					addrs, _ := i.Addrs()
-					for _, addr := range addrs {
-						switch v := addr.(type) {
-						case *net.IPNet:
-							if v.IP.To4() != nil {
-								listenAddress = v.IP.To4().String()
-							}
-						case *net.IPAddr:
-							if v.IP.To4() != nil {
-								listenAddress = v.IP.To4().String()
-							}
-						}
-					}
-				}
-			}
-			if listenAddress == "" {
-				setupLog.Error(helper.ErrYawolletIPNotFound, "no IP found for "+listenInterface)
-				os.Exit(1)
-			}
-		} else {
-			listenAddress = "0.0.0.0"
+	// set listen addresses
+	var listenAddresses []string
+	if listenAddress != "" {
+		listenAddresses = strings.Split(listenAddress, ",")
+	} else if listenInterface != "" {
+		listenAddresses = getInterfaceAddresses(listenInterface)
+		if len(listenAddresses) == 0 {
+			setupLog.Error(helper.ErrYawolletIPNotFound, "no IP found for "+listenInterface)
+			os.Exit(1)
 		}
+	} else {
+		listenAddresses = []string{"0.0.0.0"}
 	}
 
 	// envoy grpc startup
@@ -191,14 +199,22 @@ func main() {
 
 	if err = (&controllers.LoadBalancerReconciler{
 		Client:                  mgr.GetClient(),
+		APIReader:               mgr.GetAPIReader(),
 		Log:                     ctrl.Log.WithName("controller").WithName("LoadBalancer"),
 		Scheme:                  mgr.GetScheme(),
 		LoadbalancerName:        loadbalancerName,
 		LoadbalancerMachineName: loadbalancerMachineName,
 		EnvoyCache:              cache,
-		ListenAddress:           listenAddress,
+		ListenAddresses:         listenAddresses,
 		RequeueTime:             requeueTime,
 		KeepalivedStatsFile:     keepalivedStatsFile,
+		NftablesCommand:         nftablesCommand,
+		KeepalivedConfigFile:    keepalivedConfig,
+		KeepalivedInterface:     keepalivedInterface,
+		KeepalivedReloadCommand: keepalivedReloadCommand,
+		KeepalivedTrackFile:     keepalivedTrackFile,
+		KeepalivedNotifyFIFO:    keepalivedNotifyFIFO,
+		Namespace:               namespace,
 		Recorder:                mgr.GetEventRecorderFor("yawollet"),
 		RecorderLB:              mgr.GetEventRecorderFor("yawol-service"),
 	}).SetupWithManager(mgr); err != nil {
@@ -223,3 +239,38 @@ func main() {
 		os.Exit(1)
 	}
 }
+
+// getInterfaceAddresses returns the first IPv4 and the first global unicast IPv6 address of the interface.
+func getInterfaceAddresses(name string) []string {
+	iface, err := net.InterfaceByName(name)
+	if err != nil {
+		return nil
+	}
+	addrs, _ := iface.Addrs()
+
+	var ipv4, ipv6 string
+	for _, addr := range addrs {
+		var ip net.IP
+		switch v := addr.(type) {
+		case *net.IPNet:
+			ip = v.IP
+		case *net.IPAddr:
+			ip = v.IP
+		}
+		if ip.To4() != nil {
+			if ipv4 == "" {
+				ipv4 = ip.To4().String()
+			}
+		} else if ip.IsGlobalUnicast() && ipv6 == "" {
+			ipv6 = ip.String()
+		}
+	}
+
+	var addresses []string
+	for _, ip := range []string{ipv4, ipv6} {
+		if ip != "" {
+			addresses = append(addresses, ip)
+		}
+	}
+	return addresses
+}
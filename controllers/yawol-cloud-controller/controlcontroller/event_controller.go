@@ -2,13 +2,13 @@ package controlcontroller
 
 import (
 	"context"
-	"strings"
 
 	"github.com/go-logr/logr"
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 	"github.com/stackitcloud/yawol/controllers/yawol-cloud-controller/targetcontroller"
 	"github.com/stackitcloud/yawol/internal/helper"
 	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
@@ -50,18 +50,26 @@ func (r *EventReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	// get svc from target cluster
-	svc := coreV1.Service{}
-	serviceParams := strings.Split(lb.Annotations[targetcontroller.ServiceAnnotation], "/")
-	if len(serviceParams) != 2 {
-		return ctrl.Result{}, helper.ErrCouldNotReadSvcNameSpacedNameFromAnno
-	}
-	if err := r.TargetClient.Get(ctx, client.ObjectKey{Name: serviceParams[1], Namespace: serviceParams[0]}, &svc); err != nil {
+	namespacedNames, err := targetcontroller.GetServiceNamespacedNames(&lb)
+	if err != nil {
 		return ctrl.Result{}, err
 	}
 
-	// forward event
-	r.Recorder.Event(&svc, event.Type, event.Reason, event.Message)
+	// forward event to all services of the lb
+	for _, namespacedName := range namespacedNames {
+		// get svc from target cluster
+		svc := coreV1.Service{}
+		if err := r.TargetClient.Get(ctx, namespacedName, &svc); err != nil {
+			if apierrors.IsNotFound(err) {
+				// service is removed from the lb by the service controller
+				continue
+			}
+			return ctrl.Result{}, err
+		}
+
+		// forward event
+		r.Recorder.Event(&svc, event.Type, event.Reason, event.Message)
+	}
 
 	return ctrl.Result{}, nil
 }
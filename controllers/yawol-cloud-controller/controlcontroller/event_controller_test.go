@@ -99,5 +99,74 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 				return helper.ErrSvcEventNotFound
 			}, time.Second*15, time.Millisecond*500).Should(Succeed())
 		})
+
+		It("forward events to the remaining services of a LB with a missing service", func() {
+			By("create service and LB with a missing service")
+			service = v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "event-test2",
+					Namespace: "default"},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   31002,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			lb = yawolv1beta1.LoadBalancer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "default--event-shared-test",
+					Namespace: "default",
+					Annotations: map[string]string{
+						targetcontroller.ServiceAnnotation: "default/event-missing,default/event-test2",
+					},
+				},
+				Spec: yawolv1beta1.LoadBalancerSpec{
+					Replicas: 1,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+
+			By("create event")
+			event := v1.Event{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "event-shared-test",
+					Namespace: "default"},
+				InvolvedObject: v1.ObjectReference{
+					Kind:       "LoadBalancer",
+					Namespace:  lb.Namespace,
+					Name:       lb.Name,
+					UID:        lb.UID,
+					APIVersion: lb.APIVersion,
+				},
+				Message: "shared test message",
+				Type:    v1.EventTypeNormal,
+				Reason:  "reason",
+				Source:  v1.EventSource{Component: EventSource},
+			}
+			Expect(k8sClient.Create(ctx, &event)).Should(Succeed())
+
+			Eventually(func() error {
+				var curEvents v1.EventList
+				err := k8sClient.List(ctx, &curEvents)
+				if err != nil {
+					return err
+				}
+				for _, curEvent := range curEvents.Items {
+					if curEvent.InvolvedObject.UID == service.UID &&
+						curEvent.Message == event.Message {
+						return nil
+					}
+				}
+				return helper.ErrSvcEventNotFound
+			}, time.Second*15, time.Millisecond*500).Should(Succeed())
+		})
 	})
 })
@@ -11,8 +11,8 @@ import (
 	"github.com/stackitcloud/yawol/controllers/yawol-cloud-controller/targetcontroller"
 	"github.com/stackitcloud/yawol/internal/helper"
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
-	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -36,28 +36,38 @@ func (r *LoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	namespacedName := types.NamespacedName{}
-	if service := strings.Split(lb.Annotations[targetcontroller.ServiceAnnotation], "/"); len(service) == 2 {
-		namespacedName.Namespace = service[0]
-		namespacedName.Name = service[1]
-	} else {
-		return ctrl.Result{}, helper.ErrCouldNotReadSvcNameSpacedNameFromAnno
+	namespacedNames, err := targetcontroller.GetServiceNamespacedNames(lb)
+	if err != nil {
+		return ctrl.Result{}, err
 	}
 
-	svc := &v1.Service{}
-	if err := r.TargetClient.Get(ctx, namespacedName, svc); err != nil {
-		r.Log.Error(err, "could not retrieve svc")
-		return ctrl.Result{}, err
+	// update externalIP in services if lb has ready replicas
+	if lb.Status.ExternalIP == nil || lb.Status.ReadyReplicas == nil || *lb.Status.ReadyReplicas == 0 {
+		return ctrl.Result{}, nil
 	}
 
-	// update externalIP in service if lb has ready replicas
-	if lb.Status.ExternalIP != nil && lb.Status.ReadyReplicas != nil && *lb.Status.ReadyReplicas > 0 {
-		loadBalancerStatus := v1.LoadBalancerStatus{
-			Ingress: []v1.LoadBalancerIngress{
-				{
-					IP: *lb.Status.ExternalIP,
-				},
-			}}
+	loadBalancerStatus := v1.LoadBalancerStatus{}
+	// externalIPs contain the ips of all ip families, fallback to externalIP if not set yet
+	externalIPs := lb.Status.ExternalIPs
+	if len(externalIPs) == 0 {
+		externalIPs = []string{*lb.Status.ExternalIP}
+	}
+	for _, ip := range externalIPs {
+		loadBalancerStatus.Ingress = append(loadBalancerStatus.Ingress, v1.LoadBalancerIngress{IP: ip})
+	}
+
+	var requeue bool
+	// services sharing an ip all get the status of the lb
+	for _, namespacedName := range namespacedNames {
+		svc := &v1.Service{}
+		if err := r.TargetClient.Get(ctx, namespacedName, svc); err != nil {
+			if apierrors.IsNotFound(err) {
+				// service is removed from the lb by the service controller
+				continue
+			}
+			r.Log.Error(err, "could not retrieve svc")
+			return ctrl.Result{}, err
+		}
 
 		if !reflect.DeepEqual(loadBalancerStatus, svc.Status.LoadBalancer) {
 			err := helper.PatchServiceStatus(ctx, r.TargetClient.Status(), svc, &v1.ServiceStatus{LoadBalancer: loadBalancerStatus})
@@ -67,12 +77,12 @@ func (r *LoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 			r.Recorder.Event(svc,
 				v1.EventTypeNormal,
 				"creation",
-				fmt.Sprintf("LoadBalancer is successfully created with IP %v", *lb.Status.ExternalIP))
-			return ctrl.Result{Requeue: true}, nil
+				fmt.Sprintf("LoadBalancer is successfully created with IP %v", strings.Join(externalIPs, ", ")))
+			requeue = true
 		}
 	}
 
-	return ctrl.Result{}, nil
+	return ctrl.Result{Requeue: requeue}, nil
 }
 
 func (r *LoadBalancerReconciler) SetupWithManager(mgr ctrl.Manager) error {
@@ -175,5 +175,133 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 
 		})
 
+		It("create service and lb - dual-stack lb - check external IPs", func() {
+			By("create service")
+			service = v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test3",
+					Namespace: "default"},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30003,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+			replicas := 1
+			externalIP := "123.123.123.123"
+			externalIPs := []string{externalIP, "fd00::1"}
+			lb = yawolv1beta1.LoadBalancer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "default--service-test3",
+					Namespace: "default",
+					Annotations: map[string]string{
+						targetcontroller.ServiceAnnotation: "default/service-test3",
+					},
+				},
+				Spec: yawolv1beta1.LoadBalancerSpec{
+					Selector: metav1.LabelSelector{},
+					Replicas: 1,
+					Options: yawolv1beta1.LoadBalancerOptions{
+						InternalLB: false,
+						IPFamilies: []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol},
+					},
+					Endpoints:      nil,
+					Ports:          nil,
+					Infrastructure: yawolv1beta1.LoadBalancerInfrastructure{},
+				}}
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+			lb.Status = yawolv1beta1.LoadBalancerStatus{
+				ReadyReplicas: &replicas,
+				Replicas:      &replicas,
+				ExternalIP:    &externalIP,
+				ExternalIPs:   externalIPs,
+			}
+			Expect(k8sClient.Status().Update(ctx, &lb)).Should(Succeed())
+
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "service-test3", Namespace: "default"}, &service)
+				if err != nil {
+					return err
+				}
+				if len(service.Status.LoadBalancer.Ingress) == 2 &&
+					service.Status.LoadBalancer.Ingress[0].IP == externalIPs[0] &&
+					service.Status.LoadBalancer.Ingress[1].IP == externalIPs[1] {
+					return nil
+				}
+				return helper.ErrIPNotInStatus
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("create two services and a shared lb - check external IP on both services", func() {
+			By("create services")
+			for i, name := range []string{"service-test4", "service-test5"} {
+				service = v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: "default"},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{
+							{
+								Name:       "port1",
+								Protocol:   v1.ProtocolTCP,
+								Port:       int32(12345 + i),
+								TargetPort: intstr.IntOrString{IntVal: 12345},
+								NodePort:   int32(30004 + i),
+							},
+						},
+						Type: "LoadBalancer",
+					}}
+				Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+			}
+			replicas := 1
+			externalIP := "123.123.123.124"
+			lb = yawolv1beta1.LoadBalancer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "default--shared--test",
+					Namespace: "default",
+					Annotations: map[string]string{
+						targetcontroller.ServiceAnnotation: "default/service-test4,default/service-test5",
+					},
+				},
+				Spec: yawolv1beta1.LoadBalancerSpec{
+					Selector: metav1.LabelSelector{},
+					Replicas: 1,
+					Options: yawolv1beta1.LoadBalancerOptions{
+						InternalLB: false,
+					},
+					Endpoints:      nil,
+					Ports:          nil,
+					Infrastructure: yawolv1beta1.LoadBalancerInfrastructure{},
+				}}
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+			lb.Status = yawolv1beta1.LoadBalancerStatus{
+				ReadyReplicas: &replicas,
+				Replicas:      &replicas,
+				ExternalIP:    &externalIP,
+			}
+			Expect(k8sClient.Status().Update(ctx, &lb)).Should(Succeed())
+
+			for _, name := range []string{"service-test4", "service-test5"} {
+				Eventually(func() error {
+					err := k8sClient.Get(ctx, types.NamespacedName{Name: name, Namespace: "default"}, &service)
+					if err != nil {
+						return err
+					}
+					if len(service.Status.LoadBalancer.Ingress) == 1 &&
+						service.Status.LoadBalancer.Ingress[0].IP == externalIP {
+						return nil
+					}
+					return helper.ErrIPNotInStatus
+				}, time.Second*5, time.Millisecond*500).Should(Succeed())
+			}
+		})
+
 	})
 })
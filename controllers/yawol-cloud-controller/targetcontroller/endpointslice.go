@@ -0,0 +1,118 @@
+package targetcontroller
+
+import (
+	"sort"
+
+	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+
+	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getServiceRequestForEndpointSlice maps an EndpointSlice to the request of its service
+func getServiceRequestForEndpointSlice(obj client.Object) []reconcile.Request {
+	serviceName := obj.GetLabels()[discoveryV1.LabelServiceName]
+	if serviceName == "" {
+		return nil
+	}
+	return []reconcile.Request{{
+		NamespacedName: types.NamespacedName{
+			Namespace: obj.GetNamespace(),
+			Name:      serviceName,
+		},
+	}}
+}
+
+// getReadyEndpointsFromEndpointSlices returns the ready pods of the EndpointSlices as endpoints.
+// Addresses of the same pod from EndpointSlices of different ip families are merged into one endpoint.
+func getReadyEndpointsFromEndpointSlices(
+	endpointSlices []discoveryV1.EndpointSlice,
+	ipFamilies []coreV1.IPFamily,
+) []yawolv1beta1.LoadBalancerEndpoint {
+	epsByName := map[string]*yawolv1beta1.LoadBalancerEndpoint{}
+	for i := range endpointSlices {
+		if !isAddressTypeInIPFamilies(endpointSlices[i].AddressType, ipFamilies) {
+			continue
+		}
+
+		ports := getLoadBalancerEndpointPorts(endpointSlices[i].Ports)
+		for _, endpoint := range endpointSlices[i].Endpoints {
+			// unknown ready state should be interpreted as ready
+			if endpoint.Conditions.Ready != nil && !*endpoint.Conditions.Ready {
+				continue
+			}
+			if len(endpoint.Addresses) == 0 {
+				continue
+			}
+
+			name := endpoint.Addresses[0]
+			if endpoint.TargetRef != nil && endpoint.TargetRef.Name != "" {
+				name = endpoint.TargetRef.Name
+			}
+
+			ep, found := epsByName[name]
+			if !found {
+				ep = &yawolv1beta1.LoadBalancerEndpoint{
+					Name:      name,
+					Addresses: []string{},
+					Ports:     ports,
+				}
+				epsByName[name] = ep
+			}
+			ep.Addresses = append(ep.Addresses, endpoint.Addresses...)
+		}
+	}
+
+	eps := make([]yawolv1beta1.LoadBalancerEndpoint, 0, len(epsByName))
+	for _, ep := range epsByName {
+		eps = append(eps, *ep)
+	}
+	sort.Slice(eps, func(i, j int) bool {
+		return eps[i].Name < eps[j].Name
+	})
+
+	return eps
+}
+
+func getLoadBalancerEndpointPorts(endpointPorts []discoveryV1.EndpointPort) []yawolv1beta1.LoadBalancerEndpointPort {
+	ports := make([]yawolv1beta1.LoadBalancerEndpointPort, 0, len(endpointPorts))
+	for _, endpointPort := range endpointPorts {
+		if endpointPort.Port == nil {
+			continue
+		}
+
+		port := yawolv1beta1.LoadBalancerEndpointPort{
+			Protocol: coreV1.ProtocolTCP,
+			Port:     *endpointPort.Port,
+		}
+		if endpointPort.Name != nil {
+			port.Name = *endpointPort.Name
+		}
+		if endpointPort.Protocol != nil {
+			port.Protocol = *endpointPort.Protocol
+		}
+		ports = append(ports, port)
+	}
+	return ports
+}
+
+func isAddressTypeInIPFamilies(addressType discoveryV1.AddressType, ipFamilies []coreV1.IPFamily) bool {
+	if addressType != discoveryV1.AddressTypeIPv4 && addressType != discoveryV1.AddressTypeIPv6 {
+		return false
+	}
+
+	// this should never happen since k8s autofills this field if it is nil
+	if len(ipFamilies) == 0 {
+		return true
+	}
+
+	for _, ipFamily := range ipFamilies {
+		if string(ipFamily) == string(addressType) {
+			return true
+		}
+	}
+	return false
+}
@@ -4,13 +4,13 @@ import (
 	"context"
 	"encoding/json"
 	"regexp"
-	"strings"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 	"github.com/stackitcloud/yawol/internal/helper"
 
 	"github.com/go-logr/logr"
 	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
@@ -51,15 +51,32 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 	}
 
 	for i := range loadBalancers.Items {
-		// get svc
-		svc := coreV1.Service{}
-		serviceAnnotation := strings.Split(loadBalancers.Items[i].Annotations[ServiceAnnotation], "/")
-		if len(serviceAnnotation) != 2 {
-			return ctrl.Result{}, helper.ErrCouldNotReadSvcNameSpacedNameFromAnno
-		}
-		if err := r.TargetClient.Get(ctx, client.ObjectKey{Name: serviceAnnotation[1], Namespace: serviceAnnotation[0]}, &svc); err != nil {
+		// get svcs, services sharing an ip have the same options
+		namespacedNames, err := GetServiceNamespacedNames(&loadBalancers.Items[i])
+		if err != nil {
 			return ctrl.Result{}, err
 		}
+		svcs := make([]coreV1.Service, 0, len(namespacedNames))
+		for j := range namespacedNames {
+			var svc coreV1.Service
+			if err := r.TargetClient.Get(ctx, namespacedNames[j], &svc); err != nil {
+				if apierrors.IsNotFound(err) {
+					// service is removed from the lb by the service controller
+					continue
+				}
+				return ctrl.Result{}, err
+			}
+			svcs = append(svcs, svc)
+		}
+		if len(svcs) == 0 {
+			continue
+		}
+		svc := svcs[0]
+
+		// pod endpoints are synced from the EndpointSlices by the service controller
+		if helper.GetOptions(&svc).PodEndpoints {
+			continue
+		}
 
 		readyEndpoints := getReadyEndpointsFromNodes(nodes.Items, svc.Spec.IPFamilies)
 
@@ -69,7 +86,9 @@ func (r *NodeReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.
 				return ctrl.Result{}, err
 			}
 
-			r.Recorder.Event(&svc, coreV1.EventTypeNormal, "update", "LoadBalancer endpoints successfully synced with nodes addresses")
+			for j := range svcs {
+				r.Recorder.Event(&svcs[j], coreV1.EventTypeNormal, "update", "LoadBalancer endpoints successfully synced with nodes addresses")
+			}
 		}
 	}
 
@@ -238,5 +238,58 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 				return nil
 			}, time.Second*15, time.Millisecond*500).Should(Succeed())
 		})
+
+		It("sync nodes to a LB with a missing service", func() {
+			By("create service and LB with a missing service")
+			// the service belongs to another class to keep the service controller from changing the LB
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "node-test2",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceClassName: "wrongclassname",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{{
+						Protocol: v1.ProtocolTCP,
+						Port:     8124,
+					}},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			lbNN := types.NamespacedName{Name: "default--node-shared-test", Namespace: "default"}
+			lb = yawolv1beta1.LoadBalancer{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      lbNN.Name,
+					Namespace: lbNN.Namespace,
+					Annotations: map[string]string{
+						ServiceAnnotation: "default/node-missing,default/node-test2",
+					},
+				},
+				Spec: yawolv1beta1.LoadBalancerSpec{
+					Replicas: 1,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+
+			By("trigger node sync")
+			node := v1.Node{}
+			Expect(k8sClient.Get(ctx, types.NamespacedName{Name: "node2"}, &node)).Should(Succeed())
+			node.Labels = map[string]string{"yawol.stackit.cloud/test": "missing-service"}
+			Expect(k8sClient.Update(ctx, &node)).Should(Succeed())
+
+			By("check nodes in LB")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, lbNN, &lb); err != nil {
+					return err
+				}
+				if len(lb.Spec.Endpoints) == 0 {
+					return helper.ErrNoEndpointFound
+				}
+				return nil
+			}, time.Second*15, time.Millisecond*500).Should(Succeed())
+		})
 	})
 })
@@ -6,7 +6,9 @@ import (
 
 	"encoding/base32"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"net"
 	"reflect"
 	"sort"
 	"strconv"
@@ -19,19 +21,25 @@ import (
 
 	"github.com/go-logr/logr"
 	coreV1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
 	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/runtime"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 )
 
 const (
 	ServiceFinalizer      = "stackit.cloud/loadbalancer"
 	ServiceAnnotation     = "yawol.stackit.cloud/serviceName"
 	LoadBalancerLabelName = "yawol.stackit.cloud/loadbalancer"
+	// SharedIPKeyLabel contains the sharing key of a LoadBalancer shared by multiple services
+	SharedIPKeyLabel = "yawol.stackit.cloud/sharedIPKey"
 )
 
 // ServiceReconciler reconciles service Objects with type LoadBalancer
@@ -43,9 +51,14 @@ type ServiceReconciler struct {
 	Scheme                 *runtime.Scheme
 	Recorder               record.EventRecorder
 	ClassName              string
+	LoadBalancerClass      string
+	// TargetAPIReader reads the TLS secrets of the services without caching the secrets of the target cluster
+	TargetAPIReader client.Reader
 }
 
 // +kubebuilder:rbac:groups=core,resources=services,verbs=get;list;watch
+// +kubebuilder:rbac:groups=discovery.k8s.io,resources=endpointslices,verbs=get;list;watch
+// +kubebuilder:rbac:groups=core,resources=secrets,verbs=get;list;watch
 func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Result, error) {
 	_ = r.Log.WithValues("service", req.NamespacedName)
 
@@ -58,20 +71,16 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, client.IgnoreNotFound(err)
 	}
 
-	className, ok := svc.Annotations[yawolv1beta1.ServiceClassName]
-	if !ok {
-		className = ""
-	}
-
 	infraDefaults := GetMergedInfrastructureDetails(r.InfrastructureDefaults, svc)
 
-	if className != r.ClassName {
+	if !r.isServiceClassMatching(svc) {
 		r.Log.WithValues("service", req.NamespacedName).Info("service and controller classname does not match")
-		if err := r.ControlClient.Get(ctx, types.NamespacedName{
-			Namespace: *infraDefaults.Namespace,
-			Name:      req.Namespace + "--" + req.Name,
-		}, &yawolv1beta1.LoadBalancer{}); err != nil {
-			return ctrl.Result{}, client.IgnoreNotFound(err)
+		if err := r.ControlClient.Get(ctx, getLoadBalancerNamespacedName(&infraDefaults, svc), &yawolv1beta1.LoadBalancer{}); err != nil {
+			if client.IgnoreNotFound(err) != nil {
+				return ctrl.Result{}, err
+			}
+			// the lb is already gone, only the finalizer has to be removed on deletion
+			return ctrl.Result{}, r.removeFinalizerOfOtherClass(ctx, svc)
 		}
 
 		// only trigger deletion routine if the lb still exists
@@ -99,12 +108,40 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("validation failed: %v", err), svc)
 	}
 
+	lbNN := getLoadBalancerNamespacedName(&infraDefaults, svc)
+
+	// services with a sharing key share the lb with all matching services of the namespace
+	lbServices := []types.NamespacedName{req.NamespacedName}
+	lbPorts := svc.Spec.Ports
+	lbRoutes := helper.GetSNIRoutesFromService(svc)
+	if helper.GetSharedIPKey(svc) != "" {
+		lbServices, lbPorts, lbRoutes, err = r.getSharedIPServicesAndPorts(ctx, svc)
+		if err != nil && !errors.Is(err, helper.ErrSharedIPPortCollision) &&
+			!errors.Is(err, helper.ErrSharedIPSettingsMismatch) && !errors.Is(err, helper.ErrSharedIPNotSupported) {
+			return ctrl.Result{}, err
+		}
+		if err != nil {
+			// the service is not part of the shared lb, remove it in case it was added before
+			if _, removeErr := r.removeServiceFromLoadBalancers(ctx, svc, infraDefaults, types.NamespacedName{}); removeErr != nil {
+				return ctrl.Result{}, removeErr
+			}
+			if len(svc.Status.LoadBalancer.Ingress) != 0 {
+				if removeErr := r.removeIngressIPFromStatus(ctx, svc); removeErr != nil {
+					return ctrl.Result{}, removeErr
+				}
+			}
+			return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("ip sharing failed: %w", err), svc)
+		}
+	}
+
+	// remove the service from lbs it does not belong to anymore, e.g. after the sharing key changed
+	if _, err = r.removeServiceFromLoadBalancers(ctx, svc, infraDefaults, lbNN); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	loadBalancer := &yawolv1beta1.LoadBalancer{}
 
-	err = r.ControlClient.Get(ctx, types.NamespacedName{
-		Namespace: *infraDefaults.Namespace,
-		Name:      req.Namespace + "--" + req.Name,
-	}, loadBalancer)
+	err = r.ControlClient.Get(ctx, lbNN, loadBalancer)
 	if client.IgnoreNotFound(err) != nil {
 		return ctrl.Result{}, err
 	}
@@ -114,7 +151,7 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 			return ctrl.Result{}, kubernetes.RemoveFinalizerIfNeeded(ctx, r.TargetClient, svc, ServiceFinalizer)
 		}
 
-		if err = r.createLoadBalancer(ctx, req.NamespacedName, svc, infraDefaults); err != nil {
+		if err = r.createLoadBalancer(ctx, lbServices, svc, infraDefaults); err != nil {
 			return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.Recorder, err, svc)
 		}
 		r.Recorder.Event(svc, coreV1.EventTypeNormal, "creation", "LoadBalancer is in creation")
@@ -122,15 +159,15 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{Requeue: true}, nil
 	}
 
-	if loadBalancer.ObjectMeta.Annotations[ServiceAnnotation] != svc.Namespace+"/"+svc.Name {
-		if err := r.addAnnotation(ctx, loadBalancer, ServiceAnnotation, svc.Namespace+"/"+svc.Name); err != nil {
+	if serviceNames := getServiceAnnotationValue(lbServices); loadBalancer.ObjectMeta.Annotations[ServiceAnnotation] != serviceNames {
+		if err := r.addAnnotation(ctx, loadBalancer, ServiceAnnotation, serviceNames); err != nil {
 			return ctrl.Result{}, err
 		}
 		return ctrl.Result{Requeue: true}, err
 	}
 
 	// if port specs differ, patch svc => lb
-	err = r.reconcilePorts(ctx, loadBalancer, svc)
+	err = r.reconcilePorts(ctx, loadBalancer, svc, lbPorts, lbRoutes)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -141,8 +178,12 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
-	// if endpoints differ to ready nodes, patch node => endpoints
-	err = r.reconcileNodes(ctx, loadBalancer, svc)
+	// if endpoints differ to ready nodes or pods, patch node/pod => endpoints
+	if helper.GetOptions(svc).PodEndpoints {
+		err = r.reconcileEndpointSlices(ctx, loadBalancer, svc)
+	} else {
+		err = r.reconcileNodes(ctx, loadBalancer, svc)
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -157,6 +198,11 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	err = r.reconcileTLSSecret(ctx, loadBalancer, svc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	err = r.reconcileOptions(ctx, loadBalancer, svc)
 	if err != nil {
 		return ctrl.Result{}, err
@@ -167,30 +213,50 @@ func (r *ServiceReconciler) Reconcile(ctx context.Context, req ctrl.Request) (ct
 		return ctrl.Result{}, err
 	}
 
+	err = r.reconcileLoadBalancerIP(ctx, loadBalancer, svc)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	return ctrl.Result{}, nil
 }
 
 func (r *ServiceReconciler) SetupWithManager(mgr ctrl.Manager) error {
 	return ctrl.NewControllerManagedBy(mgr).
 		For(&coreV1.Service{}).
+		Watches(
+			&source.Kind{Type: &discoveryV1.EndpointSlice{}},
+			handler.EnqueueRequestsFromMapFunc(getServiceRequestForEndpointSlice),
+		).
+		Watches(
+			&source.Kind{Type: &coreV1.Secret{}},
+			handler.EnqueueRequestsFromMapFunc(r.getServiceRequestsForSecret),
+			// only the metadata of the secrets is cached, the TLS secrets are read with the TargetAPIReader
+			builder.OnlyMetadata,
+		).
 		Complete(r)
 }
 
 func (r *ServiceReconciler) createLoadBalancer(
 	ctx context.Context,
-	namespacedName types.NamespacedName,
+	services []types.NamespacedName,
 	svc *coreV1.Service,
 	infraConfig InfrastructureDefaults,
 ) error {
-	hash := sha256.Sum256([]byte(*infraConfig.Namespace + "." + namespacedName.Namespace + "--" + namespacedName.Name))
-	hashstring := strings.ToLower(base32.StdEncoding.EncodeToString(hash[:]))[:16]
 	lbNN := getLoadBalancerNamespacedName(&infraConfig, svc)
+	hash := sha256.Sum256([]byte(*infraConfig.Namespace + "." + lbNN.Name))
+	hashstring := strings.ToLower(base32.StdEncoding.EncodeToString(hash[:]))[:16]
+	var labels map[string]string
+	if key := helper.GetSharedIPKey(svc); key != "" {
+		labels = map[string]string{SharedIPKeyLabel: key}
+	}
 	loadBalancer := yawolv1beta1.LoadBalancer{
 		ObjectMeta: v1.ObjectMeta{
 			Namespace: lbNN.Namespace,
 			Name:      lbNN.Name,
+			Labels:    labels,
 			Annotations: map[string]string{
-				ServiceAnnotation: namespacedName.String(),
+				ServiceAnnotation: getServiceAnnotationValue(services),
 			},
 		},
 		Spec: yawolv1beta1.LoadBalancerSpec{
@@ -201,6 +267,7 @@ func (r *ServiceReconciler) createLoadBalancer(
 				},
 			},
 			ExistingFloatingIP: helper.GetExistingFloatingIPFromAnnotation(svc),
+			LoadBalancerIP:     helper.GetLoadBalancerIP(svc),
 			Infrastructure: yawolv1beta1.LoadBalancerInfrastructure{
 				FloatingNetID:    infraConfig.FloatingNetworkID,
 				NetworkID:        *infraConfig.NetworkID,
@@ -262,9 +329,11 @@ func (r *ServiceReconciler) reconcilePorts(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
 	svc *coreV1.Service,
+	ports []coreV1.ServicePort,
+	routes []yawolv1beta1.LoadBalancerSNIRoute,
 ) error {
-	if !reflect.DeepEqual(lb.Spec.Ports, svc.Spec.Ports) {
-		if err := r.patchLoadBalancerPorts(ctx, lb, svc.Spec.Ports); err != nil {
+	if !reflect.DeepEqual(lb.Spec.Ports, ports) || !reflect.DeepEqual(lb.Spec.SNIRoutes, routes) {
+		if err := r.patchLoadBalancerPorts(ctx, lb, ports, routes); err != nil {
 			r.Log.WithValues("service", svc.Namespace).Error(err, "could not patch loadbalancer.spec.ports")
 			return err
 		}
@@ -312,6 +381,44 @@ func (r *ServiceReconciler) reconcileExistingFloatingIP(
 	return nil
 }
 
+// reconcileLoadBalancerIP syncs the service spec.loadBalancerIP to the LoadBalancer.
+// In contrast to the existingFloatingIP annotation the ip can be changed after creation,
+// the yawol-controller re-associates the LoadBalancer to the new address.
+func (r *ServiceReconciler) reconcileLoadBalancerIP(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	svc *coreV1.Service,
+) error {
+	loadBalancerIP := helper.GetLoadBalancerIP(svc)
+
+	if loadBalancerIP != nil {
+		if net.ParseIP(*loadBalancerIP) == nil {
+			return kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: %s", helper.ErrNotAValidIP, *loadBalancerIP), svc)
+		}
+
+		existingIP := helper.GetExistingFloatingIPFromAnnotation(svc)
+		if existingIP != nil && *existingIP != *loadBalancerIP {
+			return kubernetes.SendErrorAsEvent(r.Recorder, helper.ErrLoadBalancerIPConflict, svc)
+		}
+
+		if lb.Spec.Options.InternalLB && lb.Spec.Options.FixedIP != "" && lb.Spec.Options.FixedIP != *loadBalancerIP {
+			return kubernetes.SendErrorAsEvent(r.Recorder, helper.ErrFixedIPConflict, svc)
+		}
+	}
+
+	if reflect.DeepEqual(lb.Spec.LoadBalancerIP, loadBalancerIP) {
+		return nil
+	}
+
+	if err := r.patchLoadBalancerIP(ctx, lb, loadBalancerIP); err != nil {
+		r.Log.WithValues("service", svc.Name).Error(err, "could not patch loadbalancer.spec.loadBalancerIP")
+		return err
+	}
+	r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer loadBalancerIP successfully synced with service loadBalancerIP")
+
+	return nil
+}
+
 func (r *ServiceReconciler) reconcileReplicas(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -348,6 +455,29 @@ func (r *ServiceReconciler) reconcileNodes(
 	return nil
 }
 
+func (r *ServiceReconciler) reconcileEndpointSlices(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	svc *coreV1.Service,
+) error {
+	var endpointSlices discoveryV1.EndpointSliceList
+	if err := r.TargetClient.List(ctx, &endpointSlices,
+		client.InNamespace(svc.Namespace),
+		client.MatchingLabels{discoveryV1.LabelServiceName: svc.Name},
+	); err != nil {
+		return err
+	}
+	podEPs := getReadyEndpointsFromEndpointSlices(endpointSlices.Items, svc.Spec.IPFamilies)
+
+	if !EqualLoadBalancerEndpoints(lb.Spec.Endpoints, podEPs) {
+		if err := r.patchLoadBalancerEndpoints(ctx, lb, podEPs); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer endpoints successfully synced with pod addresses")
+	}
+	return nil
+}
+
 func (r *ServiceReconciler) reconcileOptions(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -377,11 +507,161 @@ func (r *ServiceReconciler) reconcileOptions(
 			return err
 		}
 		patch := []byte(`{"spec":{"options":{"tcpProxyProtocolPortFilter":` + string(data) + `}}}`)
-		return r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch))
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+	}
+	if newOptions.TCPProxyProtocolVersion != lb.Spec.Options.TCPProxyProtocolVersion ||
+		!reflect.DeepEqual(newOptions.TCPProxyProtocolPortVersions, lb.Spec.Options.TCPProxyProtocolPortVersions) {
+		version := "null"
+		if newOptions.TCPProxyProtocolVersion != 0 {
+			version = strconv.Itoa(int(newOptions.TCPProxyProtocolVersion))
+		}
+		portVersions, err := json.Marshal(newOptions.TCPProxyProtocolPortVersions)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"tcpProxyProtocolVersion":` + version +
+			`,"tcpProxyProtocolPortVersions":` + string(portVersions) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer TCPProxyProtocolVersion successfully synced with service annotation")
+	}
+	if newOptions.DownstreamProxyProtocol != lb.Spec.Options.DownstreamProxyProtocol ||
+		!reflect.DeepEqual(newOptions.DownstreamProxyProtocolPortsFilter, lb.Spec.Options.DownstreamProxyProtocolPortsFilter) {
+		data, err := json.Marshal(newOptions.DownstreamProxyProtocolPortsFilter)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"downstreamProxyProtocol":` + strconv.FormatBool(newOptions.DownstreamProxyProtocol) +
+			`,"downstreamProxyProtocolPortFilter":` + string(data) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer DownstreamProxyProtocol successfully synced with service annotations")
+	}
+	if !reflect.DeepEqual(newOptions.IPFamilies, lb.Spec.Options.IPFamilies) ||
+		!reflect.DeepEqual(newOptions.IPFamilyPolicy, lb.Spec.Options.IPFamilyPolicy) {
+		ipFamilies, err := json.Marshal(newOptions.IPFamilies)
+		if err != nil {
+			return err
+		}
+		ipFamilyPolicy, err := json.Marshal(newOptions.IPFamilyPolicy)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"ipFamilies":` + string(ipFamilies) + `,"ipFamilyPolicy":` + string(ipFamilyPolicy) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer IPFamilies successfully synced with service IPFamilies")
+	}
+	if newOptions.HealthCheckNodePort != lb.Spec.Options.HealthCheckNodePort {
+		patch := []byte(`{"spec":{"options":{"healthCheckNodePort":` + strconv.Itoa(int(newOptions.HealthCheckNodePort)) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer HealthCheckNodePort successfully synced with service HealthCheckNodePort")
+	}
+	if newOptions.PodEndpoints != lb.Spec.Options.PodEndpoints {
+		patch := []byte(`{"spec":{"options":{"podEndpoints":` + strconv.FormatBool(newOptions.PodEndpoints) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+	}
+	if newOptions.SubnetID != lb.Spec.Options.SubnetID ||
+		newOptions.FixedIP != lb.Spec.Options.FixedIP {
+		if newOptions.FixedIP != "" && net.ParseIP(newOptions.FixedIP) == nil {
+			return kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: %s", helper.ErrNotAValidIP, newOptions.FixedIP), svc)
+		}
+		subnetID, err := json.Marshal(newOptions.SubnetID)
+		if err != nil {
+			return err
+		}
+		fixedIP, err := json.Marshal(newOptions.FixedIP)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"subnetID":` + string(subnetID) + `,"fixedIP":` + string(fixedIP) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer SubnetID and FixedIP successfully synced with service annotations")
+	}
+	if !reflect.DeepEqual(newOptions.TLSPorts, lb.Spec.Options.TLSPorts) {
+		data, err := json.Marshal(newOptions.TLSPorts)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"tlsPorts":` + string(data) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer TLSPorts successfully synced with service annotation")
+	}
+	if newOptions.LoadBalancingPolicy != lb.Spec.Options.LoadBalancingPolicy ||
+		!reflect.DeepEqual(newOptions.PortLoadBalancingPolicies, lb.Spec.Options.PortLoadBalancingPolicies) {
+		policy := []byte("null")
+		if newOptions.LoadBalancingPolicy != "" {
+			var err error
+			if policy, err = json.Marshal(newOptions.LoadBalancingPolicy); err != nil {
+				return err
+			}
+		}
+		portPolicies, err := json.Marshal(newOptions.PortLoadBalancingPolicies)
+		if err != nil {
+			return err
+		}
+		patch := []byte(`{"spec":{"options":{"loadBalancingPolicy":` + string(policy) +
+			`,"portLoadBalancingPolicies":` + string(portPolicies) + `}}}`)
+		if err := r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch)); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer LoadBalancingPolicy successfully synced with service")
+	}
+	if !reflect.DeepEqual(newOptions.HealthCheck, lb.Spec.Options.HealthCheck) {
+		if err := r.replaceLoadBalancerOption(ctx, lb, "healthCheck", newOptions.HealthCheck, newOptions.HealthCheck == nil); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer HealthCheck successfully synced with service annotations")
+	}
+	if !reflect.DeepEqual(newOptions.Timeouts, lb.Spec.Options.Timeouts) {
+		if err := r.replaceLoadBalancerOption(ctx, lb, "timeouts", newOptions.Timeouts, newOptions.Timeouts == nil); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer Timeouts successfully synced with service annotations")
+	}
+	if !reflect.DeepEqual(newOptions.PortTimeouts, lb.Spec.Options.PortTimeouts) {
+		if err := r.replaceLoadBalancerOption(ctx, lb, "portTimeouts", newOptions.PortTimeouts, newOptions.PortTimeouts == nil); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer PortTimeouts successfully synced with service annotations")
 	}
 	return nil
 }
 
+// replaceLoadBalancerOption replaces an option of the LoadBalancer as a whole or removes the existing option,
+// a merge patch would keep the fields of an option which are not set anymore
+func (r *ServiceReconciler) replaceLoadBalancerOption(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	name string,
+	value interface{},
+	remove bool,
+) error {
+	path := "/spec/options/" + name
+	patch := []byte(`[{"op":"remove","path":"` + path + `"}]`)
+	if !remove {
+		data, err := json.Marshal(value)
+		if err != nil {
+			return err
+		}
+		patch = []byte(`[{"op":"add","path":"` + path + `","value":` + string(data) + `}]`)
+	}
+	return r.ControlClient.Patch(ctx, lb, client.RawPatch(types.JSONPatchType, patch))
+}
+
 func (r *ServiceReconciler) reconcileDebugSettings(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -410,12 +690,17 @@ func (r *ServiceReconciler) patchLoadBalancerPorts(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
 	svcPorts []coreV1.ServicePort,
+	sniRoutes []yawolv1beta1.LoadBalancerSNIRoute,
 ) error {
 	svcPortsJSON, err := json.Marshal(svcPorts)
 	if err != nil {
 		return err
 	}
-	patch := []byte(`{"spec":{"ports":` + string(svcPortsJSON) + `}}`)
+	sniRoutesJSON, err := json.Marshal(sniRoutes)
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"spec":{"ports":` + string(svcPortsJSON) + `,"sniRoutes":` + string(sniRoutesJSON) + `}}`)
 
 	return r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch))
 }
@@ -468,6 +753,20 @@ func (r *ServiceReconciler) patchExistingFloatingIP(
 	return r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch))
 }
 
+func (r *ServiceReconciler) patchLoadBalancerIP(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	ip *string,
+) error {
+	ipJSON, err := json.Marshal(ip)
+	if err != nil {
+		return err
+	}
+	patch := []byte(`{"spec":{"loadBalancerIP":` + string(ipJSON) + `}}`)
+
+	return r.ControlClient.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch))
+}
+
 func EqualLoadBalancerEndpoints(eps1, eps2 []yawolv1beta1.LoadBalancerEndpoint) bool {
 	sort.Slice(eps1, func(i, j int) bool {
 		return eps1[i].Name < eps1[j].Name
@@ -491,42 +790,47 @@ func (r *ServiceReconciler) deletionRoutine(
 	svc *coreV1.Service,
 	infraDefaults InfrastructureDefaults,
 ) (ctrl.Result, error) {
-	var loadBalancer yawolv1beta1.LoadBalancer
-	var err error
-	if err = r.ControlClient.Get(
-		ctx,
-		getLoadBalancerNamespacedName(&infraDefaults, svc),
-		&loadBalancer,
-	); client.IgnoreNotFound(err) != nil {
+	deleting, err := r.removeServiceFromLoadBalancers(ctx, svc, infraDefaults, types.NamespacedName{})
+	if err != nil {
 		return ctrl.Result{}, err
 	}
+	if deleting {
+		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	}
 
-	if apierrors.IsNotFound(err) {
-		className, ok := svc.Annotations[yawolv1beta1.ServiceClassName]
-		if !ok {
-			className = ""
-		}
-		if r.ClassName != className {
-			return ctrl.Result{}, nil
-		}
-
-		if len(svc.Status.LoadBalancer.Ingress) != 0 {
-			if err := r.removeIngressIPFromStatus(ctx, svc); err != nil {
-				return ctrl.Result{}, err
-			}
-			r.Log.Info("successfully deleted loadbalancer ip on svc status")
-		}
-		r.Log.Info("load balancer deleted")
-		return ctrl.Result{}, kubernetes.RemoveFinalizerIfNeeded(ctx, r.TargetClient, svc, ServiceFinalizer)
+	// the status belongs to the implementation of the new class
+	if !r.isServiceClassMatching(svc) {
+		return ctrl.Result{}, r.removeFinalizerOfOtherClass(ctx, svc)
 	}
 
-	if loadBalancer.DeletionTimestamp == nil {
-		if err := r.ControlClient.Delete(ctx, &loadBalancer, &client.DeleteOptions{}); err != nil {
+	if len(svc.Status.LoadBalancer.Ingress) != 0 {
+		if err := r.removeIngressIPFromStatus(ctx, svc); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+		r.Log.Info("successfully deleted loadbalancer ip on svc status")
+	}
+	r.Log.Info("load balancer deleted")
+	return ctrl.Result{}, kubernetes.RemoveFinalizerIfNeeded(ctx, r.TargetClient, svc, ServiceFinalizer)
+}
+
+// removeFinalizerOfOtherClass removes the finalizer from a deleted service which moved to another class.
+// The finalizer is kept as long as the service is not deleted, because other controllers may use the same
+// finalizer and would add it again, which results in an endless loop.
+func (r *ServiceReconciler) removeFinalizerOfOtherClass(ctx context.Context, svc *coreV1.Service) error {
+	if svc.DeletionTimestamp == nil {
+		return nil
+	}
+	return kubernetes.RemoveFinalizerIfNeeded(ctx, r.TargetClient, svc, ServiceFinalizer)
+}
+
+// isServiceClassMatching returns true if the service is handled by this controller.
+// The spec.loadBalancerClass takes precedence over the className annotation. Services with
+// a loadBalancerClass are only handled if it matches, otherwise they belong to another implementation.
+func (r *ServiceReconciler) isServiceClassMatching(svc *coreV1.Service) bool {
+	if svc.Spec.LoadBalancerClass != nil {
+		return r.LoadBalancerClass != "" && *svc.Spec.LoadBalancerClass == r.LoadBalancerClass
 	}
-	return ctrl.Result{RequeueAfter: 5 * time.Second}, nil
+	return svc.Annotations[yawolv1beta1.ServiceClassName] == r.ClassName
 }
 
 func (r *ServiceReconciler) addAnnotation(
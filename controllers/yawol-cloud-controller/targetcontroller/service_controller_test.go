@@ -4,10 +4,12 @@ import (
 	"context"
 	"fmt"
 	"net"
+	"reflect"
 	"strings"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	discoveryV1 "k8s.io/api/discovery/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
@@ -1454,6 +1456,1004 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 			}, time.Second*5, time.Millisecond*500).Should(Succeed())
 		})
 
+		It("should sync the healthCheckNodePort for externalTrafficPolicy local", func() {
+			By("creating a service with externalTrafficPolicy local")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test21",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30021,
+						},
+					},
+					Type:                  "LoadBalancer",
+					ExternalTrafficPolicy: v1.ServiceExternalTrafficPolicyTypeLocal,
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			Expect(service.Spec.HealthCheckNodePort).ShouldNot(BeZero())
+
+			By("check healthCheckNodePort in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test21", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.HealthCheckNodePort == service.Spec.HealthCheckNodePort {
+					return nil
+				}
+				return fmt.Errorf("wrong healthCheckNodePort %v", lb.Spec.Options.HealthCheckNodePort)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("update svc to externalTrafficPolicy cluster")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			service.Spec.ExternalTrafficPolicy = v1.ServiceExternalTrafficPolicyTypeCluster
+			service.Spec.HealthCheckNodePort = 0
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that healthCheckNodePort is removed from LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test21", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.HealthCheckNodePort == 0 {
+					return nil
+				}
+				return fmt.Errorf("healthCheckNodePort still set %v", lb.Spec.Options.HealthCheckNodePort)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync the loadBalancerIP", func() {
+			By("creating a service with loadBalancerIP")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test25",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30025,
+						},
+					},
+					Type:           "LoadBalancer",
+					LoadBalancerIP: "192.0.2.10",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check loadBalancerIP in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test25", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.LoadBalancerIP != nil && *lb.Spec.LoadBalancerIP == "192.0.2.10" {
+					return nil
+				}
+				return fmt.Errorf("wrong loadBalancerIP %v", lb.Spec.LoadBalancerIP)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("update loadBalancerIP in svc")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			service.Spec.LoadBalancerIP = "192.0.2.20"
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the new loadBalancerIP is synced to the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test25", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.LoadBalancerIP != nil && *lb.Spec.LoadBalancerIP == "192.0.2.20" {
+					return nil
+				}
+				return fmt.Errorf("wrong loadBalancerIP %v", lb.Spec.LoadBalancerIP)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync the subnetID and fixedIP annotations", func() {
+			By("creating a service with subnetID and fixedIP")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test26",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceInternalLoadbalancer: "true",
+						yawolv1beta1.ServiceSubnetID:             "subnet-id",
+						yawolv1beta1.ServiceFixedIP:              "10.1.0.10",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30026,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check subnetID and fixedIP in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test26", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.SubnetID == "subnet-id" && lb.Spec.Options.FixedIP == "10.1.0.10" {
+					return nil
+				}
+				return fmt.Errorf("wrong subnetID %v or fixedIP %v", lb.Spec.Options.SubnetID, lb.Spec.Options.FixedIP)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("remove fixedIP annotation from svc")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			delete(service.Annotations, yawolv1beta1.ServiceFixedIP)
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that only the subnetID is left in the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test26", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.SubnetID == "subnet-id" && lb.Spec.Options.FixedIP == "" {
+					return nil
+				}
+				return fmt.Errorf("wrong subnetID %v or fixedIP %v", lb.Spec.Options.SubnetID, lb.Spec.Options.FixedIP)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync the load balancing policies", func() {
+			By("creating a service with client ip session affinity and a port policy")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test33",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceLoadBalancingPolicy: "443:LEAST_REQUEST",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       443,
+							TargetPort: intstr.IntOrString{IntVal: 443},
+							NodePort:   30033,
+						},
+					},
+					SessionAffinity: v1.ServiceAffinityClientIP,
+					Type:            "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check that the session affinity is mapped to a source ip hash policy")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test33", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.LoadBalancingPolicy == yawolv1beta1.LoadBalancingPolicyMaglev &&
+					reflect.DeepEqual(lb.Spec.Options.PortLoadBalancingPolicies, []yawolv1beta1.LoadBalancerPortPolicy{
+						{Port: 443, Policy: yawolv1beta1.LoadBalancingPolicyLeastRequest},
+					}) {
+					return nil
+				}
+				return fmt.Errorf("wrong loadBalancingPolicy %v or portLoadBalancingPolicies %v",
+					lb.Spec.Options.LoadBalancingPolicy, lb.Spec.Options.PortLoadBalancingPolicies)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("set the default policy and remove the session affinity")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			service.Annotations[yawolv1beta1.ServiceLoadBalancingPolicy] = "RING_HASH"
+			service.Spec.SessionAffinity = v1.ServiceAffinityNone
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the port policy is removed")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test33", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.LoadBalancingPolicy == yawolv1beta1.LoadBalancingPolicyRingHash &&
+					lb.Spec.Options.PortLoadBalancingPolicies == nil {
+					return nil
+				}
+				return fmt.Errorf("wrong loadBalancingPolicy %v or portLoadBalancingPolicies %v",
+					lb.Spec.Options.LoadBalancingPolicy, lb.Spec.Options.PortLoadBalancingPolicies)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync the health check annotations", func() {
+			By("creating a service with health check annotations")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test34",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceHealthCheckType:                 "HTTP",
+						yawolv1beta1.ServiceHealthCheckHTTPPath:             "/ready",
+						yawolv1beta1.ServiceHealthCheckHTTPExpectedStatuses: "200,204",
+						yawolv1beta1.ServiceHealthCheckIntervalSeconds:      "10",
+						yawolv1beta1.ServiceHealthCheckUDPPort:              "30134",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolUDP,
+							Port:       53,
+							TargetPort: intstr.IntOrString{IntVal: 53},
+							NodePort:   30034,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check health check in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test34", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if reflect.DeepEqual(lb.Spec.Options.HealthCheck, &yawolv1beta1.LoadBalancerHealthCheck{
+					Type:                 yawolv1beta1.HealthCheckTypeHTTP,
+					IntervalSeconds:      10,
+					HTTPPath:             "/ready",
+					HTTPExpectedStatuses: []int32{200, 204},
+					UDPPort:              30134,
+				}) {
+					return nil
+				}
+				return fmt.Errorf("wrong health check %v", lb.Spec.Options.HealthCheck)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("remove the HTTP annotations")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			delete(service.Annotations, yawolv1beta1.ServiceHealthCheckType)
+			delete(service.Annotations, yawolv1beta1.ServiceHealthCheckHTTPPath)
+			delete(service.Annotations, yawolv1beta1.ServiceHealthCheckHTTPExpectedStatuses)
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the removed settings are removed from the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test34", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if reflect.DeepEqual(lb.Spec.Options.HealthCheck, &yawolv1beta1.LoadBalancerHealthCheck{
+					IntervalSeconds: 10,
+					UDPPort:         30134,
+				}) {
+					return nil
+				}
+				return fmt.Errorf("wrong health check %v", lb.Spec.Options.HealthCheck)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync and validate the timeout annotations", func() {
+			By("creating a service with timeout annotations")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test35",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceConnectTimeout: "10s,5432:30s",
+						yawolv1beta1.ServiceTCPIdleTimeout: "5432:0s",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       5432,
+							TargetPort: intstr.IntOrString{IntVal: 5432},
+							NodePort:   30035,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check timeouts in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test35", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if reflect.DeepEqual(lb.Spec.Options.Timeouts, &yawolv1beta1.LoadBalancerTimeouts{
+					ConnectTimeout: &metav1.Duration{Duration: 10 * time.Second},
+				}) && reflect.DeepEqual(lb.Spec.Options.PortTimeouts, []yawolv1beta1.LoadBalancerPortTimeouts{{
+					Port: 5432,
+					LoadBalancerTimeouts: yawolv1beta1.LoadBalancerTimeouts{
+						ConnectTimeout: &metav1.Duration{Duration: 30 * time.Second},
+						TCPIdleTimeout: &metav1.Duration{Duration: 0},
+					},
+				}}) {
+					return nil
+				}
+				return fmt.Errorf("wrong timeouts %v or port timeouts %v", lb.Spec.Options.Timeouts, lb.Spec.Options.PortTimeouts)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("set an invalid timeout")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			service.Annotations[yawolv1beta1.ServiceUDPIdleTimeout] = "0s"
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check for event on service")
+			Eventually(func() error {
+				eventList := v1.EventList{}
+				err := k8sClient.List(ctx, &eventList)
+				if err != nil {
+					return err
+				}
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == "service-test35" &&
+						event.InvolvedObject.Kind == "Service" &&
+						strings.Contains(event.Message, helper.ErrInvalidTimeout.Error()) {
+						return nil
+					}
+				}
+				return helper.ErrNoEventFound
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("remove the timeout annotations")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			delete(service.Annotations, yawolv1beta1.ServiceConnectTimeout)
+			delete(service.Annotations, yawolv1beta1.ServiceTCPIdleTimeout)
+			delete(service.Annotations, yawolv1beta1.ServiceUDPIdleTimeout)
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the timeouts are removed from the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test35", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.Timeouts == nil && lb.Spec.Options.PortTimeouts == nil {
+					return nil
+				}
+				return fmt.Errorf("wrong timeouts %v or port timeouts %v", lb.Spec.Options.Timeouts, lb.Spec.Options.PortTimeouts)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should sync the proxy protocol annotations", func() {
+			By("creating a service with proxy protocol annotations")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test36",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceTCPProxyProtocol:                   "true",
+						yawolv1beta1.ServiceTCPProxyProtocolVersion:            "1,5432:2",
+						yawolv1beta1.ServiceDownstreamProxyProtocol:            "true",
+						yawolv1beta1.ServiceDownstreamProxyProtocolPortsFilter: "5432",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       5432,
+							TargetPort: intstr.IntOrString{IntVal: 5432},
+							NodePort:   30036,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check proxy protocol options in LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test36", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.TCPProxyProtocolVersion != 1 ||
+					!reflect.DeepEqual(lb.Spec.Options.TCPProxyProtocolPortVersions, []yawolv1beta1.LoadBalancerPortProxyProtocolVersion{{
+						Port:    5432,
+						Version: 2,
+					}}) {
+					return fmt.Errorf("wrong proxy protocol versions %v %v",
+						lb.Spec.Options.TCPProxyProtocolVersion, lb.Spec.Options.TCPProxyProtocolPortVersions)
+				}
+				if !lb.Spec.Options.DownstreamProxyProtocol ||
+					!reflect.DeepEqual(lb.Spec.Options.DownstreamProxyProtocolPortsFilter, []int32{5432}) {
+					return fmt.Errorf("wrong downstream proxy protocol %v %v",
+						lb.Spec.Options.DownstreamProxyProtocol, lb.Spec.Options.DownstreamProxyProtocolPortsFilter)
+				}
+				return nil
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("set an invalid proxy protocol version")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			service.Annotations[yawolv1beta1.ServiceTCPProxyProtocolVersion] = "3"
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check for event on service")
+			Eventually(func() error {
+				eventList := v1.EventList{}
+				err := k8sClient.List(ctx, &eventList)
+				if err != nil {
+					return err
+				}
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == "service-test36" &&
+						event.InvolvedObject.Kind == "Service" &&
+						strings.Contains(event.Message, helper.ErrInvalidProxyProtocolVersion.Error()) {
+						return nil
+					}
+				}
+				return helper.ErrNoEventFound
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("remove the proxy protocol annotations")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			delete(service.Annotations, yawolv1beta1.ServiceTCPProxyProtocolVersion)
+			delete(service.Annotations, yawolv1beta1.ServiceDownstreamProxyProtocol)
+			delete(service.Annotations, yawolv1beta1.ServiceDownstreamProxyProtocolPortsFilter)
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the options are removed from the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test36", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if lb.Spec.Options.TCPProxyProtocolVersion == 0 &&
+					lb.Spec.Options.TCPProxyProtocolPortVersions == nil &&
+					!lb.Spec.Options.DownstreamProxyProtocol &&
+					lb.Spec.Options.DownstreamProxyProtocolPortsFilter == nil {
+					return nil
+				}
+				return fmt.Errorf("proxy protocol options not removed %v", lb.Spec.Options)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should copy the tls secret and sync the tls ports", func() {
+			By("creating a tls secret")
+			secret := v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test30-cert",
+					Namespace: "default",
+				},
+				Type: v1.SecretTypeTLS,
+				Data: map[string][]byte{
+					v1.TLSCertKey:       []byte("cert"),
+					v1.TLSPrivateKeyKey: []byte("key"),
+				},
+			}
+			Expect(k8sClient.Create(ctx, &secret)).Should(Succeed())
+
+			By("creating a service with tls port")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test30",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceTLSPorts:      "443",
+						yawolv1beta1.ServiceTLSSecretName: "service-test30-cert",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       443,
+							TargetPort: intstr.IntOrString{IntVal: 8080},
+							NodePort:   30030,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check tls ports and tls secret of the LB")
+			lbSecretName := types.NamespacedName{Name: "default--service-test30-tls", Namespace: "default"}
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test30", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if len(lb.Spec.Options.TLSPorts) != 1 || lb.Spec.Options.TLSPorts[0] != 443 {
+					return fmt.Errorf("wrong tlsPorts %v", lb.Spec.Options.TLSPorts)
+				}
+				var lbSecret v1.Secret
+				if err := k8sClient.Get(ctx, lbSecretName, &lbSecret); err != nil {
+					return err
+				}
+				if string(lbSecret.Data[v1.TLSCertKey]) != "cert" || string(lbSecret.Data[v1.TLSPrivateKeyKey]) != "key" {
+					return fmt.Errorf("wrong tls secret data %v", lbSecret.Data)
+				}
+				return nil
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("rotating the certificate")
+			secret.Data[v1.TLSCertKey] = []byte("new-cert")
+			secret.Data[v1.TLSPrivateKeyKey] = []byte("new-key")
+			Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+
+			By("check that the tls secret of the LB is updated")
+			Eventually(func() error {
+				var lbSecret v1.Secret
+				if err := k8sClient.Get(ctx, lbSecretName, &lbSecret); err != nil {
+					return err
+				}
+				if string(lbSecret.Data[v1.TLSCertKey]) != "new-cert" || string(lbSecret.Data[v1.TLSPrivateKeyKey]) != "new-key" {
+					return fmt.Errorf("wrong tls secret data %v", lbSecret.Data)
+				}
+				return nil
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("removing the tls annotations")
+			Expect(k8sClient.Get(ctx, client.ObjectKey{Name: service.Name, Namespace: service.Namespace}, &service)).Should(Succeed())
+			delete(service.Annotations, yawolv1beta1.ServiceTLSPorts)
+			delete(service.Annotations, yawolv1beta1.ServiceTLSSecretName)
+			Expect(k8sClient.Update(ctx, &service)).Should(Succeed())
+
+			By("check that the tls secret of the LB is deleted")
+			Eventually(func() error {
+				var lbSecret v1.Secret
+				err := k8sClient.Get(ctx, lbSecretName, &lbSecret)
+				if err == nil {
+					return fmt.Errorf("tls secret of the LB still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should share one lb between services with the same sharing key", func() {
+			newSharedService := func(name string, port, nodePort int32) v1.Service {
+				return v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:      name,
+						Namespace: "default",
+						Annotations: map[string]string{
+							yawolv1beta1.ServiceAllowSharedIP: "web",
+						},
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{
+							{
+								Name:       "port1",
+								Protocol:   v1.ProtocolTCP,
+								Port:       port,
+								TargetPort: intstr.IntOrString{IntVal: port},
+								NodePort:   nodePort,
+							},
+						},
+						Type: "LoadBalancer",
+					}}
+			}
+
+			By("creating two services with the same sharing key")
+			service1 := newSharedService("service-test27", 80, 30027)
+			sharedLB := types.NamespacedName{Name: helper.GetLoadBalancerNameFromService(&service1), Namespace: "default"}
+			Expect(k8sClient.Create(ctx, &service1)).Should(Succeed())
+			service2 := newSharedService("service-test28", 443, 30028)
+			Expect(k8sClient.Create(ctx, &service2)).Should(Succeed())
+
+			By("check that both services are merged into one LB")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sharedLB, &lb); err != nil {
+					return err
+				}
+				if lb.Annotations[ServiceAnnotation] != "default/service-test27,default/service-test28" {
+					return fmt.Errorf("wrong services %v", lb.Annotations[ServiceAnnotation])
+				}
+				if len(lb.Spec.Ports) != 2 || lb.Spec.Ports[0].Port != 80 || lb.Spec.Ports[1].Port != 443 {
+					return fmt.Errorf("wrong ports %v", lb.Spec.Ports)
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("check that no LB is created for the services themselves")
+			Consistently(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test28", Namespace: "default"}, &lb)
+			}, time.Second*2, time.Millisecond*500).ShouldNot(Succeed())
+
+			By("creating a service with a colliding port")
+			service3 := newSharedService("service-test29", 443, 30029)
+			Expect(k8sClient.Create(ctx, &service3)).Should(Succeed())
+
+			By("check that the colliding service is rejected")
+			Eventually(func() error {
+				eventList := v1.EventList{}
+				if err := k8sClient.List(ctx, &eventList); err != nil {
+					return err
+				}
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == "service-test29" &&
+						event.InvolvedObject.Kind == "Service" &&
+						strings.Contains(event.Message, helper.ErrSharedIPPortCollision.Error()) {
+						return nil
+					}
+				}
+				return fmt.Errorf("event for port collision not found")
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+			Expect(k8sClient.Get(ctx, sharedLB, &lb)).Should(Succeed())
+			Expect(lb.Annotations[ServiceAnnotation]).To(Equal("default/service-test27,default/service-test28"))
+
+			By("deleting the first service")
+			Expect(k8sClient.Delete(ctx, &service1)).Should(Succeed())
+
+			By("check that the LB is kept for the remaining service")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sharedLB, &lb); err != nil {
+					return err
+				}
+				if lb.Annotations[ServiceAnnotation] != "default/service-test28" {
+					return fmt.Errorf("wrong services %v", lb.Annotations[ServiceAnnotation])
+				}
+				if len(lb.Spec.Ports) != 1 || lb.Spec.Ports[0].Port != 443 {
+					return fmt.Errorf("wrong ports %v", lb.Spec.Ports)
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("deleting the remaining services")
+			Expect(k8sClient.Delete(ctx, &service2)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, &service3)).Should(Succeed())
+
+			By("check that the LB is deleted")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, sharedLB, &lb)
+				if err == nil {
+					return fmt.Errorf("loadbalancer still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, time.Second*20, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should not share the lb of a service named like the sharing key", func() {
+			newService := func(name string, nodePort int32, annotations map[string]string) v1.Service {
+				return v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Namespace:   "default",
+						Annotations: annotations,
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{
+							{
+								Name:       "port1",
+								Protocol:   v1.ProtocolTCP,
+								Port:       80,
+								TargetPort: intstr.IntOrString{IntVal: 80},
+								NodePort:   nodePort,
+							},
+						},
+						Type: "LoadBalancer",
+					}}
+			}
+
+			By("creating a service named like the old name of a shared LB and a service with a sharing key")
+			service1 := newService("shared--collision", 30038, nil)
+			Expect(k8sClient.Create(ctx, &service1)).Should(Succeed())
+			service2 := newService("service-test38", 30039, map[string]string{
+				yawolv1beta1.ServiceAllowSharedIP: "collision",
+			})
+			Expect(k8sClient.Create(ctx, &service2)).Should(Succeed())
+
+			singleLB := types.NamespacedName{Name: helper.GetLoadBalancerNameFromService(&service1), Namespace: "default"}
+			sharedLB := types.NamespacedName{Name: helper.GetLoadBalancerNameFromService(&service2), Namespace: "default"}
+			Expect(sharedLB).ToNot(Equal(singleLB))
+
+			By("check that each service has its own LB")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, singleLB, &lb); err != nil {
+					return err
+				}
+				if lb.Annotations[ServiceAnnotation] != "default/shared--collision" {
+					return fmt.Errorf("wrong services %v", lb.Annotations[ServiceAnnotation])
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sharedLB, &lb); err != nil {
+					return err
+				}
+				if lb.Annotations[ServiceAnnotation] != "default/service-test38" {
+					return fmt.Errorf("wrong services %v", lb.Annotations[ServiceAnnotation])
+				}
+				if lb.Labels[SharedIPKeyLabel] != "collision" {
+					return fmt.Errorf("wrong sharing key label %v", lb.Labels)
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("deleting the services")
+			Expect(k8sClient.Delete(ctx, &service1)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, &service2)).Should(Succeed())
+
+			By("check that both LBs are deleted")
+			for _, lbNN := range []types.NamespacedName{singleLB, sharedLB} {
+				Eventually(func() error {
+					err := k8sClient.Get(ctx, lbNN, &lb)
+					if err == nil {
+						return fmt.Errorf("loadbalancer %s still exists", lbNN)
+					}
+					return client.IgnoreNotFound(err)
+				}, time.Second*20, time.Millisecond*500).Should(Succeed())
+			}
+		})
+
+		It("should share one port between services with different sni hostnames", func() {
+			newSNIService := func(name string, nodePort int32, hostnames string) v1.Service {
+				annotations := map[string]string{
+					yawolv1beta1.ServiceAllowSharedIP: "sni",
+				}
+				if hostnames != "" {
+					annotations[yawolv1beta1.ServiceSNIHostnames] = hostnames
+				}
+				return v1.Service{
+					ObjectMeta: metav1.ObjectMeta{
+						Name:        name,
+						Namespace:   "default",
+						Annotations: annotations,
+					},
+					Spec: v1.ServiceSpec{
+						Ports: []v1.ServicePort{
+							{
+								Name:       "port1",
+								Protocol:   v1.ProtocolTCP,
+								Port:       443,
+								TargetPort: intstr.IntOrString{IntVal: 443},
+								NodePort:   nodePort,
+							},
+						},
+						Type: "LoadBalancer",
+					}}
+			}
+
+			By("creating a default service and a service with sni hostnames on the same port")
+			service1 := newSNIService("service-test31", 30031, "")
+			sharedLB := types.NamespacedName{Name: helper.GetLoadBalancerNameFromService(&service1), Namespace: "default"}
+			Expect(k8sClient.Create(ctx, &service1)).Should(Succeed())
+			service2 := newSNIService("service-test32", 30032, "b.example.com,*.c.example.com")
+			Expect(k8sClient.Create(ctx, &service2)).Should(Succeed())
+
+			By("check that the port is routed by sni")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sharedLB, &lb); err != nil {
+					return err
+				}
+				if lb.Annotations[ServiceAnnotation] != "default/service-test31,default/service-test32" {
+					return fmt.Errorf("wrong services %v", lb.Annotations[ServiceAnnotation])
+				}
+				if len(lb.Spec.Ports) != 1 || lb.Spec.Ports[0].NodePort != 30031 {
+					return fmt.Errorf("wrong ports %v", lb.Spec.Ports)
+				}
+				if !reflect.DeepEqual(lb.Spec.SNIRoutes, []yawolv1beta1.LoadBalancerSNIRoute{
+					{Port: 443, NodePort: 30031},
+					{Port: 443, Hostnames: []string{"b.example.com", "*.c.example.com"}, NodePort: 30032},
+				}) {
+					return fmt.Errorf("wrong sni routes %v", lb.Spec.SNIRoutes)
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("deleting the default service")
+			Expect(k8sClient.Delete(ctx, &service1)).Should(Succeed())
+
+			By("check that unknown server names are not routed anymore")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, sharedLB, &lb); err != nil {
+					return err
+				}
+				if len(lb.Spec.Ports) != 1 || lb.Spec.Ports[0].NodePort != 30032 {
+					return fmt.Errorf("wrong ports %v", lb.Spec.Ports)
+				}
+				if !reflect.DeepEqual(lb.Spec.SNIRoutes, []yawolv1beta1.LoadBalancerSNIRoute{
+					{Port: 443, Hostnames: []string{"b.example.com", "*.c.example.com"}, NodePort: 30032},
+				}) {
+					return fmt.Errorf("wrong sni routes %v", lb.Spec.SNIRoutes)
+				}
+				return nil
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("deleting the remaining service")
+			Expect(k8sClient.Delete(ctx, &service2)).Should(Succeed())
+
+			By("check that the LB is deleted")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, sharedLB, &lb)
+				if err == nil {
+					return fmt.Errorf("loadbalancer still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, time.Second*20, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should use the ready pods of the endpointslices as endpoints", func() {
+			By("creating a service with pod endpoints")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test22",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServicePodEndpoints: "true",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 8080},
+							NodePort:   30022,
+						},
+					},
+					Type: "LoadBalancer",
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("creating an endpointslice with a ready and a not ready pod")
+			endpointSlice := discoveryV1.EndpointSlice{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test22-abcde",
+					Namespace: "default",
+					Labels: map[string]string{
+						discoveryV1.LabelServiceName: "service-test22",
+					},
+				},
+				AddressType: discoveryV1.AddressTypeIPv4,
+				Endpoints: []discoveryV1.Endpoint{
+					{
+						Addresses:  []string{"10.0.0.1"},
+						Conditions: discoveryV1.EndpointConditions{Ready: pointer.Bool(true)},
+						TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: "pod1", Namespace: "default"},
+					},
+					{
+						Addresses:  []string{"10.0.0.2"},
+						Conditions: discoveryV1.EndpointConditions{Ready: pointer.Bool(false)},
+						TargetRef:  &v1.ObjectReference{Kind: "Pod", Name: "pod2", Namespace: "default"},
+					},
+				},
+				Ports: []discoveryV1.EndpointPort{{
+					Name:     pointer.String("port1"),
+					Protocol: (*v1.Protocol)(pointer.String(string(v1.ProtocolTCP))),
+					Port:     pointer.Int32(8080),
+				}},
+			}
+			Expect(k8sClient.Create(ctx, &endpointSlice)).Should(Succeed())
+
+			expectedPorts := []yawolv1beta1.LoadBalancerEndpointPort{{
+				Name:     "port1",
+				Protocol: v1.ProtocolTCP,
+				Port:     8080,
+			}}
+
+			By("check that only the ready pod is an endpoint of the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test22", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if !lb.Spec.Options.PodEndpoints {
+					return fmt.Errorf("podEndpoints not set in LB options")
+				}
+				if !EqualLoadBalancerEndpoints(lb.Spec.Endpoints, []yawolv1beta1.LoadBalancerEndpoint{
+					{Name: "pod1", Addresses: []string{"10.0.0.1"}, Ports: expectedPorts},
+				}) {
+					return fmt.Errorf("wrong endpoints %v", lb.Spec.Endpoints)
+				}
+				return nil
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("set the second pod ready")
+			Expect(k8sClient.Get(ctx, client.ObjectKeyFromObject(&endpointSlice), &endpointSlice)).Should(Succeed())
+			endpointSlice.Endpoints[1].Conditions.Ready = pointer.Bool(true)
+			Expect(k8sClient.Update(ctx, &endpointSlice)).Should(Succeed())
+
+			By("check that both pods are endpoints of the LB")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test22", Namespace: "default"}, &lb)
+				if err != nil {
+					return err
+				}
+				if !EqualLoadBalancerEndpoints(lb.Spec.Endpoints, []yawolv1beta1.LoadBalancerEndpoint{
+					{Name: "pod1", Addresses: []string{"10.0.0.1"}, Ports: expectedPorts},
+					{Name: "pod2", Addresses: []string{"10.0.0.2"}, Ports: expectedPorts},
+				}) {
+					return fmt.Errorf("wrong endpoints %v", lb.Spec.Endpoints)
+				}
+				return nil
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should not touch a service with a loadBalancerClass of another implementation", func() {
+			By("creating a service with another loadBalancerClass")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test23",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30023,
+						},
+					},
+					Type:              "LoadBalancer",
+					LoadBalancerClass: pointer.String("example.com/other-lb"),
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check that no LB is created and the service has no finalizer")
+			Consistently(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test23", Namespace: "default"}, &lb)
+				if !k8sErrors.IsNotFound(err) {
+					return fmt.Errorf("loadbalancer exists or unexpected error: %v", err)
+				}
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&service), &service); err != nil {
+					return err
+				}
+				if len(service.Finalizers) != 0 {
+					return fmt.Errorf("service has finalizers %v", service.Finalizers)
+				}
+				return nil
+			}, time.Second*3, time.Millisecond*500).Should(Succeed())
+		})
+
+		It("should prefer the loadBalancerClass over the className annotation", func() {
+			By("creating a service with matching loadBalancerClass and a different className")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test24",
+					Namespace: "default",
+					Annotations: map[string]string{
+						yawolv1beta1.ServiceClassName: "wrongclassname",
+					},
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30024,
+						},
+					},
+					Type:              "LoadBalancer",
+					LoadBalancerClass: pointer.String(testLoadBalancerClass),
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check LB exists")
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test24", Namespace: "default"}, &lb)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+		})
+
 		It("create service with classname and load balancer and await deletion of load balancer", func() {
 			By("create service")
 			service := v1.Service{
@@ -1520,5 +2520,86 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 				return fmt.Errorf("finalizer is not existing %s/%s:%v", svc.Namespace, svc.Name, svc.Finalizers)
 			}, time.Second*20, time.Millisecond*500).Should(Succeed())
 		})
+
+		It("should remove the finalizer on deletion after the class changed", func() {
+			By("create service")
+			service := v1.Service{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "service-test37",
+					Namespace: "default",
+				},
+				Spec: v1.ServiceSpec{
+					Ports: []v1.ServicePort{
+						{
+							Name:       "port1",
+							Protocol:   v1.ProtocolTCP,
+							Port:       12345,
+							TargetPort: intstr.IntOrString{IntVal: 12345},
+							NodePort:   30537,
+						},
+					},
+					Type:              "LoadBalancer",
+					LoadBalancerClass: pointer.String(testLoadBalancerClass),
+				}}
+			Expect(k8sClient.Create(ctx, &service)).Should(Succeed())
+
+			By("check LB exists")
+			Eventually(func() error {
+				return k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test37", Namespace: "default"}, &lb)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("move the service to another class")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&service), &service); err != nil {
+					return err
+				}
+				service.Annotations = map[string]string{yawolv1beta1.ServiceClassName: "wrongclassname"}
+				service.Spec.LoadBalancerClass = nil
+				return k8sClient.Update(ctx, &service)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("expect deletion of load balancer")
+			Eventually(func() error {
+				err := k8sClient.Get(ctx, types.NamespacedName{Name: "default--service-test37", Namespace: "default"}, &lb)
+				if err == nil {
+					return fmt.Errorf("loadbalancer still exists")
+				}
+				return client.IgnoreNotFound(err)
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+
+			By("set the ingress ip of the other controller")
+			Eventually(func() error {
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&service), &service); err != nil {
+					return err
+				}
+				service.Status.LoadBalancer.Ingress = []v1.LoadBalancerIngress{{IP: "192.0.2.1"}}
+				return k8sClient.Status().Update(ctx, &service)
+			}, time.Second*5, time.Millisecond*500).Should(Succeed())
+
+			By("expect the ingress ip of the other controller to be kept")
+			Consistently(func() error {
+				var svc v1.Service
+				if err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&service), &svc); err != nil {
+					return err
+				}
+				if len(svc.Status.LoadBalancer.Ingress) != 1 || svc.Status.LoadBalancer.Ingress[0].IP != "192.0.2.1" {
+					return fmt.Errorf("ingress ip changed: %v", svc.Status.LoadBalancer.Ingress)
+				}
+				return nil
+			}, time.Second*3, time.Millisecond*500).Should(Succeed())
+
+			By("delete service")
+			Expect(k8sClient.Delete(ctx, &service)).Should(Succeed())
+
+			By("expect the service to be gone")
+			Eventually(func() error {
+				var svc v1.Service
+				err := k8sClient.Get(ctx, client.ObjectKeyFromObject(&service), &svc)
+				if err == nil {
+					return fmt.Errorf("service still exists with finalizers %v", svc.Finalizers)
+				}
+				return client.IgnoreNotFound(err)
+			}, time.Second*10, time.Millisecond*500).Should(Succeed())
+		})
 	})
 })
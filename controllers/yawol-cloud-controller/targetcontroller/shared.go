@@ -0,0 +1,344 @@
+package targetcontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"sort"
+	"strings"
+
+	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+	"github.com/stackitcloud/yawol/internal/helper"
+
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+)
+
+// sharedIPSettings are the settings of a service that must be equal for all services sharing a LoadBalancer.
+type sharedIPSettings struct {
+	Options            yawolv1beta1.LoadBalancerOptions
+	DebugSettings      yawolv1beta1.LoadBalancerDebugSettings
+	Replicas           int
+	LoadBalancerIP     *string
+	ExistingFloatingIP *string
+	TLSSecretName      string
+	Infrastructure     InfrastructureDefaults
+}
+
+// GetServiceNamespacedNames returns the services of the LoadBalancer from the ServiceAnnotation.
+// LoadBalancers of services sharing an ip contain a comma separated list of services.
+func GetServiceNamespacedNames(lb *yawolv1beta1.LoadBalancer) ([]types.NamespacedName, error) {
+	var services []types.NamespacedName
+	for _, service := range strings.Split(lb.Annotations[ServiceAnnotation], ",") {
+		serviceParams := strings.Split(service, "/")
+		if len(serviceParams) != 2 {
+			return nil, helper.ErrCouldNotReadSvcNameSpacedNameFromAnno
+		}
+		services = append(services, types.NamespacedName{Namespace: serviceParams[0], Name: serviceParams[1]})
+	}
+	return services, nil
+}
+
+// getServiceAnnotationValue returns the value of the ServiceAnnotation for the services.
+func getServiceAnnotationValue(services []types.NamespacedName) string {
+	names := make([]string, 0, len(services))
+	for _, service := range services {
+		names = append(names, service.String())
+	}
+	return strings.Join(names, ",")
+}
+
+// getSharedIPServicesAndPorts returns the services, the merged ports and SNI routes of the LoadBalancer of a service
+// with a sharing key. Services are added in order of their creation, a service is rejected if it
+// has different settings than the first one or one of its ports is already used by an earlier service.
+// Returns the reason as error if the service itself is rejected.
+func (r *ServiceReconciler) getSharedIPServicesAndPorts(
+	ctx context.Context,
+	svc *coreV1.Service,
+) ([]types.NamespacedName, []coreV1.ServicePort, []yawolv1beta1.LoadBalancerSNIRoute, error) {
+	var serviceList coreV1.ServiceList
+	if err := r.TargetClient.List(ctx, &serviceList, client.InNamespace(svc.Namespace)); err != nil {
+		return nil, nil, nil, err
+	}
+
+	var services []coreV1.Service
+	for i := range serviceList.Items {
+		if !r.isSharedIPServiceCandidate(&serviceList.Items[i]) ||
+			helper.GetSharedIPKey(&serviceList.Items[i]) != helper.GetSharedIPKey(svc) {
+			continue
+		}
+		services = append(services, serviceList.Items[i])
+	}
+
+	lbServices, lbPorts, lbRoutes, rejected := r.mergeSharedIPServices(services)
+	if err, found := rejected[types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}]; found {
+		return nil, nil, nil, err
+	}
+	return lbServices, lbPorts, lbRoutes, nil
+}
+
+// isSharedIPServiceCandidate returns true if the service can be added to a shared LoadBalancer.
+func (r *ServiceReconciler) isSharedIPServiceCandidate(svc *coreV1.Service) bool {
+	return svc.Spec.Type == coreV1.ServiceTypeLoadBalancer &&
+		svc.DeletionTimestamp == nil &&
+		r.isServiceClassMatching(svc) &&
+		helper.ValidateService(svc) == nil
+}
+
+// mergeSharedIPServices merges the ports of the services in order of their creation.
+// Returns the accepted services, their ports and SNI routes and the reason for every rejected service.
+func (r *ServiceReconciler) mergeSharedIPServices(
+	services []coreV1.Service,
+) ([]types.NamespacedName, []coreV1.ServicePort, []yawolv1beta1.LoadBalancerSNIRoute, map[types.NamespacedName]error) {
+	sort.Slice(services, func(i, j int) bool {
+		if services[i].CreationTimestamp.Equal(&services[j].CreationTimestamp) {
+			return services[i].Name < services[j].Name
+		}
+		return services[i].CreationTimestamp.Before(&services[j].CreationTimestamp)
+	})
+
+	var lbServices []types.NamespacedName
+	var lbPorts []coreV1.ServicePort
+	var lbRoutes []yawolv1beta1.LoadBalancerSNIRoute
+	rejected := map[types.NamespacedName]error{}
+
+	var firstSettings *sharedIPSettings
+	for i := range services {
+		svcNN := types.NamespacedName{Namespace: services[i].Namespace, Name: services[i].Name}
+
+		settings := r.getSharedIPSettings(&services[i])
+		if settings.Options.PodEndpoints || settings.Options.HealthCheckNodePort != 0 {
+			rejected[svcNN] = helper.ErrSharedIPNotSupported
+			continue
+		}
+		if firstSettings == nil {
+			firstSettings = &settings
+		} else if !reflect.DeepEqual(*firstSettings, settings) {
+			rejected[svcNN] = helper.ErrSharedIPSettingsMismatch
+			continue
+		}
+
+		ports, routes, err := mergeServicePorts(lbPorts, lbRoutes, &services[i], settings.Options.TLSPorts)
+		if err != nil {
+			rejected[svcNN] = err
+			continue
+		}
+
+		lbServices = append(lbServices, svcNN)
+		lbPorts = ports
+		lbRoutes = routes
+	}
+
+	return lbServices, lbPorts, lbRoutes, rejected
+}
+
+func (r *ServiceReconciler) getSharedIPSettings(svc *coreV1.Service) sharedIPSettings {
+	return sharedIPSettings{
+		Options:            helper.GetOptions(svc),
+		DebugSettings:      helper.GetDebugSettings(svc),
+		Replicas:           helper.GetReplicasFromService(svc),
+		LoadBalancerIP:     helper.GetLoadBalancerIP(svc),
+		ExistingFloatingIP: helper.GetExistingFloatingIPFromAnnotation(svc),
+		TLSSecretName:      helper.GetTLSSecretNameFromService(svc),
+		Infrastructure:     GetMergedInfrastructureDetails(r.InfrastructureDefaults, svc),
+	}
+}
+
+// mergeServicePorts adds the ports of the service to the used ports and SNI routes.
+// A TCP port without TLS termination can be used by multiple services if they have SNI hostnames
+// which are not used by the other services of the port. At most one service of the port can be without
+// hostnames, it is used as default route for unknown server names. Without a default route connections with
+// unknown server names are rejected.
+// The used ports and routes are not modified, the service is either added with all of its ports or not at all.
+func mergeServicePorts(
+	usedPorts []coreV1.ServicePort,
+	usedRoutes []yawolv1beta1.LoadBalancerSNIRoute,
+	svc *coreV1.Service,
+	tlsPorts []int32,
+) ([]coreV1.ServicePort, []yawolv1beta1.LoadBalancerSNIRoute, error) {
+	ports := append([]coreV1.ServicePort{}, usedPorts...)
+	routes := append([]yawolv1beta1.LoadBalancerSNIRoute{}, usedRoutes...)
+	hostnames := helper.GetSNIHostnames(svc)
+
+	for _, port := range svc.Spec.Ports {
+		sniCapable := port.Protocol == coreV1.ProtocolTCP && !containsPort(tlsPorts, port.Port)
+
+		usedPort, found := getUsedPort(usedPorts, port)
+		if !found {
+			ports = append(ports, port)
+			if sniCapable && len(hostnames) > 0 {
+				routes = append(routes, yawolv1beta1.LoadBalancerSNIRoute{Port: port.Port, Hostnames: hostnames, NodePort: port.NodePort})
+			}
+			continue
+		}
+
+		collisionErr := fmt.Errorf("%w: %s/%d", helper.ErrSharedIPPortCollision, port.Protocol, port.Port)
+		if !sniCapable {
+			return nil, nil, collisionErr
+		}
+
+		// a port without routes is used by a single service without hostnames, which is the default route
+		var portRoutes []yawolv1beta1.LoadBalancerSNIRoute
+		for _, route := range usedRoutes {
+			if route.Port == port.Port {
+				portRoutes = append(portRoutes, route)
+			}
+		}
+		hasDefault := len(portRoutes) == 0
+		for _, route := range portRoutes {
+			if len(route.Hostnames) == 0 {
+				hasDefault = true
+			}
+			if hostnamesOverlap(route.Hostnames, hostnames) {
+				return nil, nil, collisionErr
+			}
+		}
+
+		if len(hostnames) == 0 {
+			if hasDefault {
+				return nil, nil, collisionErr
+			}
+			routes = append(routes, yawolv1beta1.LoadBalancerSNIRoute{Port: port.Port, NodePort: port.NodePort})
+			continue
+		}
+
+		if len(portRoutes) == 0 {
+			routes = append(routes, yawolv1beta1.LoadBalancerSNIRoute{Port: port.Port, NodePort: usedPort.NodePort})
+		}
+		routes = append(routes, yawolv1beta1.LoadBalancerSNIRoute{Port: port.Port, Hostnames: hostnames, NodePort: port.NodePort})
+	}
+
+	if len(routes) == 0 {
+		routes = nil
+	}
+	return ports, routes, nil
+}
+
+// getUsedPort returns the used port with the same port and protocol.
+func getUsedPort(usedPorts []coreV1.ServicePort, port coreV1.ServicePort) (coreV1.ServicePort, bool) {
+	for _, usedPort := range usedPorts {
+		if port.Port == usedPort.Port && port.Protocol == usedPort.Protocol {
+			return usedPort, true
+		}
+	}
+	return coreV1.ServicePort{}, false
+}
+
+func containsPort(ports []int32, port int32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
+
+func hostnamesOverlap(a, b []string) bool {
+	for i := range a {
+		for j := range b {
+			if a[i] == b[j] {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// removeServiceFromLoadBalancers removes the service from all LoadBalancers except the one to keep.
+// The ports of the remaining services are merged again, LoadBalancers without remaining services are deleted.
+// Returns true if a LoadBalancer of the service is still being deleted.
+func (r *ServiceReconciler) removeServiceFromLoadBalancers(
+	ctx context.Context,
+	svc *coreV1.Service,
+	infraDefaults InfrastructureDefaults,
+	keep types.NamespacedName,
+) (bool, error) {
+	var loadBalancers yawolv1beta1.LoadBalancerList
+	if err := r.ControlClient.List(ctx, &loadBalancers, client.InNamespace(*infraDefaults.Namespace)); err != nil {
+		return false, err
+	}
+
+	svcNN := types.NamespacedName{Namespace: svc.Namespace, Name: svc.Name}
+	var deleting bool
+	for i := range loadBalancers.Items {
+		lb := &loadBalancers.Items[i]
+		if lb.Namespace == keep.Namespace && lb.Name == keep.Name {
+			continue
+		}
+
+		// LoadBalancers without annotation belong to the service with the same name
+		services, err := GetServiceNamespacedNames(lb)
+		if err != nil && lb.Name != svc.Namespace+"--"+svc.Name {
+			continue
+		}
+
+		var remaining []types.NamespacedName
+		var found bool
+		for _, service := range services {
+			if service == svcNN {
+				found = true
+				continue
+			}
+			remaining = append(remaining, service)
+		}
+		if !found && lb.Name != svc.Namespace+"--"+svc.Name {
+			continue
+		}
+
+		deleted, err := r.updateSharedIPLoadBalancer(ctx, lb, remaining)
+		if err != nil {
+			return false, err
+		}
+		if deleted {
+			deleting = true
+			continue
+		}
+		r.Log.Info("service removed from shared loadbalancer", "service", svcNN, "lb", lb.Name)
+	}
+
+	return deleting, nil
+}
+
+// updateSharedIPLoadBalancer sets the remaining services of the LoadBalancer and merges their ports again.
+// The LoadBalancer is deleted if none of the services remain, returns true in this case.
+func (r *ServiceReconciler) updateSharedIPLoadBalancer(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	services []types.NamespacedName,
+) (bool, error) {
+	var sharedServices []coreV1.Service
+	for _, service := range services {
+		var svc coreV1.Service
+		if err := r.TargetClient.Get(ctx, service, &svc); err != nil {
+			if apierrors.IsNotFound(err) {
+				continue
+			}
+			return false, err
+		}
+		if !r.isSharedIPServiceCandidate(&svc) {
+			continue
+		}
+		sharedServices = append(sharedServices, svc)
+	}
+
+	lbServices, lbPorts, lbRoutes, _ := r.mergeSharedIPServices(sharedServices)
+
+	if len(lbServices) == 0 {
+		if lb.DeletionTimestamp == nil {
+			if err := r.ControlClient.Delete(ctx, lb, &client.DeleteOptions{}); err != nil {
+				return false, client.IgnoreNotFound(err)
+			}
+		}
+		return true, nil
+	}
+
+	if err := r.addAnnotation(ctx, lb, ServiceAnnotation, getServiceAnnotationValue(lbServices)); err != nil {
+		return false, err
+	}
+	if !reflect.DeepEqual(lb.Spec.Ports, lbPorts) || !reflect.DeepEqual(lb.Spec.SNIRoutes, lbRoutes) {
+		return false, r.patchLoadBalancerPorts(ctx, lb, lbPorts, lbRoutes)
+	}
+	return false, nil
+}
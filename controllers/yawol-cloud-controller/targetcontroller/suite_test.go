@@ -34,6 +34,8 @@ var (
 	cancel            context.CancelFunc
 )
 
+const testLoadBalancerClass = "stackit.cloud/yawol-test"
+
 func TestAPIs(t *testing.T) {
 	RegisterFailHandler(Fail)
 
@@ -56,7 +58,7 @@ var _ = BeforeSuite(func(done Done) {
 	}
 
 	args := testEnv.ControlPlane.GetAPIServer().Configure()
-	args.Append("feature-gates", "IPv6DualStack=true")
+	args.Append("feature-gates", "IPv6DualStack=true,LoadBalancerClass=true")
 	args.Append("service-cluster-ip-range", "10.244.0.0/16,fc00::0001:0000/112")
 
 	var err error
@@ -103,11 +105,13 @@ var _ = BeforeSuite(func(done Done) {
 	err = (&ServiceReconciler{
 		TargetClient:           k8sManager.GetClient(),
 		ControlClient:          k8sManager.GetClient(),
+		TargetAPIReader:        k8sManager.GetAPIReader(),
 		InfrastructureDefaults: testInfraDefaults,
 		Log:                    ctrl.Log.WithName("controllers").WithName("Service"),
 		Scheme:                 k8sManager.GetScheme(),
 		Recorder:               k8sManager.GetEventRecorderFor("Loadbalancer"),
 		ClassName:              "",
+		LoadBalancerClass:      testLoadBalancerClass,
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -0,0 +1,112 @@
+package targetcontroller
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+
+	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+	"github.com/stackitcloud/yawol/internal/helper"
+	"github.com/stackitcloud/yawol/internal/helper/kubernetes"
+
+	coreV1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
+	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/types"
+	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+)
+
+// getServiceRequestsForSecret maps a secret to the requests of the services using it as TLS secret.
+// Secrets that are not referenced by a service annotation are ignored.
+func (r *ServiceReconciler) getServiceRequestsForSecret(obj client.Object) []reconcile.Request {
+	var services coreV1.ServiceList
+	if err := r.TargetClient.List(context.Background(), &services, client.InNamespace(obj.GetNamespace())); err != nil {
+		r.Log.Error(err, "could not list services for secret", "secret", obj.GetName())
+		return nil
+	}
+
+	var requests []reconcile.Request
+	for i := range services.Items {
+		if helper.GetTLSSecretNameFromService(&services.Items[i]) != obj.GetName() {
+			continue
+		}
+		requests = append(requests, reconcile.Request{
+			NamespacedName: types.NamespacedName{
+				Namespace: services.Items[i].Namespace,
+				Name:      services.Items[i].Name,
+			},
+		})
+	}
+	return requests
+}
+
+// reconcileTLSSecret copies the certificate of the TLS secret of the service into the TLS secret of the LoadBalancer.
+// The copy is owned by the LoadBalancer and deleted if the service has no TLS ports anymore.
+func (r *ServiceReconciler) reconcileTLSSecret(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	svc *coreV1.Service,
+) error {
+	lbSecret := coreV1.Secret{}
+	err := r.ControlClient.Get(ctx, types.NamespacedName{Namespace: lb.Namespace, Name: helper.GetTLSSecretName(lb)}, &lbSecret)
+	if client.IgnoreNotFound(err) != nil {
+		return err
+	}
+	lbSecretExists := err == nil
+
+	secretName := helper.GetTLSSecretNameFromService(svc)
+	if len(helper.GetOptions(svc).TLSPorts) == 0 || secretName == "" {
+		if !lbSecretExists {
+			return nil
+		}
+		return client.IgnoreNotFound(r.ControlClient.Delete(ctx, &lbSecret))
+	}
+
+	tlsSecret := coreV1.Secret{}
+	if err := r.TargetAPIReader.Get(ctx, types.NamespacedName{Namespace: svc.Namespace, Name: secretName}, &tlsSecret); err != nil {
+		if apierrors.IsNotFound(err) {
+			return kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: %s", helper.ErrSecretNotFound, secretName), svc)
+		}
+		return err
+	}
+	if len(tlsSecret.Data[coreV1.TLSCertKey]) == 0 || len(tlsSecret.Data[coreV1.TLSPrivateKeyKey]) == 0 {
+		return kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: %s", helper.ErrTLSSecretInvalid, secretName), svc)
+	}
+
+	data := map[string][]byte{
+		coreV1.TLSCertKey:       tlsSecret.Data[coreV1.TLSCertKey],
+		coreV1.TLSPrivateKeyKey: tlsSecret.Data[coreV1.TLSPrivateKeyKey],
+	}
+
+	if !lbSecretExists {
+		lbSecret = coreV1.Secret{
+			ObjectMeta: v1.ObjectMeta{
+				Name:      helper.GetTLSSecretName(lb),
+				Namespace: lb.Namespace,
+				OwnerReferences: []v1.OwnerReference{{
+					APIVersion: yawolv1beta1.GroupVersion.String(),
+					Kind:       helper.LoadBalancerKind,
+					Name:       lb.Name,
+					UID:        lb.UID,
+				}},
+			},
+			Type: coreV1.SecretTypeTLS,
+			Data: data,
+		}
+		if err := r.ControlClient.Create(ctx, &lbSecret); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer TLS secret successfully synced with service TLS secret")
+		return nil
+	}
+
+	if !reflect.DeepEqual(lbSecret.Data, data) {
+		lbSecret.Data = data
+		if err := r.ControlClient.Update(ctx, &lbSecret); err != nil {
+			return err
+		}
+		r.Recorder.Event(svc, coreV1.EventTypeNormal, "update", "LoadBalancer TLS secret successfully synced with service TLS secret")
+	}
+	return nil
+}
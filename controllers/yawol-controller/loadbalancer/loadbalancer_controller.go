@@ -2,6 +2,9 @@ package loadbalancer
 
 import (
 	"context"
+	"fmt"
+	"net"
+	"sort"
 	"time"
 
 	"github.com/stackitcloud/yawol/internal/helper"
@@ -16,7 +19,9 @@ import (
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 	openstackhelper "github.com/stackitcloud/yawol/internal/helper/openstack"
 	helpermetrics "github.com/stackitcloud/yawol/internal/metrics"
+	coreV1 "k8s.io/api/core/v1"
 	errors2 "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	ctrl "sigs.k8s.io/controller-runtime"
 
@@ -24,6 +29,7 @@ import (
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"k8s.io/utils/pointer"
+	"k8s.io/utils/strings/slices"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 	"sigs.k8s.io/controller-runtime/pkg/controller"
 )
@@ -37,6 +43,8 @@ const (
 // LoadBalancerReconciler reconciles service Objects with type LoadBalancer
 type Reconciler struct {
 	client.Client
+	// APIReader reads LoadBalancers of all namespaces, the cache of the Client only contains the cluster namespace
+	APIReader         client.Reader
 	Log               logr.Logger
 	Scheme            *runtime.Scheme
 	Recorder          record.EventRecorder
@@ -94,16 +102,36 @@ func (r *Reconciler) Reconcile(ctx context.Context, req ctrl.Request) (ctrl.Resu
 		return ctrl.Result{}, err
 	}
 
+	// stop reconciliation if lb is paused
+	var paused bool
+	if paused, err = r.reconcilePaused(ctx, &lb); err != nil || paused {
+		return ctrl.Result{}, err
+	}
+
 	// run openstack reconcile if needed
 	if res, err = r.reconcileOpenStackIfNeeded(ctx, &lb, req, osClient); err != nil || res.Requeue || res.RequeueAfter != 0 {
 		return res, err
 	}
 
+	// the router ID is part of the lbset hash and has to be set before the lbset is reconciled
+	if err := r.reconcileVRRPRouterID(ctx, &lb); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// lbs reconcile is not affected by lastOpenstackReconcile
 	if res, err = r.reconcileLoadBalancerSet(ctx, &lb); err != nil || res.Requeue || res.RequeueAfter != 0 {
 		return res, err
 	}
 
+	// the generation is only observed after a successful reconciliation without requeue
+	if lb.Status.ObservedGeneration != lb.Generation {
+		if err := helper.PatchLBStatus(ctx, r.Status(), &lb, yawolv1beta1.LoadBalancerStatus{
+			ObservedGeneration: lb.Generation,
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{RequeueAfter: 5 * time.Minute}, nil
 }
 
@@ -127,6 +155,72 @@ func (r *Reconciler) SetupWithManager(mgr ctrl.Manager) error {
 		Complete(r)
 }
 
+// reconcileVRRPRouterID assigns a keepalived virtual router ID to the LoadBalancer which is unique
+// among the LoadBalancers in the same network. If an older LoadBalancer uses the same ID,
+// the LoadBalancer gets a new ID, which is rolled out with a new LoadBalancerSet.
+// LoadBalancers of all namespaces are read, since other clusters can use the same network.
+func (r *Reconciler) reconcileVRRPRouterID(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+) error {
+	var lbList yawolv1beta1.LoadBalancerList
+	if err := r.APIReader.List(ctx, &lbList); err != nil {
+		return err
+	}
+
+	routerID, collision, err := helper.GetVRRPRouterID(lb, lbList.Items)
+	if err != nil {
+		return kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+	}
+	if collision {
+		_ = kubernetes.SendErrorAsEvent(r.RecorderLB,
+			fmt.Errorf("%w: %d, changed to %d", helper.ErrVRRPRouterIDCollision, *lb.Status.VRRPRouterID, routerID), lb)
+	}
+
+	if lb.Status.VRRPRouterID != nil && *lb.Status.VRRPRouterID == routerID {
+		return nil
+	}
+	return helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
+		VRRPRouterID: &routerID,
+	})
+}
+
+// reconcilePaused updates the Paused condition of the LoadBalancer.
+// Returns true if the LoadBalancer is paused and must not be reconciled any further.
+func (r *Reconciler) reconcilePaused(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+) (bool, error) {
+	paused := helper.IsLoadBalancerPaused(lb)
+
+	// only set the condition to false if the lb was paused before
+	if !paused && meta.FindStatusCondition(lb.Status.Conditions, string(helper.Paused)) == nil {
+		return false, nil
+	}
+
+	condition := metaV1.Condition{
+		Type:               string(helper.Paused),
+		Status:             metaV1.ConditionFalse,
+		Reason:             "Resumed",
+		Message:            "reconciliation of the LoadBalancer is active",
+		ObservedGeneration: lb.Generation,
+	}
+	if paused {
+		condition.Status = metaV1.ConditionTrue
+		condition.Reason = "Paused"
+		condition.Message = "reconciliation of the LoadBalancer is paused"
+	}
+
+	if err := helper.PatchLBCondition(ctx, r.Status(), lb, condition); err != nil {
+		return paused, err
+	}
+
+	if paused {
+		r.Log.Info("reconciliation is paused", "lb", lb.Name)
+	}
+	return paused, nil
+}
+
 func (r *Reconciler) reconcileOpenStackIfNeeded(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -143,18 +237,27 @@ func (r *Reconciler) reconcileOpenStackIfNeeded(
 	var err error
 
 	requeue, err = r.reconcileSecGroup(ctx, req, lb, osClient)
+	if err := r.patchOpenStackCondition(ctx, lb, helper.SecurityGroupReady, requeue, err); err != nil {
+		return ctrl.Result{}, err
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	overallRequeue = overallRequeue || requeue
 
 	requeue, err = r.reconcileFIP(ctx, req, lb, osClient)
+	if err := r.patchOpenStackCondition(ctx, lb, helper.FloatingIPReady, requeue, err); err != nil {
+		return ctrl.Result{}, err
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 	overallRequeue = overallRequeue || requeue
 
 	requeue, err = r.reconcilePort(ctx, req, lb, osClient)
+	if err := r.patchOpenStackCondition(ctx, lb, helper.PortReady, requeue, err); err != nil {
+		return ctrl.Result{}, err
+	}
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -166,6 +269,18 @@ func (r *Reconciler) reconcileOpenStackIfNeeded(
 	}
 	overallRequeue = overallRequeue || requeue
 
+	requeue, err = r.reconcileImage(ctx, lb, osClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	overallRequeue = overallRequeue || requeue
+
+	requeue, err = r.reconcileFlavor(ctx, lb, osClient)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	overallRequeue = overallRequeue || requeue
+
 	if overallRequeue {
 		return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
 	}
@@ -184,6 +299,37 @@ func (r *Reconciler) reconcileOpenStackIfNeeded(
 	return ctrl.Result{}, nil
 }
 
+// patchOpenStackCondition sets the condition for an openstack resource of the LoadBalancer
+// depending on the result of its reconciliation.
+func (r *Reconciler) patchOpenStackCondition(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	conditionType helper.LoadbalancerCondition,
+	requeue bool,
+	reconcileErr error,
+) error {
+	condition := metaV1.Condition{
+		Type:               string(conditionType),
+		Status:             metaV1.ConditionTrue,
+		Reason:             "Reconciled",
+		Message:            "openstack resource is reconciled",
+		ObservedGeneration: lb.Generation,
+	}
+
+	switch {
+	case reconcileErr != nil:
+		condition.Status = metaV1.ConditionFalse
+		condition.Reason = "ReconcileFailed"
+		condition.Message = reconcileErr.Error()
+	case requeue:
+		condition.Status = metaV1.ConditionFalse
+		condition.Reason = "InProgress"
+		condition.Message = "openstack resource is being reconciled"
+	}
+
+	return helper.PatchLBCondition(ctx, r.Status(), lb, condition)
+}
+
 func (r *Reconciler) updateOpenstackReconcileHash(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -266,6 +412,23 @@ func (r *Reconciler) reconcileFIP(
 		}
 	}
 
+	// re-associate the LoadBalancer if the requested LoadBalancerIP changed,
+	// the current FIP is released and the new one is claimed in the next run
+	if lb.Spec.ExistingFloatingIP == nil &&
+		lb.Spec.LoadBalancerIP != nil &&
+		fip.FloatingIP != *lb.Spec.LoadBalancerIP {
+		r.Log.Info("LoadBalancerIP changed, release FloatingIP", "lb", lb.Name, "fip", fip.FloatingIP)
+		if err := r.releaseFIP(ctx, fipClient, lb, fip); err != nil {
+			return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+		}
+		if err := helper.RemoveFromLBStatus(ctx, r.Status(), lb, "floatingID"); err != nil {
+			return false, err
+		}
+		r.RecorderLB.Event(lb, "Normal", "Reassociate",
+			fmt.Sprintf("Released FloatingIP %s for LoadBalancerIP %s", fip.FloatingIP, *lb.Spec.LoadBalancerIP))
+		return true, nil
+	}
+
 	// patch floatingIP in status
 	if lb.Status.ExternalIP == nil || *lb.Status.ExternalIP != fip.FloatingIP {
 		r.Log.Info("Update ExternalIP", "lb", lb.Name)
@@ -327,6 +490,30 @@ func (r *Reconciler) assignOrCreateFIP(
 		return nil
 	}
 
+	// claim the FIP of the requested LoadBalancerIP or allocate it
+	if lb.Spec.LoadBalancerIP != nil {
+		r.Log.Info("Use LoadBalancerIP", "lb", lb.Name)
+		fip, err = openstackhelper.GetFIPByIP(ctx, fipClient, *lb.Spec.LoadBalancerIP)
+		switch {
+		case err == helper.ErrFIPNotFound:
+			r.Log.Info("Create FloatingIP for LoadBalancerIP", "lb", lb.Name)
+			if fip, err = openstackhelper.CreateFIP(ctx, fipClient, lb); err != nil {
+				return kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+			}
+		case err != nil:
+			r.Log.Error(err, "retrieving FIP by IP failed")
+			return kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+		case fip.PortID != "" && (lb.Status.PortID == nil || fip.PortID != *lb.Status.PortID):
+			return kubernetes.SendErrorAsEvent(r.RecorderLB, fmt.Errorf("%w: %s", helper.ErrFIPInUse, fip.FloatingIP), lb)
+		}
+		// double check so status won't be corrupted
+		if fip.ID == "" {
+			return helper.ErrFIPIDEmpty
+		}
+
+		return helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{FloatingID: &fip.ID})
+	}
+
 	// try to find FIP by name
 	fip, _ = openstackhelper.GetFIPByName(ctx, fipClient, *lb.Status.FloatingName)
 	if fip != nil {
@@ -359,6 +546,20 @@ func (r *Reconciler) assignOrCreateFIP(
 	return nil
 }
 
+// releaseFIP deletes a FIP that was allocated for the LoadBalancer.
+// FIPs that are not managed by yawol are only unbound from the port.
+func (r *Reconciler) releaseFIP(
+	ctx context.Context,
+	fipClient openstack.FipClient,
+	lb *yawolv1beta1.LoadBalancer,
+	fip *floatingips.FloatingIP,
+) error {
+	if lb.Status.FloatingName != nil && fip.Description == *lb.Status.FloatingName {
+		return openstackhelper.DeleteFIP(ctx, fipClient, fip.ID)
+	}
+	return openstackhelper.UnbindFIP(ctx, fipClient, fip.ID)
+}
+
 func (r *Reconciler) reconcilePort(
 	ctx context.Context,
 	req ctrl.Request,
@@ -405,7 +606,12 @@ func (r *Reconciler) reconcilePort(
 	// Create Port
 	if lb.Status.PortID == nil {
 		r.Log.Info("Create Port", "lb", lb.Name)
-		port, err = openstackhelper.CreatePort(ctx, portClient, *lb.Status.PortName, lb.Spec.Infrastructure.NetworkID)
+		var fixedIPs []ports.IP
+		fixedIPs, err = r.getFixedIPsForNewPort(ctx, osClient, portClient, lb)
+		if err != nil {
+			return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+		}
+		port, err = openstackhelper.CreatePort(ctx, portClient, *lb.Status.PortName, lb.Spec.Infrastructure.NetworkID, fixedIPs)
 		if err != nil {
 			r.Log.Info("unexpected error occurred claiming a port", "lb", req.NamespacedName)
 			return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
@@ -466,17 +672,67 @@ func (r *Reconciler) reconcilePort(
 		}
 
 		requeue = requeue || changed
+
+		// use the requested subnet or fixed ip for the VIP of the port
+		if requested := helper.GetRequestedFixedIP(lb); requested != nil && !helper.HasRequestedFixedIP(port.FixedIPs, *requested) {
+			r.Log.Info("Update fixed ip of port", "lb", lb.Name, "subnetID", requested.SubnetID, "fixedIP", requested.IPAddress)
+			var subnetClient openstack.SubnetClient
+			subnetClient, err = osClient.SubnetClient(ctx)
+			if err != nil {
+				return false, err
+			}
+			var fixedIP ports.IP
+			var ipVersion int
+			fixedIP, ipVersion, err = openstackhelper.ValidateFixedIP(
+				ctx, subnetClient, portClient, lb.Spec.Infrastructure.NetworkID, port.ID, *requested,
+			)
+			if err != nil {
+				return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+			}
+			fixedIPs := helper.ReplaceFixedIPForIPFamily(port.FixedIPs, fixedIP, getIPFamilyForIPVersion(ipVersion))
+			if port, err = portClient.Update(ctx, port.ID, ports.UpdateOpts{FixedIPs: fixedIPs}); err != nil {
+				r.Log.Error(err, "could not update port.fixedIPs", "lb", lb)
+				return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+			}
+			requeue = true
+		}
 	}
 
 	if port == nil {
 		return true, nil
 	}
 
-	// If internal LB, use first port ip as external ip
-	if lb.Spec.Options.InternalLB && len(port.FixedIPs) >= 1 &&
-		(lb.Status.ExternalIP == nil || *lb.Status.ExternalIP != port.FixedIPs[0].IPAddress) {
+	vips, err := helper.GetVIPsForIPFamilies(lb, port.FixedIPs)
+	if err != nil {
+		return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+	}
+
+	// If internal LB, use the VIP of the primary ip family as external ip
+	if lb.Spec.Options.InternalLB &&
+		(lb.Status.ExternalIP == nil || *lb.Status.ExternalIP != vips[0]) {
+		if err := helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
+			ExternalIP: &vips[0],
+		}); err != nil {
+			return false, err
+		}
+		requeue = true
+	}
+
+	// external ips contain the VIPs of all ip families, for external LBs the IPv4 VIP is replaced by the FIP
+	externalIPs := make([]string, 0, len(vips))
+	for _, vip := range vips {
+		if !lb.Spec.Options.InternalLB && net.ParseIP(vip).To4() != nil {
+			if lb.Status.ExternalIP == nil {
+				continue
+			}
+			vip = *lb.Status.ExternalIP
+		}
+		externalIPs = append(externalIPs, vip)
+	}
+
+	if !slices.Equal(lb.Status.ExternalIPs, externalIPs) {
 		if err := helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
-			ExternalIP: &port.FixedIPs[0].IPAddress,
+			ExternalIPs: externalIPs,
 		}); err != nil {
 			return false, err
 		}
@@ -486,6 +742,58 @@ func (r *Reconciler) reconcilePort(
 	return requeue, nil
 }
 
+// getFixedIPsForNewPort returns the fixed ips for a new port of the LoadBalancer.
+// Returns nil if neither a subnet nor a fixed ip is requested, so that openstack chooses the fixed ips.
+// The other IP families of the LoadBalancer get a fixed ip from the first subnet of their ip version.
+func (r *Reconciler) getFixedIPsForNewPort(
+	ctx context.Context,
+	osClient openstack.Client,
+	portClient openstack.PortClient,
+	lb *yawolv1beta1.LoadBalancer,
+) ([]ports.IP, error) {
+	requested := helper.GetRequestedFixedIP(lb)
+	if requested == nil {
+		return nil, nil
+	}
+
+	subnetClient, err := osClient.SubnetClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
+	networkID := lb.Spec.Infrastructure.NetworkID
+	fixedIP, ipVersion, err := openstackhelper.ValidateFixedIP(ctx, subnetClient, portClient, networkID, "", *requested)
+	if err != nil {
+		return nil, err
+	}
+
+	fixedIPs := []ports.IP{fixedIP}
+	for _, ipFamily := range helper.GetIPFamilies(lb) {
+		if ipFamily == getIPFamilyForIPVersion(ipVersion) {
+			continue
+		}
+		otherIPVersion := 4
+		if ipFamily == coreV1.IPv6Protocol {
+			otherIPVersion = 6
+		}
+		subnet, err := openstackhelper.GetSubnetForIPVersion(ctx, subnetClient, networkID, otherIPVersion)
+		if err != nil {
+			return nil, err
+		}
+		if subnet != nil {
+			fixedIPs = append(fixedIPs, ports.IP{SubnetID: subnet.ID})
+		}
+	}
+	return fixedIPs, nil
+}
+
+func getIPFamilyForIPVersion(ipVersion int) coreV1.IPFamily {
+	if ipVersion == 6 {
+		return coreV1.IPv6Protocol
+	}
+	return coreV1.IPv4Protocol
+}
+
 func (r *Reconciler) reconcileSecGroup(
 	ctx context.Context,
 	req ctrl.Request,
@@ -644,6 +952,99 @@ func (r *Reconciler) reconcileFIPAssociate(
 	return false, nil
 }
 
+// reconcileImage resolves the image name or image search of the LoadBalancer and stores the image ID in the status.
+// The resolved image is part of the LoadBalancerSet hash, so that a newly resolved image results in a rollout.
+func (r *Reconciler) reconcileImage(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	osClient openstack.Client,
+) (bool, error) {
+	image := lb.Spec.Infrastructure.Image
+
+	// images referenced by id do not need to be resolved
+	if image == nil || image.ImageID != nil {
+		if lb.Status.ImageID == nil {
+			return false, nil
+		}
+		if err := helper.RemoveFromLBStatus(ctx, r.Status(), lb, "imageID"); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	r.Log.Info("Reconcile Image", "lb", lb.Name)
+
+	imageClient, err := osClient.ImageClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	imageID, err := helper.GetImageID(ctx, imageClient, *image)
+	if err != nil {
+		return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+	}
+
+	if lb.Status.ImageID != nil && *lb.Status.ImageID == imageID {
+		return false, nil
+	}
+
+	r.Log.Info("image resolved to new id", "lb", lb.Name, "imageID", imageID)
+	if err := helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
+		ImageID: &imageID,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
+// reconcileFlavor resolves the flavor name or flavor search of the LoadBalancer and stores the flavor ID in the status.
+// The flavor search lists the extra specs of every flavor, so it is only resolved during the openstack reconcile
+// and not for every server that is created.
+func (r *Reconciler) reconcileFlavor(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	osClient openstack.Client,
+) (bool, error) {
+	flavor := lb.Spec.Infrastructure.Flavor
+
+	// flavors referenced by id do not need to be resolved
+	if flavor == nil || flavor.FlavorID != nil {
+		if lb.Status.FlavorID == nil {
+			return false, nil
+		}
+		if err := helper.RemoveFromLBStatus(ctx, r.Status(), lb, "flavorID"); err != nil {
+			return false, err
+		}
+		return true, nil
+	}
+
+	r.Log.Info("Reconcile Flavor", "lb", lb.Name)
+
+	flavorClient, err := osClient.FlavorClient(ctx)
+	if err != nil {
+		return false, err
+	}
+
+	flavorID, err := helper.GetFlavorID(ctx, flavorClient, *flavor)
+	if err != nil {
+		return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+	}
+
+	if lb.Status.FlavorID != nil && *lb.Status.FlavorID == flavorID {
+		return false, nil
+	}
+
+	r.Log.Info("flavor resolved to new id", "lb", lb.Name, "flavorID", flavorID)
+	if err := helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
+		FlavorID: &flavorID,
+	}); err != nil {
+		return false, err
+	}
+
+	return true, nil
+}
+
 func (r *Reconciler) reconcileLoadBalancerSet(
 	ctx context.Context,
 	lb *yawolv1beta1.LoadBalancer,
@@ -671,9 +1072,20 @@ func (r *Reconciler) reconcileLoadBalancerSet(
 		return ctrl.Result{}, err
 	}
 
-	// Get LoadBalancerSet for current hash
+	// Get LoadBalancerSet for current hash or for the revision to roll back to
 	var loadBalancerSet *yawolv1beta1.LoadBalancerSet
-	if loadBalancerSet, err = helper.GetLoadBalancerSetForHash(ctx, r.Client, lbsLabels, hash); err != nil {
+	if lb.Spec.RollbackTo != nil {
+		if loadBalancerSet, err = helper.GetLoadBalancerSetForRevision(ctx, r.Client, lb, lb.Spec.RollbackTo.Revision); err != nil {
+			return ctrl.Result{}, err
+		}
+		if loadBalancerSet == nil {
+			return ctrl.Result{}, kubernetes.SendErrorAsEvent(
+				r.RecorderLB,
+				fmt.Errorf("%w: %d", helper.ErrRollbackRevisionNotFound, lb.Spec.RollbackTo.Revision),
+				lb,
+			)
+		}
+	} else if loadBalancerSet, err = helper.GetLoadBalancerSetForHash(ctx, r.Client, lbsLabels, hash); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -694,14 +1106,46 @@ func (r *Reconciler) reconcileLoadBalancerSet(
 			return ctrl.Result{}, helper.ErrLBPortNotSet
 		}
 
+		// the new lbset starts with as many replicas as the rollout strategy allows
+		var maxSurge int
+		maxSurge, _, err = helper.GetMaxSurgeAndMaxUnavailable(lb)
+		if err != nil {
+			return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+		}
+
+		var oldSets []yawolv1beta1.LoadBalancerSet
+		oldSets, err = r.getOldLoadBalancerSets(ctx, lb, "")
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		// pin the resolved image and flavor in the lbset to be able to roll back to them
+		infrastructure := *lb.Spec.Infrastructure.DeepCopy()
+		if lb.Status.ImageID != nil {
+			infrastructure.Image = &yawolv1beta1.OpenstackImageRef{
+				ImageID: pointer.String(*lb.Status.ImageID),
+			}
+		}
+		if lb.Status.FlavorID != nil {
+			infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+				FlavorID: pointer.String(*lb.Status.FlavorID),
+			}
+		}
+
+		var vrrpRouterID int32
+		if lb.Status.VRRPRouterID != nil {
+			vrrpRouterID = *lb.Status.VRRPRouterID
+		}
+
 		if err := helper.CreateLoadBalancerSet(ctx, r.Client, lb, &yawolv1beta1.LoadBalancerMachineSpec{
-			Infrastructure: lb.Spec.Infrastructure,
+			Infrastructure: infrastructure,
 			PortID:         *lb.Status.PortID,
 			LoadBalancerRef: yawolv1beta1.LoadBalancerRef{
 				Namespace: lb.Namespace,
 				Name:      lb.Name,
 			},
-		}, hash, newRevision); err != nil {
+			VRRPRouterID: vrrpRouterID,
+		}, hash, newRevision, getSurgeReplicas(lb.Spec.Replicas, 0, sumReplicas(oldSets), maxSurge)); err != nil {
 			return ctrl.Result{}, err
 		}
 
@@ -730,39 +1174,291 @@ func (r *Reconciler) reconcileLoadBalancerSet(
 		}
 	}
 
-	// update replicas
-	if loadBalancerSet.Spec.Replicas != lb.Spec.Replicas {
-		err := helper.PatchLoadBalancerSetReplicas(ctx, r.Client, loadBalancerSet, lb.Spec.Replicas)
-		if err != nil {
+	// reference the keepalived secret of the current lbset
+	if secretRef := loadBalancerSet.Spec.Template.Spec.KeepalivedSecretRef; secretRef != nil &&
+		(lb.Status.KeepalivedSecretName == nil || *lb.Status.KeepalivedSecretName != secretRef.Name) {
+		if err := helper.PatchLBStatus(ctx, r.Status(), lb, yawolv1beta1.LoadBalancerStatus{
+			KeepalivedSecretName: pointer.String(secretRef.Name),
+		}); err != nil {
 			return ctrl.Result{}, err
 		}
-		return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
 	}
 
-	// check if all replicas from current lbset are ready and requeue if current lbset is not fully ready
-	ready, err := helper.LoadBalancerSetIsReady(ctx, r.Client, lb, loadBalancerSet)
+	return r.rolloutLoadBalancerSet(ctx, lb, loadBalancerSet)
+}
+
+// rolloutLoadBalancerSet scales the current lbset up and all other lbsets of the LoadBalancer down
+// within the limits of the rollout strategy. Old lbsets are scaled down by revision,
+// the lbset which contains the keepalived master is scaled down last.
+func (r *Reconciler) rolloutLoadBalancerSet(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	currentSet *yawolv1beta1.LoadBalancerSet,
+) (ctrl.Result, error) {
+	maxSurge, maxUnavailable, err := helper.GetMaxSurgeAndMaxUnavailable(lb)
+	if err != nil {
+		return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
+	}
+
+	oldSets, err := r.getOldLoadBalancerSets(ctx, lb, currentSet.Name)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
+	oldReplicas := sumReplicas(oldSets)
+
+	if err := r.patchRolloutConditions(ctx, lb, currentSet, oldSets, maxUnavailable); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// scale up the current lbset as far as maxSurge allows
+	if replicas := getSurgeReplicas(lb.Spec.Replicas, currentSet.Spec.Replicas, oldReplicas, maxSurge); replicas != currentSet.Spec.Replicas {
+		r.Log.Info("scale current lbset", "lbs", currentSet.Name, "replicas", replicas)
+		if err := helper.PatchLoadBalancerSetReplicas(ctx, r.Client, currentSet, replicas); err != nil {
+			return ctrl.Result{}, err
+		}
+		return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
+	}
+
+	if len(oldSets) == 0 {
+		// check if all replicas from current lbset are ready and requeue if current lbset is not fully ready
+		ready, err := helper.LoadBalancerSetIsReady(ctx, r.Client, lb, currentSet)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+
+		if !ready {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+
+		// the VRRP password is shared by all lbsets and only rotated after the rollout
+		revision, err := helper.ReadRevisionFromLBS(currentSet)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		rotating, err := helper.RotateKeepalivedSecretIfNeeded(ctx, r.Client, lb, revision)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if rotating {
+			return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
+		}
+
+		return ctrl.Result{}, r.pruneLoadBalancerSets(ctx, lb, currentSet)
+	}
 
-	if !ready {
+	// scale down old lbsets as far as maxUnavailable allows,
+	// machines of old lbsets which are not available can always be removed
+	var oldAvailable int
+	for i := range oldSets {
+		oldAvailable += getAvailableReplicas(&oldSets[i])
+	}
+	minAvailable := lb.Spec.Replicas - maxUnavailable
+	scaleDown := getAvailableReplicas(currentSet) + oldAvailable - minAvailable + (oldReplicas - oldAvailable)
+	if scaleDown <= 0 {
 		return ctrl.Result{RequeueAfter: 1 * time.Second}, nil
 	}
 
-	r.Log.Info("current lbset is ready", "lbs", loadBalancerSet.Name)
+	if err := r.sortOldLoadBalancerSetsForScaleDown(ctx, lb, oldSets); err != nil {
+		return ctrl.Result{}, err
+	}
+
+	for i := range oldSets {
+		if scaleDown <= 0 {
+			break
+		}
+
+		replicas := oldSets[i].Spec.Replicas - scaleDown
+		if replicas < 0 {
+			replicas = 0
+		}
+		scaleDown -= oldSets[i].Spec.Replicas - replicas
+
+		r.Log.Info("scale down old lbset", "lbs", oldSets[i].Name, "replicas", replicas)
+		if err := helper.PatchLoadBalancerSetReplicas(ctx, r.Client, &oldSets[i], replicas); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
+}
+
+// patchRolloutConditions sets the Available and RolloutInProgress conditions of the LoadBalancer.
+// The LoadBalancer is available if at least the desired replicas minus maxUnavailable are available.
+func (r *Reconciler) patchRolloutConditions(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	currentSet *yawolv1beta1.LoadBalancerSet,
+	oldSets []yawolv1beta1.LoadBalancerSet,
+	maxUnavailable int,
+) error {
+	available := getAvailableReplicas(currentSet)
+	for i := range oldSets {
+		available += getAvailableReplicas(&oldSets[i])
+	}
+
+	availableCondition := metaV1.Condition{
+		Type:               string(helper.Available),
+		Status:             metaV1.ConditionTrue,
+		Reason:             "MinimumReplicasAvailable",
+		Message:            fmt.Sprintf("%d of %d replicas are available", available, lb.Spec.Replicas),
+		ObservedGeneration: lb.Generation,
+	}
+	if available < lb.Spec.Replicas-maxUnavailable || (lb.Spec.Replicas > 0 && available == 0) {
+		availableCondition.Status = metaV1.ConditionFalse
+		availableCondition.Reason = "MinimumReplicasUnavailable"
+	}
+	if err := helper.PatchLBCondition(ctx, r.Status(), lb, availableCondition); err != nil {
+		return err
+	}
 
-	// scale down all other existing lbsets for the LoadBalancer after upscale to ensure no downtime
-	downscaled, err := helper.AreAllLoadBalancerSetsForLBButDownscaled(ctx, r.Client, lb, loadBalancerSet.Name)
+	rolloutCondition := metaV1.Condition{
+		Type:               string(helper.RolloutInProgress),
+		Status:             metaV1.ConditionFalse,
+		Reason:             "RolloutFinished",
+		Message:            fmt.Sprintf("lbset %s is rolled out", currentSet.Name),
+		ObservedGeneration: lb.Generation,
+	}
+	if len(oldSets) > 0 ||
+		currentSet.Spec.Replicas != lb.Spec.Replicas ||
+		getAvailableReplicas(currentSet) != lb.Spec.Replicas {
+		rolloutCondition.Status = metaV1.ConditionTrue
+		rolloutCondition.Reason = "RolloutInProgress"
+		rolloutCondition.Message = fmt.Sprintf("lbset %s is being rolled out", currentSet.Name)
+	}
+	return helper.PatchLBCondition(ctx, r.Status(), lb, rolloutCondition)
+}
+
+// getOldLoadBalancerSets returns all lbsets of the LoadBalancer with replicas except of the current one.
+func (r *Reconciler) getOldLoadBalancerSets(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	currentSetName string,
+) ([]yawolv1beta1.LoadBalancerSet, error) {
+	loadBalancerSetList, err := helper.GetLoadBalancerSetsForLoadBalancer(ctx, r.Client, lb)
 	if err != nil {
-		return ctrl.Result{}, err
+		return nil, err
 	}
 
-	if !downscaled {
-		r.Log.Info("scale down all lbsets except of", "lbs", loadBalancerSet.Name)
-		return helper.ScaleDownAllLoadBalancerSetsForLBBut(ctx, r.Client, lb, loadBalancerSet.Name)
+	oldSets := make([]yawolv1beta1.LoadBalancerSet, 0)
+	for i := range loadBalancerSetList.Items {
+		if loadBalancerSetList.Items[i].Name == currentSetName || loadBalancerSetList.Items[i].Spec.Replicas == 0 {
+			continue
+		}
+		oldSets = append(oldSets, loadBalancerSetList.Items[i])
 	}
+	return oldSets, nil
+}
 
-	return ctrl.Result{}, nil
+// pruneLoadBalancerSets deletes old lbsets without replicas which exceed the revision history limit of the LoadBalancer.
+// The lbsets with the lowest revisions are deleted first, the current lbset is never deleted.
+func (r *Reconciler) pruneLoadBalancerSets(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	currentSet *yawolv1beta1.LoadBalancerSet,
+) error {
+	loadBalancerSetList, err := helper.GetLoadBalancerSetsForLoadBalancer(ctx, r.Client, lb)
+	if err != nil {
+		return err
+	}
+
+	revisions := make(map[string]int, len(loadBalancerSetList.Items))
+	historySets := make([]yawolv1beta1.LoadBalancerSet, 0)
+	for i := range loadBalancerSetList.Items {
+		lbs := loadBalancerSetList.Items[i]
+		if lbs.Name == currentSet.Name ||
+			lbs.DeletionTimestamp != nil ||
+			lbs.Spec.Replicas != 0 ||
+			(lbs.Status.Replicas != nil && *lbs.Status.Replicas != 0) {
+			continue
+		}
+
+		if revisions[lbs.Name], err = helper.ReadRevisionFromLBS(&lbs); err != nil {
+			return err
+		}
+		historySets = append(historySets, lbs)
+	}
+
+	revisionHistoryLimit := helper.GetRevisionHistoryLimit(lb)
+	if len(historySets) <= revisionHistoryLimit {
+		return nil
+	}
+
+	sort.SliceStable(historySets, func(i, j int) bool {
+		return revisions[historySets[i].Name] < revisions[historySets[j].Name]
+	})
+
+	for i := range historySets[:len(historySets)-revisionHistoryLimit] {
+		r.Log.Info("delete old lbset", "lbs", historySets[i].Name, "revision", revisions[historySets[i].Name])
+		if err := r.Client.Delete(ctx, &historySets[i]); client.IgnoreNotFound(err) != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+// sortOldLoadBalancerSetsForScaleDown sorts lbsets by revision,
+// the lbset which contains the keepalived master is sorted to the end.
+func (r *Reconciler) sortOldLoadBalancerSetsForScaleDown(
+	ctx context.Context,
+	lb *yawolv1beta1.LoadBalancer,
+	oldSets []yawolv1beta1.LoadBalancerSet,
+) error {
+	masterHash, err := helper.GetKeepalivedMasterLoadBalancerSetHash(ctx, r.Client, lb)
+	if err != nil {
+		return err
+	}
+
+	revisions := make(map[string]int, len(oldSets))
+	for i := range oldSets {
+		if revisions[oldSets[i].Name], err = helper.ReadRevisionFromLBS(&oldSets[i]); err != nil {
+			return err
+		}
+	}
+
+	sort.SliceStable(oldSets, func(i, j int) bool {
+		iIsMaster := masterHash != "" && oldSets[i].Labels[helper.HashLabel] == masterHash
+		jIsMaster := masterHash != "" && oldSets[j].Labels[helper.HashLabel] == masterHash
+		if iIsMaster != jIsMaster {
+			return jIsMaster
+		}
+		return revisions[oldSets[i].Name] < revisions[oldSets[j].Name]
+	})
+	return nil
+}
+
+// getSurgeReplicas returns the replicas for the current lbset.
+// The current lbset is scaled up until the desired replicas as far as maxSurge allows, it is never scaled down
+// during a rollout but if the desired replicas are lower than the current replicas.
+func getSurgeReplicas(desiredReplicas, currentReplicas, oldReplicas, maxSurge int) int {
+	replicas := desiredReplicas + maxSurge - oldReplicas
+	if replicas < currentReplicas {
+		replicas = currentReplicas
+	}
+	if replicas > desiredReplicas {
+		replicas = desiredReplicas
+	}
+	return replicas
+}
+
+// getAvailableReplicas returns the ready replicas of a lbset but at most the desired replicas of the lbset.
+// Ready machines which are already scaled down are not available anymore.
+func getAvailableReplicas(lbs *yawolv1beta1.LoadBalancerSet) int {
+	if lbs.Status.ReadyReplicas == nil {
+		return 0
+	}
+	if *lbs.Status.ReadyReplicas > lbs.Spec.Replicas {
+		return lbs.Spec.Replicas
+	}
+	return *lbs.Status.ReadyReplicas
+}
+
+func sumReplicas(lbsList []yawolv1beta1.LoadBalancerSet) int {
+	var replicas int
+	for i := range lbsList {
+		replicas += lbsList[i].Spec.Replicas
+	}
+	return replicas
 }
 
 func (r *Reconciler) deletionRoutine(
@@ -826,7 +1522,7 @@ func (r *Reconciler) deletionRoutine(
 
 // Deletes floating ips related to the LoadBalancer object
 // 1. Retrieves FIP by ID in lb.Status.FloatingID
-// 1.1 if found => delete FIP
+// 1.1 if found => delete FIP (FIPs not managed by yawol are only unbound)
 // 2. Retrieves FIP by Name in lb.Status.FloatingName
 // 2.1 if found => delete FIP
 // Returns any error except for 404 errors from gophercloud
@@ -887,7 +1583,7 @@ func (r *Reconciler) deleteFips(
 		}
 
 		if fip != nil {
-			if err := openstackhelper.DeleteFIP(ctx, fipClient, fip.ID); err != nil {
+			if err := r.releaseFIP(ctx, fipClient, lb, fip); err != nil {
 				r.Log.Info("an unexpected error occurred deleting fip", "lb", lb.Namespace+"/"+lb.Name, "fipId", *lb.Status.FloatingID)
 				return false, kubernetes.SendErrorAsEvent(r.RecorderLB, err, lb)
 			}
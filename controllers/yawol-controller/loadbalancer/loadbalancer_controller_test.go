@@ -11,21 +11,27 @@ import (
 	. "github.com/onsi/gomega"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+	"github.com/stackitcloud/yawol/internal/helper"
 	"github.com/stackitcloud/yawol/internal/openstack"
 	"github.com/stackitcloud/yawol/internal/openstack/testing"
 	v1 "k8s.io/api/core/v1"
 
 	k8sErrors "k8s.io/apimachinery/pkg/api/errors"
+	"k8s.io/apimachinery/pkg/api/meta"
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"k8s.io/utils/pointer"
 	runtimeClient "sigs.k8s.io/controller-runtime/pkg/client"
 )
@@ -114,6 +120,244 @@ var _ = Describe("loadbalancer controller", func() {
 		})
 	})
 
+	When("dual-stack ip families are set", func() {
+		BeforeEach(func() {
+			lb.Spec.Options.InternalLB = true
+			lb.Spec.Options.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+
+			// the fake port client only creates ports with an IPv4 address
+			portClient := client.PortClientObj.(*testing.CallbackPortClient)
+			createPort := portClient.CreateFunc
+			portClient.CreateFunc = func(ctx context.Context, opts ports.CreateOptsBuilder) (*ports.Port, error) {
+				port, err := createPort(ctx, opts)
+				if err != nil {
+					return nil, err
+				}
+				port.FixedIPs = append(port.FixedIPs, ports.IP{IPAddress: "fd00::1"})
+				return port, nil
+			}
+		})
+
+		It("should set the external ips of both ip families", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIP).ToNot(BeNil())
+				g.Expect(act.Status.ExternalIPs).Should(Equal([]string{*act.Status.ExternalIP, "fd00::1"}))
+				return nil
+			})
+		})
+	})
+
+	When("dual-stack is required but the port has no IPv6 address", func() {
+		BeforeEach(func() {
+			requireDualStack := v1.IPFamilyPolicyRequireDualStack
+			lb.Spec.Options.InternalLB = true
+			lb.Spec.Options.IPFamilies = []v1.IPFamily{v1.IPv4Protocol, v1.IPv6Protocol}
+			lb.Spec.Options.IPFamilyPolicy = &requireDualStack
+		})
+
+		It("should send an event and not set the external ips", func() {
+			Eventually(func(g Gomega) {
+				var eventList v1.EventList
+				g.Expect(k8sClient.List(ctx, &eventList)).Should(Succeed())
+
+				found := false
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == lbNN.Name &&
+						strings.HasPrefix(event.Message, helper.ErrNoFixedIPForIPFamily.Error()) {
+						found = true
+					}
+				}
+				g.Expect(found).Should(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIPs).Should(BeEmpty())
+				return nil
+			})
+		})
+	})
+
+	When("a loadBalancerIP is requested", func() {
+		var userFIP *floatingips.FloatingIP
+
+		BeforeEach(func() {
+			lb.Spec.LoadBalancerIP = pointer.String("192.0.2.10")
+
+			fipClient, err := client.FipClient(ctx)
+			Expect(err).ToNot(HaveOccurred())
+			userFIP, err = fipClient.Create(ctx, floatingips.CreateOpts{
+				Description: "user managed fip",
+				FloatingIP:  "192.0.2.20",
+			})
+			Expect(err).ToNot(HaveOccurred())
+		})
+
+		It("should allocate the fip and re-associate it on change", func() {
+			fipClient, err := client.FipClient(ctx)
+			Expect(err).ToNot(HaveOccurred())
+
+			By("checking that the fip is allocated with the requested ip")
+			var allocatedFIPID string
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.FloatingID).ToNot(BeNil())
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("192.0.2.10")))
+				allocatedFIPID = *act.Status.FloatingID
+				return nil
+			})
+
+			By("switching to the existing fip")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.LoadBalancerIP = pointer.String("192.0.2.20")
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.FloatingID).To(Equal(&userFIP.ID))
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("192.0.2.20")))
+				g.Expect(act.Status.PortID).ToNot(BeNil())
+
+				fip, err := fipClient.Get(ctx, userFIP.ID)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(fip.PortID).To(Equal(*act.Status.PortID))
+				return nil
+			})
+
+			By("checking that the allocated fip is deleted")
+			_, err = fipClient.Get(ctx, allocatedFIPID)
+			Expect(err).To(HaveOccurred())
+
+			By("switching back to a new fip")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.LoadBalancerIP = pointer.String("192.0.2.10")
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("192.0.2.10")))
+				return nil
+			})
+
+			By("checking that the user managed fip is only unbound")
+			fip, err := fipClient.Get(ctx, userFIP.ID)
+			Expect(err).ToNot(HaveOccurred())
+			Expect(fip.PortID).To(BeEmpty())
+		})
+	})
+
+	When("a loadBalancerIP is requested for an internal lb", func() {
+		BeforeEach(func() {
+			lb.Spec.Options.InternalLB = true
+			lb.Spec.LoadBalancerIP = pointer.String("10.1.0.50")
+
+			client.StoredValues["subnets"].(map[string]*subnets.Subnet)["subnet-id"] = &subnets.Subnet{
+				ID:        "subnet-id",
+				NetworkID: "network-id",
+				IPVersion: 4,
+				CIDR:      "10.1.0.0/24",
+			}
+		})
+
+		It("should use the loadBalancerIP as fixed ip of the port", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("10.1.0.50")))
+				return nil
+			})
+
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.LoadBalancerIP = pointer.String("10.1.0.51")
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("10.1.0.51")))
+				g.Expect(act.Status.ExternalIPs).To(Equal([]string{"10.1.0.51"}))
+				return nil
+			})
+		})
+	})
+
+	When("a fixed ip is requested", func() {
+		BeforeEach(func() {
+			lb.Spec.Options.InternalLB = true
+			lb.Spec.Options.SubnetID = "subnet-b"
+			lb.Spec.Options.FixedIP = "10.2.0.10"
+
+			snts := client.StoredValues["subnets"].(map[string]*subnets.Subnet)
+			snts["subnet-a"] = &subnets.Subnet{ID: "subnet-a", NetworkID: "network-id", IPVersion: 4, CIDR: "10.1.0.0/24"}
+			snts["subnet-b"] = &subnets.Subnet{ID: "subnet-b", NetworkID: "network-id", IPVersion: 4, CIDR: "10.2.0.0/24"}
+		})
+
+		It("should create the port with the fixed ip in the subnet", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ExternalIP).To(Equal(pointer.String("10.2.0.10")))
+				g.Expect(act.Status.PortID).ToNot(BeNil())
+
+				port, err := client.PortClientObj.Get(ctx, *act.Status.PortID)
+				g.Expect(err).ToNot(HaveOccurred())
+				g.Expect(port.FixedIPs).To(Equal([]ports.IP{{SubnetID: "subnet-b", IPAddress: "10.2.0.10"}}))
+				return nil
+			})
+		})
+	})
+
+	When("the requested fixed ip is not available", func() {
+		expectEvent := func(expectedErr error) {
+			Eventually(func(g Gomega) {
+				var eventList v1.EventList
+				g.Expect(k8sClient.List(ctx, &eventList)).Should(Succeed())
+
+				found := false
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == lbNN.Name &&
+						strings.HasPrefix(event.Message, expectedErr.Error()) {
+						found = true
+					}
+				}
+				g.Expect(found).Should(BeTrue())
+			}, timeout, interval).Should(Succeed())
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.PortID).To(BeNil())
+				return nil
+			})
+		}
+
+		BeforeEach(func() {
+			lb.Spec.Options.InternalLB = true
+
+			client.StoredValues["subnets"].(map[string]*subnets.Subnet)["subnet-id"] = &subnets.Subnet{
+				ID:        "subnet-id",
+				NetworkID: "network-id",
+				IPVersion: 4,
+				CIDR:      "10.1.0.0/24",
+			}
+		})
+
+		When("it is used by another port", func() {
+			BeforeEach(func() {
+				lb.Spec.Options.FixedIP = "10.1.0.20"
+
+				_, err := client.PortClientObj.Create(ctx, ports.CreateOpts{
+					Name:      "other-port",
+					NetworkID: "network-id",
+					FixedIPs:  []ports.IP{{SubnetID: "subnet-id", IPAddress: "10.1.0.20"}},
+				})
+				Expect(err).ToNot(HaveOccurred())
+			})
+
+			It("should send an event and not create the port", func() {
+				expectEvent(helper.ErrFixedIPInUse)
+			})
+		})
+
+		When("it is not in a subnet of the network", func() {
+			BeforeEach(func() {
+				lb.Spec.Options.FixedIP = "10.9.0.20"
+			})
+
+			It("should send an event and not create the port", func() {
+				expectEvent(helper.ErrFixedIPNotInSubnet)
+			})
+		})
+	})
+
 	When("we deploy an external lb", func() {
 		It("should swap to an internal lb", func() {
 			By("checking that the lb gets created with an public ip")
@@ -291,6 +535,524 @@ var _ = Describe("loadbalancer controller", func() {
 		})
 	}) // loadbalancerset context
 
+	When("the image is referenced by name", func() {
+		BeforeEach(func() {
+			imgs := client.StoredValues["images"].(map[string]*images.Image)
+			imgs["old-image-id"] = &images.Image{
+				ID:        "old-image-id",
+				Name:      "yawol-image",
+				Status:    images.ImageStatusActive,
+				CreatedAt: time.Now().Add(-time.Hour),
+			}
+			imgs["new-image-id"] = &images.Image{
+				ID:        "new-image-id",
+				Name:      "yawol-image",
+				Status:    images.ImageStatusActive,
+				CreatedAt: time.Now(),
+			}
+			imgs["queued-image-id"] = &images.Image{
+				ID:        "queued-image-id",
+				Name:      "yawol-image",
+				Status:    images.ImageStatusQueued,
+				CreatedAt: time.Now().Add(time.Hour),
+			}
+
+			lb.Spec.Infrastructure.Image = &yawolv1beta1.OpenstackImageRef{
+				ImageName: pointer.String("yawol-image"),
+			}
+		})
+
+		It("should resolve the newest active image", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ImageID).ToNot(BeNil())
+				g.Expect(*act.Status.ImageID).To(Equal("new-image-id"))
+				return nil
+			})
+		})
+
+		It("should remove the resolved image when switching to an image id", func() {
+			By("waiting for the image to be resolved")
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ImageID).ToNot(BeNil())
+				return nil
+			})
+
+			By("switching to an image id")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Infrastructure.Image = &yawolv1beta1.OpenstackImageRef{
+					ImageID: pointer.String("image-id"),
+				}
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.ImageID).To(BeNil())
+				return nil
+			})
+		})
+	}) // image referenced by name
+
+	When("the flavor is referenced by search", func() {
+		BeforeEach(func() {
+			flvs := client.StoredValues["flavors"].(map[string]*flavors.Flavor)
+			flvs["small-flavor-id"] = &flavors.Flavor{ID: "small-flavor-id", Name: "s1.small"}
+			flvs["yawol-flavor-id"] = &flavors.Flavor{ID: "yawol-flavor-id", Name: "s1.medium"}
+			client.StoredValues["extraspecs"].(map[string]map[string]string)["yawol-flavor-id"] = map[string]string{
+				"yawol": "true",
+			}
+
+			lb.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+				FlavorSearch: pointer.String("yawol=true"),
+			}
+		})
+
+		It("should resolve the flavor and pin it in the loadbalancerset", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.FlavorID).ToNot(BeNil())
+				g.Expect(*act.Status.FlavorID).To(Equal("yawol-flavor-id"))
+				return nil
+			})
+
+			Eventually(func(g Gomega) {
+				var lbsetList yawolv1beta1.LoadBalancerSetList
+				g.Expect(k8sClient.List(ctx, &lbsetList, &runtimeClient.ListOptions{
+					LabelSelector: labels.SelectorFromSet(lb.Spec.Selector.MatchLabels),
+				})).Should(Succeed())
+
+				g.Expect(len(lbsetList.Items)).Should(Equal(1))
+				flavor := lbsetList.Items[0].Spec.Template.Spec.Infrastructure.Flavor
+				g.Expect(flavor).ToNot(BeNil())
+				g.Expect(flavor.FlavorID).To(Equal(pointer.String("yawol-flavor-id")))
+				g.Expect(flavor.FlavorSearch).To(BeNil())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("should remove the resolved flavor when switching to a flavor id", func() {
+			By("waiting for the flavor to be resolved")
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.FlavorID).ToNot(BeNil())
+				return nil
+			})
+
+			By("switching to a flavor id")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+					FlavorID: pointer.String("flavor-id"),
+				}
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.FlavorID).To(BeNil())
+				return nil
+			})
+		})
+	}) // flavor referenced by search
+
+	When("a rollout strategy is set", func() {
+		BeforeEach(func() {
+			maxSurge := intstr.FromInt(1)
+			maxUnavailable := intstr.FromInt(0)
+			lb.Spec.Replicas = 3
+			lb.Spec.Strategy = &yawolv1beta1.LoadBalancerStrategy{
+				MaxSurge:       &maxSurge,
+				MaxUnavailable: &maxUnavailable,
+			}
+		})
+
+		It("should only surge the new lbset by maxSurge", func() {
+			By("waiting for lbset creation")
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				var lbsetList yawolv1beta1.LoadBalancerSetList
+				g.Expect(k8sClient.List(ctx, &lbsetList, &runtimeClient.ListOptions{
+					LabelSelector: labels.SelectorFromSet(lb.Spec.Selector.MatchLabels),
+				})).Should(Succeed())
+
+				g.Expect(len(lbsetList.Items)).Should(Equal(1))
+				g.Expect(lbsetList.Items[0].Spec.Replicas).Should(Equal(3))
+				return nil
+			})
+
+			By("changing flavorid")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+					FlavorID: pointer.String("somenewid"),
+				}
+			})
+
+			By("checking that the new lbset is only scaled up by one and the old lbset is kept")
+			checkReplicas := func(g Gomega) {
+				var lbsetList yawolv1beta1.LoadBalancerSetList
+				g.Expect(k8sClient.List(ctx, &lbsetList, &runtimeClient.ListOptions{
+					LabelSelector: labels.SelectorFromSet(lb.Spec.Selector.MatchLabels),
+				})).Should(Succeed())
+
+				var replicas []int
+				for i := range lbsetList.Items {
+					replicas = append(replicas, lbsetList.Items[i].Spec.Replicas)
+				}
+				g.Expect(replicas).Should(ConsistOf(3, 1))
+			}
+			Eventually(checkReplicas, timeout, interval).Should(Succeed())
+			Consistently(checkReplicas, 5*time.Second, interval).Should(Succeed())
+		})
+	}) // rollout strategy
+
+	When("the infrastructure changed", func() {
+		JustBeforeEach(func() {
+			By("waiting for the first lbset")
+			Eventually(func(g Gomega) {
+				g.Expect(getLBSetsByRevision(g, lb)).Should(HaveKey("1"))
+			}, timeout, interval).Should(Succeed())
+
+			By("changing flavorid")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+					FlavorID: pointer.String("somenewid"),
+				}
+			})
+
+			By("waiting for the second lbset")
+			Eventually(func(g Gomega) {
+				g.Expect(getLBSetsByRevision(g, lb)).Should(HaveKey("2"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("should roll back to an earlier revision", func() {
+			By("marking the first lbset as ready")
+			Eventually(func(g Gomega) {
+				lbset := getLBSetsByRevision(g, lb)["1"]
+				g.Expect(patchLBSetStatus(&lbset, yawolv1beta1.LoadBalancerSetStatus{
+					Replicas:      intPtr(1),
+					ReadyReplicas: intPtr(1),
+				})).Should(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("rolling back to the first revision")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.RollbackTo = &yawolv1beta1.LoadBalancerRollbackConfig{Revision: 1}
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Annotations[RevisionAnnotation]).Should(Equal("1"))
+
+				lbsets := getLBSetsByRevision(g, lb)
+				g.Expect(lbsets["1"].Spec.Replicas).Should(Equal(1))
+				g.Expect(lbsets["2"].Spec.Replicas).Should(Equal(0))
+				return nil
+			})
+		})
+
+		It("should send an event if the rollback revision does not exist", func() {
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.RollbackTo = &yawolv1beta1.LoadBalancerRollbackConfig{Revision: 42}
+			})
+
+			Eventually(func(g Gomega) {
+				var eventList v1.EventList
+				g.Expect(k8sClient.List(ctx, &eventList)).Should(Succeed())
+
+				found := false
+				for _, event := range eventList.Items {
+					if event.InvolvedObject.Name == lbNN.Name &&
+						strings.HasPrefix(event.Message, helper.ErrRollbackRevisionNotFound.Error()) {
+						found = true
+					}
+				}
+				g.Expect(found).Should(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+
+		When("the revision history limit is zero", func() {
+			BeforeEach(func() {
+				lb.Spec.RevisionHistoryLimit = pointer.Int(0)
+			})
+
+			It("should delete the old lbset after the rollout", func() {
+				By("marking the second lbset as ready")
+				Eventually(func(g Gomega) {
+					lbset := getLBSetsByRevision(g, lb)["2"]
+					g.Expect(patchLBSetStatus(&lbset, yawolv1beta1.LoadBalancerSetStatus{
+						Replicas:      intPtr(1),
+						ReadyReplicas: intPtr(1),
+					})).Should(Succeed())
+				}, timeout, interval).Should(Succeed())
+
+				Eventually(func(g Gomega) {
+					lbsets := getLBSetsByRevision(g, lb)
+					g.Expect(lbsets).ShouldNot(HaveKey("1"))
+					g.Expect(lbsets).Should(HaveKey("2"))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+	}) // infrastructure changed
+
+	When("the lb is paused", func() {
+		BeforeEach(func() {
+			lb.Spec.Paused = true
+		})
+
+		It("should set the paused condition and not reconcile", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				condition := meta.FindStatusCondition(act.Status.Conditions, string(helper.Paused))
+				g.Expect(condition).ToNot(BeNil())
+				g.Expect(condition.Status).To(Equal(metav1.ConditionTrue))
+				return nil
+			})
+
+			Consistently(func(g Gomega) {
+				var act LB
+				g.Expect(k8sClient.Get(ctx, lbNN, &act)).Should(Succeed())
+				g.Expect(act.Status.PortID).To(BeNil())
+				g.Expect(getLBSetsByRevision(g, lb)).To(BeEmpty())
+			}, 5*time.Second, interval).Should(Succeed())
+		})
+
+		It("should reconcile again after resuming", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.Paused))).To(BeTrue())
+				return nil
+			})
+
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Paused = false
+			})
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(meta.IsStatusConditionFalse(act.Status.Conditions, string(helper.Paused))).To(BeTrue())
+				g.Expect(act.Status.PortID).ToNot(BeNil())
+				g.Expect(getLBSetsByRevision(g, lb)).ToNot(BeEmpty())
+				return nil
+			})
+		})
+	}) // paused
+
+	Context("vrrp", func() {
+		It("should assign a router id and a keepalived secret to the lbset", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(act.Status.VRRPRouterID).ShouldNot(BeNil())
+				g.Expect(act.Status.KeepalivedSecretName).ShouldNot(BeNil())
+
+				lbsets := getLBSetsByRevision(g, lb)
+				g.Expect(lbsets).Should(HaveKey("1"))
+				spec := lbsets["1"].Spec.Template.Spec
+				g.Expect(spec.VRRPRouterID).Should(Equal(*act.Status.VRRPRouterID))
+				g.Expect(spec.KeepalivedSecretRef).ShouldNot(BeNil())
+				g.Expect(spec.KeepalivedSecretRef.Name).Should(Equal(*act.Status.KeepalivedSecretName))
+
+				var secret v1.Secret
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: spec.KeepalivedSecretRef.Namespace,
+					Name:      spec.KeepalivedSecretRef.Name,
+				}, &secret)).Should(Succeed())
+				g.Expect(secret.Data[helper.KeepalivedAuthPassKey]).Should(HaveLen(8))
+				return nil
+			})
+		})
+
+		It("should share the keepalived secret between lbsets and rotate it after the rollout", func() {
+			getAuthPass := func(g Gomega) (string, string) {
+				var secret v1.Secret
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      helper.GetKeepalivedSecretName(lb),
+				}, &secret)).Should(Succeed())
+				return string(secret.Data[helper.KeepalivedAuthPassKey]), secret.Annotations[helper.RevisionAnnotation]
+			}
+
+			By("waiting for the first lbset")
+			var authPass string
+			Eventually(func(g Gomega) {
+				g.Expect(getLBSetsByRevision(g, lb)).Should(HaveKey("1"))
+				var revision string
+				authPass, revision = getAuthPass(g)
+				g.Expect(authPass).Should(HaveLen(8))
+				g.Expect(revision).Should(Equal("1"))
+			}, timeout, interval).Should(Succeed())
+
+			By("changing flavorid")
+			updateLB(lbNN, func(act *LB) {
+				act.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+					FlavorID: pointer.String("somenewid"),
+				}
+			})
+
+			By("checking that both lbsets use the same secret and password during the rollout")
+			checkRollout := func(g Gomega) {
+				lbsets := getLBSetsByRevision(g, lb)
+				g.Expect(lbsets).Should(HaveKey("2"))
+				g.Expect(lbsets["1"].Spec.Template.Spec.KeepalivedSecretRef).Should(Equal(
+					lbsets["2"].Spec.Template.Spec.KeepalivedSecretRef))
+
+				currentPass, _ := getAuthPass(g)
+				g.Expect(currentPass).Should(Equal(authPass))
+			}
+			Eventually(checkRollout, timeout, interval).Should(Succeed())
+			Consistently(checkRollout, 3*time.Second, interval).Should(Succeed())
+
+			By("creating a machine of the second lbset")
+			lbm := yawolv1beta1.LoadBalancerMachine{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      "testlb-keepalived-lbm",
+					Namespace: namespace,
+					Labels:    lb.Spec.Selector.MatchLabels,
+				},
+				Spec: yawolv1beta1.LoadBalancerMachineSpec{
+					Infrastructure:  lb.Spec.Infrastructure,
+					PortID:          "port-id",
+					LoadBalancerRef: yawolv1beta1.LoadBalancerRef{Name: lb.Name, Namespace: namespace},
+				},
+			}
+			Expect(k8sClient.Create(ctx, &lbm)).Should(Succeed())
+			defer func() {
+				Expect(runtimeClient.IgnoreNotFound(k8sClient.Delete(ctx, &lbm))).Should(Succeed())
+			}()
+
+			By("marking the second lbset as ready")
+			Eventually(func(g Gomega) {
+				lbset := getLBSetsByRevision(g, lb)["2"]
+				g.Expect(patchLBSetStatus(&lbset, yawolv1beta1.LoadBalancerSetStatus{
+					Replicas:      intPtr(1),
+					ReadyReplicas: intPtr(1),
+				})).Should(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			By("checking that the new password is added after the old lbset is scaled down")
+			var secret v1.Secret
+			checkNextAuthPass := func(g Gomega) {
+				g.Expect(getLBSetsByRevision(g, lb)["1"].Spec.Replicas).Should(Equal(0))
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      helper.GetKeepalivedSecretName(lb),
+				}, &secret)).Should(Succeed())
+				g.Expect(secret.Annotations[helper.RevisionAnnotation]).Should(Equal("2"))
+				g.Expect(secret.Data[helper.KeepalivedAuthPassKey]).Should(Equal([]byte(authPass)))
+				g.Expect(secret.Data[helper.KeepalivedNextAuthPassKey]).Should(HaveLen(8))
+				g.Expect(secret.Data[helper.KeepalivedNextAuthPassKey]).ShouldNot(Equal([]byte(authPass)))
+			}
+			Eventually(checkNextAuthPass, timeout, interval).Should(Succeed())
+
+			By("checking that the password is kept until the machine knows the new password")
+			Consistently(checkNextAuthPass, 3*time.Second, interval).Should(Succeed())
+			nextAuthPass := string(secret.Data[helper.KeepalivedNextAuthPassKey])
+
+			By("reporting the secret version from the machine")
+			Expect(k8sClient.Status().Patch(ctx, &lbm, runtimeClient.RawPatch(types.MergePatchType,
+				[]byte(`{"status":{"keepalivedSecretVersion":"`+secret.ResourceVersion+`"}}`)))).Should(Succeed())
+
+			By("checking that the new password is used")
+			Eventually(func(g Gomega) {
+				currentPass, revision := getAuthPass(g)
+				g.Expect(currentPass).Should(Equal(nextAuthPass))
+				g.Expect(revision).Should(Equal("2"))
+				g.Expect(k8sClient.Get(ctx, types.NamespacedName{
+					Namespace: namespace,
+					Name:      helper.GetKeepalivedSecretName(lb),
+				}, &secret)).Should(Succeed())
+				g.Expect(secret.Data).ShouldNot(HaveKey(helper.KeepalivedNextAuthPassKey))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		When("an older lb in the same network uses the router id", func() {
+			otherNN := types.NamespacedName{Name: "testlb-other", Namespace: namespace}
+
+			BeforeEach(func() {
+				other := getMockLB(otherNN)
+				Expect(k8sClient.Create(ctx, other)).Should(Succeed())
+				patchStatus(other, yawolv1beta1.LoadBalancerStatus{VRRPRouterID: pointer.Int32(1)})
+
+				lb.Status.VRRPRouterID = pointer.Int32(1)
+			})
+
+			AfterEach(func() {
+				cleanupLB(otherNN, timeout)
+			})
+
+			It("should change the router id of the newer lb", func() {
+				hopefully(lbNN, func(g Gomega, act LB) error {
+					g.Expect(act.Status.VRRPRouterID).ShouldNot(BeNil())
+					g.Expect(*act.Status.VRRPRouterID).ShouldNot(Equal(int32(1)))
+					return nil
+				})
+
+				hopefully(otherNN, func(g Gomega, act LB) error {
+					g.Expect(act.Status.VRRPRouterID).Should(Equal(pointer.Int32(1)))
+					return nil
+				})
+			})
+		})
+
+		When("an older lb in the same network of another namespace uses the router id", func() {
+			otherNN := types.NamespacedName{Name: "testlb-other", Namespace: namespace + "-other"}
+
+			BeforeEach(func() {
+				ns := v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: otherNN.Namespace}}
+				if err := apiClient.Create(ctx, &ns); !k8sErrors.IsAlreadyExists(err) {
+					Expect(err).ShouldNot(HaveOccurred())
+				}
+
+				// the lb is not in the cache of the manager and therefore not reconciled
+				other := getMockLB(otherNN)
+				Expect(apiClient.Create(ctx, other)).Should(Succeed())
+				jsonData, err := json.Marshal(yawolv1beta1.LoadBalancerStatus{VRRPRouterID: pointer.Int32(1)})
+				Expect(err).ShouldNot(HaveOccurred())
+				Expect(apiClient.Status().Patch(ctx, other,
+					runtimeClient.RawPatch(types.MergePatchType, []byte(`{"status": `+string(jsonData)+`}`)),
+				)).Should(Succeed())
+
+				lb.Status.VRRPRouterID = pointer.Int32(1)
+			})
+
+			AfterEach(func() {
+				Expect(runtimeClient.IgnoreNotFound(apiClient.Delete(ctx, &LB{
+					ObjectMeta: metav1.ObjectMeta{Namespace: otherNN.Namespace, Name: otherNN.Name},
+				}))).Should(Succeed())
+			})
+
+			It("should change the router id of the newer lb", func() {
+				hopefully(lbNN, func(g Gomega, act LB) error {
+					g.Expect(act.Status.VRRPRouterID).ShouldNot(BeNil())
+					g.Expect(*act.Status.VRRPRouterID).ShouldNot(Equal(int32(1)))
+					return nil
+				})
+			})
+		})
+	}) // vrrp
+
+	Context("conditions", func() {
+		It("should set the openstack conditions and observed generation", func() {
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.SecurityGroupReady))).To(BeTrue())
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.FloatingIPReady))).To(BeTrue())
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.PortReady))).To(BeTrue())
+				g.Expect(act.Status.ObservedGeneration).To(Equal(act.Generation))
+				return nil
+			})
+		})
+
+		It("should be available after the rollout", func() {
+			By("waiting for the rollout to start")
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.RolloutInProgress))).To(BeTrue())
+				g.Expect(meta.IsStatusConditionFalse(act.Status.Conditions, string(helper.Available))).To(BeTrue())
+				return nil
+			})
+
+			By("marking the lbset as ready")
+			Eventually(func(g Gomega) {
+				lbset := getLBSetsByRevision(g, lb)["1"]
+				g.Expect(patchLBSetStatus(&lbset, yawolv1beta1.LoadBalancerSetStatus{
+					Replicas:      intPtr(1),
+					ReadyReplicas: intPtr(1),
+				})).Should(Succeed())
+			}, timeout, interval).Should(Succeed())
+
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				g.Expect(meta.IsStatusConditionFalse(act.Status.Conditions, string(helper.RolloutInProgress))).To(BeTrue())
+				g.Expect(meta.IsStatusConditionTrue(act.Status.Conditions, string(helper.Available))).To(BeTrue())
+				return nil
+			})
+		})
+	}) // conditions
+
 	Context("security group rules", func() {
 		BeforeEach(func() {
 			lb.Spec.Ports = []v1.ServicePort{
@@ -480,6 +1242,22 @@ var _ = Describe("loadbalancer controller", func() {
 				return fmt.Errorf("expected event not found")
 			}, timeout, interval).Should(Succeed())
 		})
+
+		It("should not update the observed generation", func() {
+			By("waiting for the failed reconciliation")
+			hopefully(lbNN, func(g Gomega, act LB) error {
+				condition := meta.FindStatusCondition(act.Status.Conditions, string(helper.SecurityGroupReady))
+				g.Expect(condition).ShouldNot(BeNil())
+				g.Expect(condition.Reason).Should(Equal("ReconcileFailed"))
+				return nil
+			})
+
+			Consistently(func(g Gomega) {
+				var act LB
+				g.Expect(k8sClient.Get(ctx, lbNN, &act)).Should(Succeed())
+				g.Expect(act.Status.ObservedGeneration).ShouldNot(Equal(act.Generation))
+			}, 5*time.Second, interval).Should(Succeed())
+		})
 	}) // openstack not working context
 
 	Context("clean up openstack", func() {
@@ -593,6 +1371,20 @@ func cleanupLB(lbNN types.NamespacedName, timeout time.Duration) {
 	}, timeout, interval).Should(Succeed())
 }
 
+// getLBSetsByRevision returns all lbsets of the lb mapped by their revision annotation
+func getLBSetsByRevision(g Gomega, lb *LB) map[string]yawolv1beta1.LoadBalancerSet {
+	var lbsetList yawolv1beta1.LoadBalancerSetList
+	g.Expect(k8sClient.List(ctx, &lbsetList, &runtimeClient.ListOptions{
+		LabelSelector: labels.SelectorFromSet(lb.Spec.Selector.MatchLabels),
+	})).Should(Succeed())
+
+	lbsets := make(map[string]yawolv1beta1.LoadBalancerSet, len(lbsetList.Items))
+	for i := range lbsetList.Items {
+		lbsets[lbsetList.Items[i].Annotations[RevisionAnnotation]] = lbsetList.Items[i]
+	}
+	return lbsets
+}
+
 func getDesiredSecGroups(remoteID string) []rules.SecGroupRule {
 	desiredSecGroups := []rules.SecGroupRule{}
 	etherTypes := []rules.RuleEtherType{rules.EtherType4, rules.EtherType6}
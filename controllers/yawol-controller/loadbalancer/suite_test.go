@@ -34,6 +34,7 @@ const secretName = "testsecret"
 var (
 	cfg                    *rest.Config
 	k8sClient              client.Client
+	apiClient              client.Client // not limited to the namespace of the manager cache
 	testEnv                *envtest.Environment
 	loadBalancerReconciler *Reconciler
 	ctx                    context.Context
@@ -73,6 +74,7 @@ var _ = BeforeSuite(func() {
 	k8sClient, err = client.New(cfg, client.Options{Scheme: scheme.Scheme})
 	Expect(err).ToNot(HaveOccurred())
 	Expect(k8sClient).ToNot(BeNil())
+	apiClient = k8sClient
 
 	k8sManager, err := ctrl.NewManager(cfg, ctrl.Options{
 		Scheme:             scheme.Scheme,
@@ -98,6 +100,7 @@ var _ = BeforeSuite(func() {
 
 	loadBalancerReconciler = &Reconciler{
 		Client:           k8sManager.GetClient(),
+		APIReader:        k8sManager.GetAPIReader(),
 		Log:              ctrl.Log.WithName("controllers").WithName("LoadBalancer"),
 		Scheme:           k8sManager.GetScheme(),
 		RecorderLB:       k8sManager.GetEventRecorderFor("yawol-service"),
@@ -3,7 +3,7 @@ package loadbalancermachine
 import (
 	"context"
 	"fmt"
-	"regexp"
+	"reflect"
 	"strings"
 	"time"
 
@@ -41,9 +41,6 @@ const (
 	DefaultRequeueTime = 10 * time.Millisecond
 )
 
-var ipv4Regex = `^(((25[0-5]|2[0-4][0-9]|[01]?[0-9][0-9]?)(\.|$)){4})`
-var ipv4RegexC, _ = regexp.Compile(ipv4Regex)
-
 // LoadBalancerMachineReconciler reconciles service Objects with type LoadBalancer
 type LoadBalancerMachineReconciler struct { //nolint:revive // naming from kubebuilder
 	client.Client
@@ -141,6 +138,11 @@ func (r *LoadBalancerMachineReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
+	// stop reconciliation if lb is paused, requeue to continue after the lb is resumed
+	if helper.IsLoadBalancerPaused(loadbalancer) {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
 	// Reconcile ServiceAccount for yawollet access
 	var sa v1.ServiceAccount
 	var res ctrl.Result
@@ -167,8 +169,8 @@ func (r *LoadBalancerMachineReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	var vip string
-	vip, err = r.reconcilePortAddressPair(ctx, osClient, loadBalancerMachine, loadbalancer)
+	var vips []string
+	vips, err = r.reconcilePortAddressPair(ctx, osClient, loadBalancerMachine, loadbalancer)
 	if err != nil {
 		if err != nil {
 			return ctrl.Result{}, err
@@ -178,7 +180,7 @@ func (r *LoadBalancerMachineReconciler) Reconcile(ctx context.Context, req ctrl.
 		return ctrl.Result{}, err
 	}
 
-	if err := r.reconcileServer(ctx, osClient, loadbalancer, loadBalancerMachine, sa, vip); err != nil {
+	if err := r.reconcileServer(ctx, osClient, loadbalancer, loadBalancerMachine, sa, vips); err != nil {
 		return ctrl.Result{}, err
 	}
 
@@ -273,6 +275,13 @@ func (r *LoadBalancerMachineReconciler) reconcileRole(
 			return err
 		}
 		r.Log.Info("Role created", "loadBalancerMachineName", loadBalancerMachine.Name)
+	} else if !reflect.DeepEqual(role.Rules, rules) {
+		// roles of older yawol versions are missing new rules
+		role.Rules = rules
+		if err := r.Client.Update(ctx, &role); err != nil {
+			return err
+		}
+		r.Log.Info("Role updated", "loadBalancerMachineName", loadBalancerMachine.Name)
 	}
 
 	roleNamespacedName := types.NamespacedName{Name: role.Name, Namespace: role.Namespace}.String()
@@ -368,7 +377,7 @@ func (r *LoadBalancerMachineReconciler) reconcilePort(
 
 		// create port
 		if port == nil {
-			port, err = openstackhelper.CreatePort(ctx, portClient, portName, lbm.Spec.Infrastructure.NetworkID)
+			port, err = openstackhelper.CreatePort(ctx, portClient, portName, lbm.Spec.Infrastructure.NetworkID, nil)
 			if err != nil {
 				r.Log.Info("unexpected error occurred claiming a port", "lbm", lbm.Name)
 				return kubernetes.SendErrorAsEvent(r.Recorder, err, lbm)
@@ -432,44 +441,61 @@ func (r *LoadBalancerMachineReconciler) reconcilePortAddressPair(
 	osClient os.Client,
 	lbm *yawolv1beta1.LoadBalancerMachine,
 	lb *yawolv1beta1.LoadBalancer,
-) (string, error) {
+) ([]string, error) {
 	var portClient os.PortClient
 
 	var err error
 	portClient, err = osClient.PortClient(ctx)
 	if err != nil {
-		return "", err
+		return nil, err
 	}
 
 	if lbm.Status.PortID == nil {
 		r.Log.Info(helper.ErrLBMPortNotSet.Error(), "lbm", lbm.Name)
-		return "", helper.ErrLBMPortNotSet
+		return nil, helper.ErrLBMPortNotSet
 	}
 
 	portLBM, err := openstackhelper.GetPortByID(ctx, portClient, *lbm.Status.PortID)
 	if err != nil {
-		return "", kubernetes.SendErrorAsEvent(r.Recorder, err, lbm)
+		return nil, kubernetes.SendErrorAsEvent(r.Recorder, err, lbm)
 	}
 
 	portLB, err := openstackhelper.GetPortByID(ctx, portClient, lbm.Spec.PortID)
 	if err != nil {
-		return "", kubernetes.SendErrorAsEvent(r.Recorder, err, lb)
+		return nil, kubernetes.SendErrorAsEvent(r.Recorder, err, lb)
 	}
 
 	if portLB == nil || portLBM == nil {
-		return "", helper.ErrLBOrLBMPortsAreNil
+		return nil, helper.ErrLBOrLBMPortsAreNil
 	}
 
 	if len(portLB.FixedIPs) < 1 {
-		return "", helper.ErrNoFixedIPForLBPort
+		return nil, helper.ErrNoFixedIPForLBPort
+	}
+
+	vips, err := helper.GetVIPsForIPFamilies(lb, portLB.FixedIPs)
+	if err != nil {
+		return nil, kubernetes.SendErrorAsEvent(r.Recorder, err, lbm)
+	}
+
+	// the yawollet writes the VIPs to the keepalived config and
+	// the lbset controller passes the source IP to the other machines as unicast peer
+	var status yawolv1beta1.LoadBalancerMachineStatus
+	if lbm.Status.VIPs == nil || !reflect.DeepEqual(*lbm.Status.VIPs, vips) {
+		status.VIPs = &vips
+	}
+	if srcIP := helper.GetVRRPUnicastSrcIP(lb, portLBM.FixedIPs); srcIP != "" &&
+		(lbm.Status.VRRPUnicastSrcIP == nil || *lbm.Status.VRRPUnicastSrcIP != srcIP) {
+		status.VRRPUnicastSrcIP = &srcIP
+	}
+	if status.VIPs != nil || status.VRRPUnicastSrcIP != nil {
+		if err := helper.PatchLBMStatus(ctx, r.Client.Status(), lbm, status); err != nil {
+			return nil, err
+		}
 	}
 
-	var ipv4 string
 	var addressPair []ports.AddressPair
 	for _, ips := range portLB.FixedIPs {
-		if ipv4RegexC.MatchString(ips.IPAddress) {
-			ipv4 = ips.IPAddress
-		}
 		addressPair = append(addressPair, ports.AddressPair{
 			IPAddress: ips.IPAddress,
 			// MACAddress is set automatically by openstack
@@ -477,10 +503,6 @@ func (r *LoadBalancerMachineReconciler) reconcilePortAddressPair(
 		})
 	}
 
-	if ipv4 == "" {
-		return "", helper.ErrNoFixedIPForLBPort
-	}
-
 	if len(portLBM.AllowedAddressPairs) == len(portLB.FixedIPs) {
 		uptodate := true
 		for i := range portLBM.AllowedAddressPairs {
@@ -490,13 +512,13 @@ func (r *LoadBalancerMachineReconciler) reconcilePortAddressPair(
 			}
 		}
 		if uptodate {
-			// port addressPairs are already uptodate, return only ipv4 until dualstack is implemented
-			return ipv4, nil
+			// port addressPairs are already uptodate
+			return vips, nil
 		}
 	}
 
 	err = openstackhelper.SetAllowedAddressPairsInPort(ctx, portClient, portLBM, &addressPair)
-	return ipv4, err
+	return vips, err
 }
 
 func (r *LoadBalancerMachineReconciler) reconcileServer(
@@ -505,7 +527,7 @@ func (r *LoadBalancerMachineReconciler) reconcileServer(
 	loadbalancer *yawolv1beta1.LoadBalancer,
 	loadBalancerMachine *yawolv1beta1.LoadBalancerMachine,
 	serviceAccount v1.ServiceAccount,
-	vip string,
+	vips []string,
 ) error {
 	var srvClient os.ServerClient
 	var err error
@@ -521,6 +543,20 @@ func (r *LoadBalancerMachineReconciler) reconcileServer(
 		return err
 	}
 
+	keepalivedAuthPass, _, err := helper.GetKeepalivedAuthPass(ctx, r.Client, loadBalancerMachine)
+	if err != nil {
+		return kubernetes.SendErrorAsEvent(r.Recorder, err, loadBalancerMachine)
+	}
+
+	var vrrpUnicastSrcIP string
+	if loadBalancerMachine.Status.VRRPUnicastSrcIP != nil {
+		vrrpUnicastSrcIP = *loadBalancerMachine.Status.VRRPUnicastSrcIP
+	}
+	var vrrpUnicastPeers []string
+	if loadBalancerMachine.Status.VRRPUnicastPeers != nil {
+		vrrpUnicastPeers = *loadBalancerMachine.Status.VRRPUnicastPeers
+	}
+
 	// Generate user-data for yawollet VM
 	userData := helper.GenerateUserData(
 		kubeconfig,
@@ -528,7 +564,11 @@ func (r *LoadBalancerMachineReconciler) reconcileServer(
 		loadBalancerMachine.Name,
 		loadBalancerMachine.Namespace,
 		loadbalancer.Spec.DebugSettings.Enabled,
-		vip,
+		vips,
+		loadBalancerMachine.Spec.VRRPRouterID,
+		keepalivedAuthPass,
+		vrrpUnicastSrcIP,
+		vrrpUnicastPeers,
 	)
 
 	var srv *servers.Server
@@ -550,6 +590,7 @@ func (r *LoadBalancerMachineReconciler) reconcileServer(
 	if srv == nil {
 		srv, err = r.createServer(
 			ctx,
+			osClient,
 			srvClient,
 			loadBalancerMachine,
 			loadbalancer,
@@ -583,6 +624,7 @@ func (r *LoadBalancerMachineReconciler) reconcileServer(
 
 func (r *LoadBalancerMachineReconciler) createServer(
 	ctx context.Context,
+	osClient os.Client,
 	serverClient os.ServerClient,
 	loadBalancerMachine *yawolv1beta1.LoadBalancerMachine,
 	loadBalancer *yawolv1beta1.LoadBalancer,
@@ -592,14 +634,26 @@ func (r *LoadBalancerMachineReconciler) createServer(
 
 	var err error
 
+	var imageClient os.ImageClient
+	imageClient, err = osClient.ImageClient(ctx)
+	if err != nil {
+		return nil, err
+	}
+
 	var imageID string
-	imageID, err = helper.GetImageID(*loadBalancerMachine.Spec.Infrastructure.Image)
+	imageID, err = helper.GetImageID(ctx, imageClient, *loadBalancerMachine.Spec.Infrastructure.Image)
+	if err != nil {
+		return nil, err
+	}
+
+	var flavorClient os.FlavorClient
+	flavorClient, err = osClient.FlavorClient(ctx)
 	if err != nil {
 		return nil, err
 	}
 
 	var flavorID string
-	flavorID, err = helper.GetFlavorID(*loadBalancerMachine.Spec.Infrastructure.Flavor)
+	flavorID, err = helper.GetFlavorID(ctx, flavorClient, *loadBalancerMachine.Spec.Infrastructure.Flavor)
 	if err != nil {
 		return nil, err
 	}
@@ -633,6 +687,12 @@ func (r *LoadBalancerMachineReconciler) createServer(
 		return nil, err
 	}
 
+	if err := helper.PatchLBMStatus(ctx, r.Client.Status(), loadBalancerMachine, yawolv1beta1.LoadBalancerMachineStatus{
+		ImageID: &imageID,
+	}); err != nil {
+		return nil, err
+	}
+
 	err = r.waitForServerStatus(ctx, serverClient, server.ID, []string{"BUILD"}, []string{"ACTIVE"}, 600)
 	return server, err
 }
@@ -4,11 +4,14 @@ import (
 	"context"
 	"encoding/json"
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/gophercloud/gophercloud"
 
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 
 	. "github.com/onsi/ginkgo"
@@ -222,6 +225,167 @@ var _ = Describe("load balancer machine", func() {
 		})
 	}) // openstack not working
 
+	When("the image is referenced by a search", func() {
+		BeforeEach(func() {
+			imgs := client.StoredValues["images"].(map[string]*images.Image)
+			imgs["old-image-id"] = &images.Image{
+				ID:        "old-image-id",
+				Name:      "yawol-old",
+				Status:    images.ImageStatusActive,
+				Tags:      []string{"yawol"},
+				CreatedAt: time.Now().Add(-time.Hour),
+			}
+			imgs["new-image-id"] = &images.Image{
+				ID:         "new-image-id",
+				Name:       "yawol-new",
+				Status:     images.ImageStatusActive,
+				Properties: map[string]interface{}{"purpose": "yawol"},
+				CreatedAt:  time.Now(),
+			}
+			imgs["other-image-id"] = &images.Image{
+				ID:        "other-image-id",
+				Name:      "other",
+				Status:    images.ImageStatusActive,
+				CreatedAt: time.Now().Add(time.Hour),
+			}
+			imgs["near-tag-image-id"] = &images.Image{
+				ID:        "near-tag-image-id",
+				Name:      "my-yawol-test",
+				Status:    images.ImageStatusActive,
+				Tags:      []string{"yawol-old", "my-yawol-test"},
+				CreatedAt: time.Now().Add(time.Hour),
+			}
+			imgs["near-property-image-id"] = &images.Image{
+				ID:         "near-property-image-id",
+				Name:       "yawol",
+				Status:     images.ImageStatusActive,
+				Properties: map[string]interface{}{"purpose": "yawol-test"},
+				CreatedAt:  time.Now().Add(time.Hour),
+			}
+
+			lbm.Spec.Infrastructure.Image = &yawolv1beta1.OpenstackImageRef{
+				ImageSearch: pointer.String("purpose=yawol"),
+			}
+		})
+
+		It("should create the server with the newest exactly matching image", func() {
+			lbmNN := runtimeClient.ObjectKeyFromObject(lbm)
+
+			Eventually(func(g Gomega) {
+				var actual yawolv1beta1.LoadBalancerMachine
+				g.Expect(k8sClient.Get(ctx, lbmNN, &actual)).To(Succeed())
+
+				g.Expect(actual.Status.ServerID).ToNot(BeNil())
+				g.Expect(actual.Status.ImageID).ToNot(BeNil())
+				g.Expect(*actual.Status.ImageID).To(Equal("new-image-id"))
+
+				srv, err := client.ServerClientObj.Get(ctx, *actual.Status.ServerID)
+				g.Expect(err).To(Succeed())
+				g.Expect(srv.Image["id"]).To(Equal("new-image-id"))
+			}, timeout, interval).Should(Succeed())
+		})
+
+		When("the search is a tag", func() {
+			BeforeEach(func() {
+				lbm.Spec.Infrastructure.Image = &yawolv1beta1.OpenstackImageRef{
+					ImageSearch: pointer.String("yawol"),
+				}
+			})
+
+			It("should not match images with similar tags or names", func() {
+				lbmNN := runtimeClient.ObjectKeyFromObject(lbm)
+
+				Eventually(func(g Gomega) {
+					var actual yawolv1beta1.LoadBalancerMachine
+					g.Expect(k8sClient.Get(ctx, lbmNN, &actual)).To(Succeed())
+					g.Expect(actual.Status.ImageID).ToNot(BeNil())
+					g.Expect(*actual.Status.ImageID).To(Equal("old-image-id"))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+
+		When("multiple matching images have the same creation time", func() {
+			BeforeEach(func() {
+				created := time.Now().Add(2 * time.Hour)
+				imgs := client.StoredValues["images"].(map[string]*images.Image)
+				for _, id := range []string{"a-image-id", "c-image-id", "b-image-id"} {
+					imgs[id] = &images.Image{
+						ID:         id,
+						Name:       "yawol",
+						Status:     images.ImageStatusActive,
+						Properties: map[string]interface{}{"purpose": "yawol"},
+						CreatedAt:  created,
+					}
+				}
+			})
+
+			It("should pick the image deterministically", func() {
+				lbmNN := runtimeClient.ObjectKeyFromObject(lbm)
+
+				Eventually(func(g Gomega) {
+					var actual yawolv1beta1.LoadBalancerMachine
+					g.Expect(k8sClient.Get(ctx, lbmNN, &actual)).To(Succeed())
+					g.Expect(actual.Status.ImageID).ToNot(BeNil())
+					g.Expect(*actual.Status.ImageID).To(Equal("c-image-id"))
+				}, timeout, interval).Should(Succeed())
+			})
+		})
+	}) // image referenced by search
+
+	When("the flavor is referenced by name", func() {
+		BeforeEach(func() {
+			flvs := client.StoredValues["flavors"].(map[string]*flavors.Flavor)
+			flvs["small-flavor-id"] = &flavors.Flavor{ID: "small-flavor-id", Name: "s1.small"}
+			flvs["medium-flavor-id"] = &flavors.Flavor{ID: "medium-flavor-id", Name: "s1.medium"}
+
+			lbm.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+				FlavorName: pointer.String("s1.medium"),
+			}
+		})
+
+		It("should create the server", func() {
+			lbmNN := runtimeClient.ObjectKeyFromObject(lbm)
+
+			Eventually(func(g Gomega) {
+				var actual yawolv1beta1.LoadBalancerMachine
+				g.Expect(k8sClient.Get(ctx, lbmNN, &actual)).To(Succeed())
+				g.Expect(actual.Status.ServerID).ToNot(BeNil())
+			}, timeout, interval).Should(Succeed())
+		})
+	}) // flavor referenced by name
+
+	When("the flavor search matches multiple flavors", func() {
+		BeforeEach(func() {
+			flvs := client.StoredValues["flavors"].(map[string]*flavors.Flavor)
+			flvs["first-flavor-id"] = &flavors.Flavor{ID: "first-flavor-id", Name: "first"}
+			flvs["second-flavor-id"] = &flavors.Flavor{ID: "second-flavor-id", Name: "second"}
+
+			extraSpecs := client.StoredValues["extraspecs"].(map[string]map[string]string)
+			extraSpecs["first-flavor-id"] = map[string]string{"yawol": "true"}
+			extraSpecs["second-flavor-id"] = map[string]string{"yawol": "true"}
+
+			lbm.Spec.Infrastructure.Flavor = &yawolv1beta1.OpenstackFlavorRef{
+				FlavorSearch: pointer.String("yawol=true"),
+			}
+		})
+
+		It("should throw an error event", func() {
+			Eventually(func(g Gomega) {
+				var curEvents v1.EventList
+				g.Expect(k8sClient.List(ctx, &curEvents)).To(Succeed())
+
+				eventFound := false
+				for _, curEvent := range curEvents.Items {
+					if curEvent.InvolvedObject.Name == lb.Name &&
+						strings.HasPrefix(curEvent.Message, helper.ErrFlavorAmbiguous.Error()) {
+						eventFound = true
+					}
+				}
+				g.Expect(eventFound).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
+	}) // flavor search matches multiple flavors
+
 	Context("HA features", func() {
 		It("should create openstack resources", func() {
 			lbmNN := runtimeClient.ObjectKeyFromObject(lbm)
@@ -235,6 +399,8 @@ var _ = Describe("load balancer machine", func() {
 				port, err := client.PortClientObj.Get(ctx, *actual.Status.PortID)
 				g.Expect(err).To(Succeed())
 				g.Expect(len(port.AllowedAddressPairs)).To(Equal(1))
+				g.Expect(actual.Status.VRRPUnicastSrcIP).ToNot(BeNil())
+				g.Expect(*actual.Status.VRRPUnicastSrcIP).To(Equal(port.FixedIPs[0].IPAddress))
 
 				var actualLB yawolv1beta1.LoadBalancer
 				g.Expect(k8sClient.Get(ctx, lbNN, &actualLB)).To(Succeed())
@@ -37,6 +37,15 @@ func (r *LoadBalancerMachineStatusReconciler) Reconcile(ctx context.Context, req
 	}
 
 	if shouldMachineBeDeleted(loadBalancerMachine) {
+		// machines are not replaced if the lb is paused
+		paused, err := isLoadBalancerPaused(ctx, r.Client, loadBalancerMachine.Spec.LoadBalancerRef)
+		if err != nil {
+			return ctrl.Result{}, err
+		}
+		if paused {
+			return ctrl.Result{RequeueAfter: 15 * time.Second}, nil
+		}
+
 		if err := r.Client.Delete(ctx, &loadBalancerMachine); err != nil {
 			return ctrl.Result{}, err
 		}
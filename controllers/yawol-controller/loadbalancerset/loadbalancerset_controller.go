@@ -5,6 +5,7 @@ import (
 	"crypto/rand"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"time"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
@@ -52,6 +53,15 @@ func (r *LoadBalancerSetReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return r.deletionRoutine(ctx, &set)
 	}
 
+	// the set and its machines are not changed if the lb is paused
+	paused, err := isLoadBalancerPaused(ctx, r.Client, set.Spec.Template.Spec.LoadBalancerRef)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+	if paused {
+		return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
+	}
+
 	helper.ParseLoadBalancerSetMetrics(
 		set,
 		r.Metrics,
@@ -70,6 +80,15 @@ func (r *LoadBalancerSetReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 		return ctrl.Result{}, err
 	}
 
+	lbMachines, err := r.getLoadBalancerMachines(ctx, &set, childMachines.Items)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	if err := r.reconcileVRRPUnicastPeers(ctx, lbMachines); err != nil {
+		return ctrl.Result{}, err
+	}
+
 	readyMachines := make([]yawolv1beta1.LoadBalancerMachine, 0)
 	notReadyMachines := make([]yawolv1beta1.LoadBalancerMachine, 0)
 	deletedMachines := make([]yawolv1beta1.LoadBalancerMachine, 0)
@@ -100,6 +119,27 @@ func (r *LoadBalancerSetReconciler) Reconcile(ctx context.Context, req ctrl.Requ
 	return ctrl.Result{RequeueAfter: 10 * time.Second}, nil
 }
 
+// isLoadBalancerPaused returns true if the referenced LoadBalancer is paused.
+// Returns false if the LoadBalancer is not referenced or does not exist anymore.
+func isLoadBalancerPaused(
+	ctx context.Context,
+	c client.Client,
+	lbRef yawolv1beta1.LoadBalancerRef,
+) (bool, error) {
+	if lbRef.Name == "" {
+		return false, nil
+	}
+
+	var lb yawolv1beta1.LoadBalancer
+	if err := c.Get(ctx, client.ObjectKey{
+		Name:      lbRef.Name,
+		Namespace: lbRef.Namespace,
+	}, &lb); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+	return helper.IsLoadBalancerPaused(&lb), nil
+}
+
 func (r *LoadBalancerSetReconciler) deletionRoutine(
 	ctx context.Context,
 	set *yawolv1beta1.LoadBalancerSet,
@@ -163,9 +203,115 @@ func (r *LoadBalancerSetReconciler) reconcileStatus(
 		return ctrl.Result{RequeueAfter: time.Second * 2}, nil
 	}
 
+	// Write observed generation into status
+	if set.Status.ObservedGeneration != set.Generation {
+		if err := r.patchLoadBalancerSetStatus(ctx, set, yawolv1beta1.LoadBalancerSetStatus{
+			ObservedGeneration: set.Generation,
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
+	// Write available condition into status
+	condition := v1.Condition{
+		Type:               string(helper.Available),
+		Status:             v1.ConditionTrue,
+		Reason:             "AllReplicasReady",
+		Message:            fmt.Sprintf("%d of %d replicas are ready", len(readyMachines), set.Spec.Replicas),
+		ObservedGeneration: set.Generation,
+	}
+	if len(readyMachines) < set.Spec.Replicas {
+		condition.Status = v1.ConditionFalse
+		condition.Reason = "ReplicasNotReady"
+	}
+	if conditions, changed := kubernetes.SetConditionIfChanged(set.Status.Conditions, condition); changed {
+		if err := r.patchLoadBalancerSetStatus(ctx, set, yawolv1beta1.LoadBalancerSetStatus{
+			Conditions: conditions,
+		}); err != nil {
+			return ctrl.Result{}, err
+		}
+	}
+
 	return ctrl.Result{}, nil
 }
 
+// getLoadBalancerMachines returns the machines of all sets of the LoadBalancer of the set.
+// Returns the machines of the set if the LoadBalancer is not referenced or does not exist anymore.
+func (r *LoadBalancerSetReconciler) getLoadBalancerMachines(
+	ctx context.Context,
+	set *yawolv1beta1.LoadBalancerSet,
+	childMachines []yawolv1beta1.LoadBalancerMachine,
+) ([]yawolv1beta1.LoadBalancerMachine, error) {
+	if set.Spec.Template.Spec.LoadBalancerRef.Name == "" {
+		return childMachines, nil
+	}
+
+	var lb yawolv1beta1.LoadBalancer
+	if err := r.Client.Get(ctx, client.ObjectKey{
+		Name:      set.Spec.Template.Spec.LoadBalancerRef.Name,
+		Namespace: set.Spec.Template.Spec.LoadBalancerRef.Namespace,
+	}, &lb); err != nil {
+		if client.IgnoreNotFound(err) == nil {
+			return childMachines, nil
+		}
+		return nil, err
+	}
+
+	var lbMachines yawolv1beta1.LoadBalancerMachineList
+	if err := r.List(ctx, &lbMachines, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(helper.GetLoadBalancerSetLabelsFromLoadBalancer(&lb)),
+		Namespace:     set.Namespace,
+	}); err != nil {
+		r.Log.Error(err, helper.ErrListingChildLBMs.Error())
+		return nil, err
+	}
+	return lbMachines.Items, nil
+}
+
+// reconcileVRRPUnicastPeers sets the VRRP unicast source IPs of all other machines as unicast peers of a machine.
+// The machines of all sets of a LoadBalancer are peers, so old and new machines elect one master during a rollout.
+// The yawollet updates the keepalived config of the machine if the peers change.
+func (r *LoadBalancerSetReconciler) reconcileVRRPUnicastPeers(
+	ctx context.Context,
+	machines []yawolv1beta1.LoadBalancerMachine,
+) error {
+	srcIPs := make([]string, 0, len(machines))
+	for i := range machines {
+		if machines[i].DeletionTimestamp == nil && machines[i].Status.VRRPUnicastSrcIP != nil {
+			srcIPs = append(srcIPs, *machines[i].Status.VRRPUnicastSrcIP)
+		}
+	}
+	sort.Strings(srcIPs)
+
+	for i := range machines {
+		if machines[i].DeletionTimestamp != nil {
+			continue
+		}
+
+		peers := make([]string, 0, len(srcIPs))
+		for _, srcIP := range srcIPs {
+			if machines[i].Status.VRRPUnicastSrcIP == nil || srcIP != *machines[i].Status.VRRPUnicastSrcIP {
+				peers = append(peers, srcIP)
+			}
+		}
+
+		var currentPeers []string
+		if machines[i].Status.VRRPUnicastPeers != nil {
+			currentPeers = *machines[i].Status.VRRPUnicastPeers
+		}
+		if equalStrings(currentPeers, peers) {
+			continue
+		}
+
+		if err := helper.PatchLBMStatus(ctx, r.Client.Status(), &machines[i], yawolv1beta1.LoadBalancerMachineStatus{
+			VRRPUnicastPeers: &peers,
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
 func (r *LoadBalancerSetReconciler) reconcileReplicas(
 	ctx context.Context,
 	set *yawolv1beta1.LoadBalancerSet,
@@ -192,9 +338,16 @@ func (r *LoadBalancerSetReconciler) reconcileReplicas(
 			return ctrl.Result{RequeueAfter: time.Second * 2}, nil
 		}
 
-		// Delete ready machines
+		// Delete ready machines, the keepalived master is deleted last
 		if len(readyMachines) > 0 {
-			if err := r.deleteMachine(ctx, &readyMachines[0]); err != nil {
+			machine := &readyMachines[0]
+			for i := range readyMachines {
+				if !helper.IsKeepalivedMaster(&readyMachines[i]) {
+					machine = &readyMachines[i]
+					break
+				}
+			}
+			if err := r.deleteMachine(ctx, machine); err != nil {
 				return ctrl.Result{}, err
 			}
 			return ctrl.Result{RequeueAfter: time.Second * 2}, nil
@@ -344,6 +497,18 @@ func (r *LoadBalancerSetReconciler) getMachineLabelsFromSet(set *yawolv1beta1.Lo
 	return copyLabelMap(set.Spec.Selector.MatchLabels)
 }
 
+func equalStrings(a, b []string) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i] != b[i] {
+			return false
+		}
+	}
+	return true
+}
+
 func containsString(slice []string, s string) bool {
 	for _, item := range slice {
 		if item == s {
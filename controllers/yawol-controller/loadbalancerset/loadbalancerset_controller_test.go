@@ -2,12 +2,14 @@ package loadbalancerset
 
 import (
 	"context"
+	"fmt"
 	"reflect"
 	"strings"
 	"testing"
 	"time"
 
 	v1 "k8s.io/api/core/v1"
+	"k8s.io/apimachinery/pkg/api/meta"
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/utils/pointer"
@@ -118,6 +120,39 @@ var _ = Describe("LoadBalancerSet controller", func() {
 				return len(getChildMachines(ctx, &setStub))
 			}, timeout, interval).Should(Equal(4))
 		})
+
+		It("Should set the available condition and observed generation", func() {
+			Eventually(func(g Gomega) {
+				set := &yawolv1beta1.LoadBalancerSet{}
+				g.Expect(k8sClient.Get(ctx, key, set)).To(Succeed())
+				g.Expect(meta.IsStatusConditionTrue(set.Status.Conditions, string(helper.Available))).To(BeTrue())
+				g.Expect(set.Status.ObservedGeneration).To(Equal(set.Generation))
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+
+	// vrrp unicast peers
+	Context("Set vrrp unicast source IPs", func() {
+		ctx := context.Background()
+		It("Should be successfully", func() {
+			for i, machine := range getChildMachines(ctx, &setStub) {
+				patch := []byte(fmt.Sprintf(`{"status":{"vrrpUnicastSrcIP":"10.0.0.%d"}}`, i+1))
+				Expect(k8sClient.Status().Patch(ctx, &machine, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+			}
+		})
+
+		It("Should set the source IPs of the other machines as unicast peers", func() {
+			Eventually(func(g Gomega) {
+				machines := getChildMachines(ctx, &setStub)
+				g.Expect(machines).To(HaveLen(4))
+				for _, machine := range machines {
+					g.Expect(machine.Status.VRRPUnicastSrcIP).ToNot(BeNil())
+					g.Expect(machine.Status.VRRPUnicastPeers).ToNot(BeNil())
+					g.Expect(*machine.Status.VRRPUnicastPeers).To(HaveLen(3))
+					g.Expect(*machine.Status.VRRPUnicastPeers).ToNot(ContainElement(*machine.Status.VRRPUnicastSrcIP))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
 	})
 
 	// status & conditions
@@ -172,6 +207,14 @@ var _ = Describe("LoadBalancerSet controller", func() {
 				return *set.Status.ReadyReplicas
 			}, timeout, interval).Should(Equal(0))
 		})
+
+		It("Should set the available condition to false", func() {
+			Eventually(func(g Gomega) {
+				set := &yawolv1beta1.LoadBalancerSet{}
+				g.Expect(k8sClient.Get(ctx, key, set)).To(Succeed())
+				g.Expect(meta.IsStatusConditionFalse(set.Status.Conditions, string(helper.Available))).To(BeTrue())
+			}, timeout, interval).Should(Succeed())
+		})
 	})
 
 	// delete
@@ -210,6 +253,210 @@ var _ = Describe("LoadBalancerSet controller", func() {
 	})
 })
 
+var _ = Describe("LoadBalancerSets of one LoadBalancer", func() {
+	const (
+		LoadBalancerName      = "test-lb"
+		LoadBalancerNamespace = "test-lbset-rollout-namespace"
+
+		LoadBalancerLabelKey   = "app"
+		LoadBalancerLabelValue = "test_rollout"
+
+		timeout  = time.Second * 30
+		interval = time.Millisecond * 250
+	)
+
+	lbLabels := map[string]string{LoadBalancerLabelKey: LoadBalancerLabelValue}
+
+	lb := yawolv1beta1.LoadBalancer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LoadBalancerName,
+			Namespace: LoadBalancerNamespace,
+		},
+		Spec: yawolv1beta1.LoadBalancerSpec{
+			Selector: metav1.LabelSelector{
+				MatchLabels: lbLabels,
+			},
+			Replicas: 2,
+			Infrastructure: yawolv1beta1.LoadBalancerInfrastructure{
+				FloatingNetID: pointer.String("floatingnetid"),
+				NetworkID:     "networkid",
+				AuthSecretRef: v1.SecretReference{
+					Name:      "cloud-provider-config",
+					Namespace: LoadBalancerNamespace,
+				},
+			},
+		},
+	}
+
+	newSetStub := func(name, hash string) yawolv1beta1.LoadBalancerSet {
+		setLabels := map[string]string{
+			LoadBalancerLabelKey: LoadBalancerLabelValue,
+			helper.HashLabel:     hash,
+		}
+		return yawolv1beta1.LoadBalancerSet{
+			ObjectMeta: metav1.ObjectMeta{
+				Name:      name,
+				Namespace: LoadBalancerNamespace,
+				Labels:    setLabels,
+			},
+			Spec: yawolv1beta1.LoadBalancerSetSpec{
+				Selector: metav1.LabelSelector{
+					MatchLabels: setLabels,
+				},
+				Replicas: 2,
+				Template: yawolv1beta1.LoadBalancerMachineTemplateSpec{
+					Labels: setLabels,
+					Spec: yawolv1beta1.LoadBalancerMachineSpec{
+						Infrastructure: lb.Spec.Infrastructure,
+						PortID:         "port-id",
+						LoadBalancerRef: yawolv1beta1.LoadBalancerRef{
+							Name:      LoadBalancerName,
+							Namespace: LoadBalancerNamespace,
+						},
+					},
+				},
+			},
+		}
+	}
+
+	oldSet := newSetStub("test-lbset-old", "old")
+	newSet := newSetStub("test-lbset-new", "new")
+
+	Context("Two LoadBalancerSets during a rollout", func() {
+		ctx := context.Background()
+		It("Should create successfully", func() {
+			ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: LoadBalancerNamespace}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &oldSet)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &newSet)).Should(Succeed())
+		})
+
+		It("Should create two LoadBalancerMachines per set", func() {
+			Eventually(func() int {
+				return len(getChildMachines(ctx, &oldSet)) + len(getChildMachines(ctx, &newSet))
+			}, timeout, interval).Should(Equal(4))
+		})
+
+		It("Should set vrrp unicast source IPs successfully", func() {
+			for i, machine := range append(getChildMachines(ctx, &oldSet), getChildMachines(ctx, &newSet)...) {
+				patch := []byte(fmt.Sprintf(`{"status":{"vrrpUnicastSrcIP":"10.0.1.%d"}}`, i+1))
+				Expect(k8sClient.Status().Patch(ctx, &machine, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+			}
+		})
+
+		It("Should set the source IPs of the machines of both sets as unicast peers", func() {
+			Eventually(func(g Gomega) {
+				machines := append(getChildMachines(ctx, &oldSet), getChildMachines(ctx, &newSet)...)
+				g.Expect(machines).To(HaveLen(4))
+				for _, machine := range machines {
+					g.Expect(machine.Status.VRRPUnicastSrcIP).ToNot(BeNil())
+					g.Expect(machine.Status.VRRPUnicastPeers).ToNot(BeNil())
+					g.Expect(*machine.Status.VRRPUnicastPeers).To(HaveLen(3))
+					g.Expect(*machine.Status.VRRPUnicastPeers).ToNot(ContainElement(*machine.Status.VRRPUnicastSrcIP))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+
+		It("Should remove the machines of a deleted set from the unicast peers", func() {
+			Expect(k8sClient.Delete(ctx, &oldSet)).Should(Succeed())
+			Eventually(func(g Gomega) {
+				g.Expect(getChildMachines(ctx, &oldSet)).To(BeEmpty())
+				machines := getChildMachines(ctx, &newSet)
+				g.Expect(machines).To(HaveLen(2))
+				for _, machine := range machines {
+					g.Expect(machine.Status.VRRPUnicastPeers).ToNot(BeNil())
+					g.Expect(*machine.Status.VRRPUnicastPeers).To(HaveLen(1))
+				}
+			}, timeout, interval).Should(Succeed())
+		})
+	})
+})
+
+var _ = Describe("LoadBalancerMachine of a paused LoadBalancer", func() {
+	const (
+		LoadBalancerName      = "test-lb-paused"
+		LoadBalancerNamespace = "test-lbm-paused-namespace"
+
+		timeout  = time.Second * 30
+		interval = time.Millisecond * 250
+	)
+
+	lb := yawolv1beta1.LoadBalancer{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      LoadBalancerName,
+			Namespace: LoadBalancerNamespace,
+		},
+		Spec: yawolv1beta1.LoadBalancerSpec{
+			Selector: metav1.LabelSelector{
+				MatchLabels: map[string]string{"app": "test_paused"},
+			},
+			Replicas: 1,
+			Paused:   true,
+			Infrastructure: yawolv1beta1.LoadBalancerInfrastructure{
+				FloatingNetID: pointer.String("floatingnetid"),
+				NetworkID:     "networkid",
+				AuthSecretRef: v1.SecretReference{
+					Name:      "cloud-provider-config",
+					Namespace: LoadBalancerNamespace,
+				},
+			},
+		},
+	}
+
+	machine := yawolv1beta1.LoadBalancerMachine{
+		ObjectMeta: metav1.ObjectMeta{
+			Name:      "test-lbm-paused",
+			Namespace: LoadBalancerNamespace,
+			Labels:    map[string]string{"app": "test_paused"},
+		},
+		Spec: yawolv1beta1.LoadBalancerMachineSpec{
+			Infrastructure: lb.Spec.Infrastructure,
+			PortID:         "port-id",
+			LoadBalancerRef: yawolv1beta1.LoadBalancerRef{
+				Name:      LoadBalancerName,
+				Namespace: LoadBalancerNamespace,
+			},
+		},
+	}
+
+	machineKey := client.ObjectKey{Name: machine.Name, Namespace: LoadBalancerNamespace}
+
+	Context("Machine with stale conditions", func() {
+		ctx := context.Background()
+		It("Should create successfully", func() {
+			ns := &v1.Namespace{ObjectMeta: metav1.ObjectMeta{Name: LoadBalancerNamespace}}
+			Expect(k8sClient.Create(ctx, ns)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &lb)).Should(Succeed())
+			Expect(k8sClient.Create(ctx, &machine)).Should(Succeed())
+
+			machine.Status.Conditions = &[]v1.NodeCondition{{
+				Message:            "reconcile is running",
+				Reason:             "ConfigReady",
+				Status:             "True",
+				Type:               v1.NodeConditionType(helper.ConfigReady),
+				LastHeartbeatTime:  metav1.Time{Time: time.Now().Add(-6 * time.Minute)},
+				LastTransitionTime: metav1.Time{Time: time.Now().Add(-6 * time.Minute)},
+			}}
+			Expect(k8sClient.Status().Update(ctx, &machine)).Should(Succeed())
+		})
+
+		It("Should keep the machine while the LoadBalancer is paused", func() {
+			Consistently(func() error {
+				return k8sClient.Get(ctx, machineKey, &yawolv1beta1.LoadBalancerMachine{})
+			}, time.Second*5, interval).Should(Succeed())
+		})
+
+		It("Should delete the machine after the LoadBalancer is resumed", func() {
+			patch := []byte(`{"spec": {"paused": false}}`)
+			Expect(k8sClient.Patch(ctx, &lb, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+			Eventually(func() error {
+				return k8sClient.Get(ctx, machineKey, &yawolv1beta1.LoadBalancerMachine{})
+			}, timeout, interval).ShouldNot(Succeed())
+		})
+	})
+})
+
 func getChildMachines(ctx context.Context, set *yawolv1beta1.LoadBalancerSet) []yawolv1beta1.LoadBalancerMachine {
 	var childMachines yawolv1beta1.LoadBalancerMachineList
 	err := k8sClient.List(ctx, &childMachines, &client.ListOptions{
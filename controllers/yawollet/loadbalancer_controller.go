@@ -4,18 +4,32 @@ package controllers
 import (
 	"context"
 	"fmt"
+	"reflect"
 	"time"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 	"github.com/stackitcloud/yawol/internal/helper"
 	"github.com/stackitcloud/yawol/internal/helper/kubernetes"
+	"github.com/stackitcloud/yawol/internal/keepalived"
 
 	"github.com/envoyproxy/go-control-plane/pkg/resource/v3"
 	"github.com/go-logr/logr"
+	coreV1 "k8s.io/api/core/v1"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+	"k8s.io/apimachinery/pkg/fields"
 	"k8s.io/apimachinery/pkg/runtime"
+	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	ctrl "sigs.k8s.io/controller-runtime"
+	"sigs.k8s.io/controller-runtime/pkg/builder"
+	"sigs.k8s.io/controller-runtime/pkg/cache"
 	"sigs.k8s.io/controller-runtime/pkg/client"
+	"sigs.k8s.io/controller-runtime/pkg/event"
+	"sigs.k8s.io/controller-runtime/pkg/handler"
+	"sigs.k8s.io/controller-runtime/pkg/manager"
+	"sigs.k8s.io/controller-runtime/pkg/predicate"
+	"sigs.k8s.io/controller-runtime/pkg/reconcile"
+	"sigs.k8s.io/controller-runtime/pkg/source"
 
 	envoycache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
 )
@@ -23,6 +37,8 @@ import (
 // LoadBalancerReconciler reconciles service Objects with type LoadBalancer
 type LoadBalancerReconciler struct {
 	client.Client
+	// APIReader reads the TLS secret without caching all secrets of the namespace
+	APIReader               client.Reader
 	Log                     logr.Logger
 	Scheme                  *runtime.Scheme
 	Recorder                record.EventRecorder
@@ -30,9 +46,25 @@ type LoadBalancerReconciler struct {
 	LoadbalancerName        string
 	LoadbalancerMachineName string
 	EnvoyCache              envoycache.SnapshotCache
-	ListenAddress           string
+	ListenAddresses         []string
 	RequeueTime             int
 	KeepalivedStatsFile     string
+	// NftablesCommand is the nft command to enforce the source ranges of UDP ports, disabled if empty
+	NftablesCommand string
+	// KeepalivedConfigFile is the keepalived config that is kept up to date, disabled if empty
+	KeepalivedConfigFile string
+	// KeepalivedInterface is the interface of the vrrp instance, detected by the unicast source IP if empty
+	KeepalivedInterface string
+	// KeepalivedReloadCommand makes keepalived reload its config
+	KeepalivedReloadCommand string
+	// KeepalivedTrackFile lowers the VRRP priority of an unhealthy machine, disabled if empty
+	KeepalivedTrackFile string
+	// KeepalivedNotifyFIFO streams the VRRP state transitions from keepalived, disabled if empty
+	KeepalivedNotifyFIFO string
+	// Namespace of the lb and lbm, used to reconcile the lb on a VRRP state transition
+	Namespace string
+
+	keepalivedNotifications *keepalived.Notifications
 }
 
 // Reconcile handles reconciliation of loadbalancer object
@@ -61,8 +93,20 @@ func (r *LoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: unable to get current snapshot", err), lbm)
 	}
 
+	// get certificate for TLS ports
+	var tlsSecret *coreV1.Secret
+	if len(lb.Spec.Options.TLSPorts) > 0 {
+		tlsSecret = &coreV1.Secret{}
+		err = r.APIReader.Get(ctx, client.ObjectKey{Name: helper.GetTLSSecretName(lb), Namespace: req.Namespace}, tlsSecret)
+		if err != nil {
+			_ = helper.UpdateLBMConditions(ctx, r.Status(), lbm,
+				helper.ConfigReady, helper.ConditionFalse, "EnvoyConfigurationFailed", "tls secret cant be read")
+			return ctrl.Result{}, kubernetes.SendErrorAsEvent(r.Recorder, fmt.Errorf("%w: unable to get tls secret", err), lbm)
+		}
+	}
+
 	// create new snapshot
-	changed, snapshot, err := helper.CreateEnvoyConfig(r.RecorderLB, &oldSnapshot, lb, r.ListenAddress)
+	changed, snapshot, err := helper.CreateEnvoyConfig(r.RecorderLB, &oldSnapshot, lb, tlsSecret, r.ListenAddresses)
 	if err != nil {
 		_ = helper.UpdateLBMConditions(ctx, r.Status(), lbm,
 			helper.ConfigReady, helper.ConditionFalse, "EnvoyConfigurationFailed", "new snapshot cant create successful")
@@ -120,14 +164,33 @@ func (r *LoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 		}
 	}
 
+	// enforce source ranges of UDP ports and update condition
+	err = helper.UpdateSourceRangesStatus(ctx, r.Status(), r.Recorder, r.NftablesCommand, lb, lbm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// update keepalived config and condition
+	err = helper.UpdateKeepalivedConfigStatus(ctx, r.Status(), r.Recorder, r.APIReader,
+		r.KeepalivedConfigFile, r.KeepalivedInterface, r.KeepalivedReloadCommand, lbm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
+	// lower VRRP priority if envoy or the backends are not healthy
+	err = helper.UpdateKeepalivedTrackFile(ctx, r.Status(), r.Recorder, r.KeepalivedTrackFile, lbm)
+	if err != nil {
+		return ctrl.Result{}, err
+	}
+
 	// update keepalived status condition
-	err = helper.UpdateKeepalivedStatus(ctx, r.Status(), r.KeepalivedStatsFile, lbm)
+	err = helper.UpdateKeepalivedStatus(ctx, r.Status(), r.Recorder, r.KeepalivedStatsFile, r.keepalivedNotifications, lbm)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
 
 	// update Metrics
-	err = helper.WriteLBMMetrics(ctx, r.Status(), r.KeepalivedStatsFile, lbm)
+	err = helper.WriteLBMMetrics(ctx, r.Status(), r.KeepalivedStatsFile, r.keepalivedNotifications, lbm)
 	if err != nil {
 		return ctrl.Result{}, err
 	}
@@ -137,7 +200,92 @@ func (r *LoadBalancerReconciler) Reconcile(ctx context.Context, req ctrl.Request
 
 // SetupWithManager is used by kubebuilder to init the controller loop
 func (r *LoadBalancerReconciler) SetupWithManager(mgr ctrl.Manager) error {
-	return ctrl.NewControllerManagedBy(mgr).
+	// the keepalived config depends on the lbm and the keepalived secret, changes are applied immediately
+	enqueueLB := handler.EnqueueRequestsFromMapFunc(func(client.Object) []reconcile.Request {
+		return []reconcile.Request{{NamespacedName: types.NamespacedName{Name: r.LoadbalancerName, Namespace: r.Namespace}}}
+	})
+	keepalivedSecretName := helper.GetKeepalivedSecretName(&yawolv1beta1.LoadBalancer{
+		ObjectMeta: metav1.ObjectMeta{Name: r.LoadbalancerName},
+	})
+
+	// only the keepalived secret is cached, the yawollet is not allowed to list other secrets
+	secretCache, err := cache.New(mgr.GetConfig(), cache.Options{
+		Scheme:    mgr.GetScheme(),
+		Mapper:    mgr.GetRESTMapper(),
+		Namespace: r.Namespace,
+		SelectorsByObject: cache.SelectorsByObject{
+			&coreV1.Secret{}: {Field: fields.OneTermEqualSelector("metadata.name", keepalivedSecretName)},
+		},
+	})
+	if err != nil {
+		return err
+	}
+	if err := mgr.Add(secretCache); err != nil {
+		return err
+	}
+
+	controllerBuilder := ctrl.NewControllerManagedBy(mgr).
 		For(&yawolv1beta1.LoadBalancer{}).
-		Complete(r)
+		Watches(&source.Kind{Type: &yawolv1beta1.LoadBalancerMachine{}}, enqueueLB, builder.WithPredicates(
+			hasName(r.LoadbalancerMachineName), keepalivedConfigChanged(),
+		)).
+		Watches(source.NewKindWithCache(&coreV1.Secret{}, secretCache), enqueueLB, builder.WithPredicates(
+			hasName(keepalivedSecretName),
+		))
+
+	if r.KeepalivedNotifyFIFO != "" {
+		// reconcile the lb on every VRRP state transition to update the lbm immediately
+		transitions := make(chan event.GenericEvent, 10)
+		r.keepalivedNotifications = &keepalived.Notifications{}
+		err := mgr.Add(manager.RunnableFunc(func(ctx context.Context) error {
+			err := keepalived.ReadNotifyFIFO(ctx, r.KeepalivedNotifyFIFO, r.keepalivedNotifications,
+				func(keepalived.Notification) {
+					select {
+					case transitions <- event.GenericEvent{Object: &yawolv1beta1.LoadBalancer{
+						ObjectMeta: metav1.ObjectMeta{Name: r.LoadbalancerName, Namespace: r.Namespace},
+					}}:
+					case <-ctx.Done():
+					}
+				})
+			if err != nil {
+				// the stats file is still used for the keepalived status
+				r.Log.Error(err, "unable to read keepalived notify fifo", "fifo", r.KeepalivedNotifyFIFO)
+			}
+			return nil
+		}))
+		if err != nil {
+			return err
+		}
+		controllerBuilder = controllerBuilder.Watches(&source.Channel{Source: transitions}, &handler.EnqueueRequestForObject{})
+	}
+
+	return controllerBuilder.Complete(r)
+}
+
+// hasName filters the events of the object with the name.
+func hasName(name string) predicate.Predicate {
+	return predicate.NewPredicateFuncs(func(object client.Object) bool {
+		return object.GetName() == name
+	})
+}
+
+// keepalivedConfigChanged filters the updates of the lbm that change the keepalived config.
+// The yawollet updates the conditions and metrics of the lbm on every reconcile, these updates are ignored.
+func keepalivedConfigChanged() predicate.Predicate {
+	return predicate.Funcs{
+		UpdateFunc: func(e event.UpdateEvent) bool {
+			oldLBM, ok := e.ObjectOld.(*yawolv1beta1.LoadBalancerMachine)
+			if !ok {
+				return false
+			}
+			newLBM, ok := e.ObjectNew.(*yawolv1beta1.LoadBalancerMachine)
+			if !ok {
+				return false
+			}
+			return oldLBM.Generation != newLBM.Generation ||
+				!reflect.DeepEqual(oldLBM.Status.VIPs, newLBM.Status.VIPs) ||
+				!reflect.DeepEqual(oldLBM.Status.VRRPUnicastSrcIP, newLBM.Status.VRRPUnicastSrcIP) ||
+				!reflect.DeepEqual(oldLBM.Status.VRRPUnicastPeers, newLBM.Status.VRRPUnicastPeers)
+		},
+	}
 }
@@ -2,8 +2,19 @@ package controllers
 
 import (
 	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/json"
+	"encoding/pem"
+	"fmt"
 	"io"
+	"math/big"
+	"net"
 	"net/http"
+	"os"
 	"os/exec"
 	"strings"
 	"time"
@@ -14,6 +25,7 @@ import (
 	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/apimachinery/pkg/util/intstr"
+	"sigs.k8s.io/controller-runtime/pkg/client"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 	"github.com/stackitcloud/yawol/internal/helper"
@@ -149,6 +161,63 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 			lb.Spec.Options.LoadBalancerSourceRanges = nil
 			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
 		})
+		It("enforce source ranges of udp ports with nftables", func() {
+			By("add source ranges and an udp port")
+			oldPorts := lb.Spec.Ports
+			lb.Spec.Ports = append(lb.Spec.Ports, v1.ServicePort{
+				Name:       "port3",
+				Protocol:   "UDP",
+				Port:       8083,
+				TargetPort: intstr.IntOrString{IntVal: 8083},
+				NodePort:   12458,
+			})
+			lb.Spec.Options.LoadBalancerSourceRanges = []string{
+				"127.0.0.1/24",
+				"2002::1234:abcd:ffff:c0a8:101/64",
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the source ranges are enforced for the udp port")
+			Eventually(func() error {
+				if err := checkSourceRangesCondition(ctx, "SourceRangesEnforced",
+					"openstack security group, envoy rbac for tcp ports, nftables for udp ports"); err != nil {
+					return err
+				}
+				rules, err := os.ReadFile(nftablesRules)
+				if err != nil {
+					return err
+				}
+				for _, rule := range []string{
+					"meta nfproto ipv4 udp dport { 8083 } ip saddr != { 127.0.0.0/24 } drop",
+					"meta nfproto ipv6 udp dport { 8083 } ip6 saddr != { 2002:0:0:1234::/64 } drop",
+				} {
+					if !strings.Contains(string(rules), rule) {
+						return fmt.Errorf("rule %s not found in %s", rule, rules)
+					}
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove source ranges and udp port")
+			lb.Spec.Ports = oldPorts
+			lb.Spec.Options.LoadBalancerSourceRanges = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the nftables table is removed")
+			Eventually(func() error {
+				if err := checkSourceRangesCondition(ctx, "NoSourceRanges", "No source ranges are set"); err != nil {
+					return err
+				}
+				rules, err := os.ReadFile(nftablesRules)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(string(rules), "drop") {
+					return fmt.Errorf("rules not removed: %s", rules)
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
 		It("set envoy to fail and check envoyReady conditions", func() {
 			By("set envoy ready status to fail")
 			_, err := http.Post("http://127.0.0.1:9000/healthcheck/fail", "", nil)
@@ -534,6 +603,677 @@ var _ = Describe("Check loadbalancer reconcile", func() {
 				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", "", "")
 			}, time.Second*15, time.Second*1).Should(Succeed())
 		})
+		It("set health check node port with proxy protocol", func() {
+			By("set health check node port")
+			lb.Spec.Options.HealthCheckNodePort = 32000
+			lb.Spec.Options.TCPProxyProtocol = true
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(
+					ctx,
+					"test-lbm",
+					"testns",
+					helper.ConditionTrue,
+					"",
+					helper.ConditionTrue,
+					"TCP-8081::127.0.0.1:8081",
+				)
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove health check node port")
+			lb.Spec.Options.HealthCheckNodePort = 0
+			lb.Spec.Options.TCPProxyProtocol = false
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("use pod endpoints without NodePorts", func() {
+			By("set pod endpoints and remove NodePorts")
+			oldEndpoints := lb.Spec.Endpoints
+			oldPorts := make([]v1.ServicePort, len(lb.Spec.Ports))
+			copy(oldPorts, lb.Spec.Ports)
+			lb.Spec.Options.PodEndpoints = true
+			lb.Spec.Endpoints = []yawolv1beta1.LoadBalancerEndpoint{{
+				Name:      "pod",
+				Addresses: []string{"127.0.0.1"},
+				Ports: []yawolv1beta1.LoadBalancerEndpointPort{{
+					Name:     "port",
+					Protocol: v1.ProtocolTCP,
+					Port:     12456,
+				}},
+			}}
+			for i := range lb.Spec.Ports {
+				lb.Spec.Ports[i].NodePort = 0
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(
+					ctx,
+					"test-lbm",
+					"testns",
+					helper.ConditionTrue,
+					"",
+					helper.ConditionTrue,
+					"TCP-8081::127.0.0.1:8081",
+				)
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("reset to node endpoints")
+			lb.Spec.Options.PodEndpoints = false
+			lb.Spec.Endpoints = oldEndpoints
+			lb.Spec.Ports = oldPorts
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("terminate tls on a port", func() {
+			By("set tls port without tls secret")
+			lb.Spec.Options.TLSPorts = []int32{8081}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check that the config is not ready")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("create tls secret")
+			certPEM, keyPEM := generateTLSCertificate(1)
+			secret := v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{
+					Name:      helper.GetTLSSecretName(&lb),
+					Namespace: "testns",
+				},
+				Type: v1.SecretTypeTLS,
+				Data: map[string][]byte{
+					v1.TLSCertKey:       certPEM,
+					v1.TLSPrivateKeyKey: keyPEM,
+				},
+			}
+			Expect(k8sClient.Create(ctx, &secret)).Should(Succeed())
+
+			By("check if config is successful and the certificate is used")
+			Eventually(func() error {
+				if err := checkConditions(
+					ctx,
+					"test-lbm",
+					"testns",
+					helper.ConditionTrue,
+					"",
+					helper.ConditionTrue,
+					"TCP-8081::127.0.0.1:8081",
+				); err != nil {
+					return err
+				}
+				return checkCertificate("1")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("rotate the certificate")
+			certPEM, keyPEM = generateTLSCertificate(2)
+			secret.Data[v1.TLSCertKey] = certPEM
+			secret.Data[v1.TLSPrivateKeyKey] = keyPEM
+			Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+
+			By("check that the new certificate is used")
+			Eventually(func() error {
+				return checkCertificate("2")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove tls port")
+			lb.Spec.Options.TLSPorts = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+			Expect(k8sClient.Delete(ctx, &secret)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("route a port by sni", func() {
+			By("add the same hostname twice")
+			lb.Spec.SNIRoutes = []yawolv1beta1.LoadBalancerSNIRoute{
+				{Port: 8081, Hostnames: []string{"a.example.com"}, NodePort: 32081},
+				{Port: 8081, Hostnames: []string{"a.example.com"}, NodePort: 32082},
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config fails")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("add routes with a default route")
+			lb.Spec.SNIRoutes = []yawolv1beta1.LoadBalancerSNIRoute{
+				{Port: 8081, Hostnames: []string{"a.example.com", "*.b.example.com"}, NodePort: 32081},
+				{Port: 8081, Hostnames: []string{"c.example.com"}, NodePort: 32082},
+				{Port: 8081, NodePort: 32083},
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(
+					ctx,
+					"test-lbm",
+					"testns",
+					helper.ConditionTrue,
+					"",
+					helper.ConditionTrue,
+					"TCP-8081::127.0.0.1:8081",
+				)
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("add route to an unknown port")
+			lb.Spec.SNIRoutes = append(lb.Spec.SNIRoutes, yawolv1beta1.LoadBalancerSNIRoute{
+				Port: 9999, Hostnames: []string{"a.example.com"}, NodePort: 32081,
+			})
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config fails")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove routes")
+			lb.Spec.SNIRoutes = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("set load balancing policies", func() {
+			By("set a default and a port policy")
+			lb.Spec.Options.LoadBalancingPolicy = yawolv1beta1.LoadBalancingPolicyLeastRequest
+			lb.Spec.Options.PortLoadBalancingPolicies = []yawolv1beta1.LoadBalancerPortPolicy{
+				{Port: 8081, Policy: yawolv1beta1.LoadBalancingPolicyMaglev},
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the clusters use the policies")
+			Eventually(func() error {
+				if err := checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, ""); err != nil {
+					return err
+				}
+				for _, port := range lb.Spec.Ports {
+					policy := string(yawolv1beta1.LoadBalancingPolicyLeastRequest)
+					if port.Port == 8081 {
+						policy = string(yawolv1beta1.LoadBalancingPolicyMaglev)
+					}
+					if err := checkClusterLbPolicy(fmt.Sprintf("%v-%v", port.Protocol, port.Port), policy); err != nil {
+						return err
+					}
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("reset policies")
+			lb.Spec.Options.LoadBalancingPolicy = ""
+			lb.Spec.Options.PortLoadBalancingPolicies = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the default policy is used")
+			Eventually(func() error {
+				return checkClusterLbPolicy("TCP-8081", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("set health check settings", func() {
+			By("set an invalid health check")
+			lb.Spec.Options.HealthCheck = &yawolv1beta1.LoadBalancerHealthCheck{
+				Type:                 yawolv1beta1.HealthCheckTypeHTTP,
+				HTTPExpectedStatuses: []int32{42},
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config fails")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("set an HTTP health check with a health check port for UDP")
+			oldPorts := lb.Spec.Ports
+			lb.Spec.Ports = append(lb.Spec.Ports, v1.ServicePort{
+				Name:       "port3",
+				Protocol:   "UDP",
+				Port:       8083,
+				TargetPort: intstr.IntOrString{IntVal: 8083},
+				NodePort:   12458,
+			})
+			lb.Spec.Options.HealthCheck = &yawolv1beta1.LoadBalancerHealthCheck{
+				Type:                 yawolv1beta1.HealthCheckTypeHTTP,
+				IntervalSeconds:      10,
+				HTTPPath:             "/ready",
+				HTTPExpectedStatuses: []int32{200, 204},
+				UDPPort:              12459,
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the TCP and UDP clusters are checked")
+			Eventually(func() error {
+				if err := checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, ""); err != nil {
+					return err
+				}
+				if err := checkClusterHealthCheck("TCP-8081", "/ready"); err != nil {
+					return err
+				}
+				return checkClusterHealthCheck("UDP-8083", "/ready")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("set a TCP health check with payloads")
+			lb.Spec.Options.HealthCheck = &yawolv1beta1.LoadBalancerHealthCheck{
+				TCPSend:    "PING",
+				TCPReceive: "PONG",
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the payloads are hex encoded")
+			Eventually(func() error {
+				return checkClusterHealthCheck("TCP-8081", "50494e47")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("reset health check")
+			lb.Spec.Ports = oldPorts
+			lb.Spec.Options.HealthCheck = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is successful")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("set timeouts", func() {
+			By("set an invalid connect timeout")
+			lb.Spec.Options.Timeouts = &yawolv1beta1.LoadBalancerTimeouts{
+				ConnectTimeout: &metav1.Duration{Duration: -time.Second},
+			}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config fails")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("set timeouts for all ports and a single port")
+			lb.Spec.Options.Timeouts = &yawolv1beta1.LoadBalancerTimeouts{
+				ConnectTimeout: &metav1.Duration{Duration: 10 * time.Second},
+				TCPIdleTimeout: &metav1.Duration{Duration: 0},
+			}
+			lb.Spec.Options.PortTimeouts = []yawolv1beta1.LoadBalancerPortTimeouts{{
+				Port: 8081,
+				LoadBalancerTimeouts: yawolv1beta1.LoadBalancerTimeouts{
+					ConnectTimeout: &metav1.Duration{Duration: 30 * time.Second},
+				},
+			}}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the clusters use the timeouts")
+			Eventually(func() error {
+				if err := checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, ""); err != nil {
+					return err
+				}
+				for _, port := range lb.Spec.Ports {
+					expected := "10s"
+					if port.Port == 8081 {
+						expected = "30s"
+					}
+					cluster, err := getEnvoyClusterConfig(fmt.Sprintf("%v-%v", port.Protocol, port.Port))
+					if err != nil {
+						return err
+					}
+					if cluster["connect_timeout"] != expected {
+						return fmt.Errorf("cluster %v-%v has connect timeout %v", port.Protocol, port.Port, cluster["connect_timeout"])
+					}
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("reset timeouts")
+			lb.Spec.Options.Timeouts = nil
+			lb.Spec.Options.PortTimeouts = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the default timeout is used")
+			Eventually(func() error {
+				cluster, err := getEnvoyClusterConfig("TCP-8081")
+				if err != nil {
+					return err
+				}
+				if cluster["connect_timeout"] != "5s" {
+					return fmt.Errorf("cluster TCP-8081 has connect timeout %v", cluster["connect_timeout"])
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("set proxy protocol versions and accept downstream proxy protocol", func() {
+			By("set an invalid proxy protocol version")
+			lb.Spec.Options.TCPProxyProtocol = true
+			lb.Spec.Options.TCPProxyProtocolVersion = 3
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config fails")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionFalse, "", "", "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("set version 1 for all ports, version 2 for a single port and accept downstream proxy protocol")
+			lb.Spec.Options.TCPProxyProtocolVersion = 1
+			lb.Spec.Options.TCPProxyProtocolPortVersions = []yawolv1beta1.LoadBalancerPortProxyProtocolVersion{{
+				Port:    8081,
+				Version: 2,
+			}}
+			lb.Spec.Options.DownstreamProxyProtocol = true
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the cluster uses version 2")
+			Eventually(func() error {
+				if err := checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, ""); err != nil {
+					return err
+				}
+				version, err := getEnvoyClusterProxyProtocolVersion("TCP-8081")
+				if err != nil {
+					return err
+				}
+				if version != "V2" {
+					return fmt.Errorf("cluster TCP-8081 has proxy protocol version %v", version)
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove the port version")
+			lb.Spec.Options.TCPProxyProtocolPortVersions = nil
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the cluster uses version 1")
+			Eventually(func() error {
+				version, err := getEnvoyClusterProxyProtocolVersion("TCP-8081")
+				if err != nil {
+					return err
+				}
+				// V1 is the default value and is omitted in the config dump
+				if version != "" && version != "V1" {
+					return fmt.Errorf("cluster TCP-8081 has proxy protocol version %v", version)
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("reset proxy protocol")
+			lb.Spec.Options.TCPProxyProtocol = false
+			lb.Spec.Options.TCPProxyProtocolVersion = 0
+			lb.Spec.Options.DownstreamProxyProtocol = false
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if config is ready")
+			Eventually(func() error {
+				return checkConditions(ctx, "test-lbm", "testns", helper.ConditionTrue, "", helper.ConditionTrue, "")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("update keepalived config from the loadbalancermachine", func() {
+			By("check if the config fails without vips")
+			Eventually(func() error {
+				return checkLBMCondition(ctx, helper.KeepalivedConfigReady, helper.ConditionFalse, "KeepalivedConfigFailed")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("set vips, unicast source ip and peers")
+			patch := []byte(`{"status":{"vips":["127.0.0.1"],"vrrpUnicastSrcIP":"10.0.0.1","vrrpUnicastPeers":["10.0.0.2","10.0.0.3"]}}`)
+			Expect(k8sClient.Status().Patch(ctx, &lbm, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+
+			By("check if the config is written and keepalived is reloaded")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedConfigReady, helper.ConditionTrue, ""); err != nil {
+					return err
+				}
+				if err := checkKeepalivedConfig(
+					"\tinterface eth0\n",
+					"\tvirtual_router_id 100\n",
+					"\t\tauth_pass yawol\n",
+					"\tvirtual_ipaddress {\n\t\t127.0.0.1\n\t}",
+					"\tunicast_src_ip 10.0.0.1\n",
+					"\tunicast_peer {\n\t\t10.0.0.2\n\t\t10.0.0.3\n\t}",
+				); err != nil {
+					return err
+				}
+				if getKeepalivedReloads() < 1 {
+					return fmt.Errorf("keepalived not reloaded")
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("change the config on the machine")
+			reloads := getKeepalivedReloads()
+			Expect(os.WriteFile(keepalivedConfig, []byte("drift"), 0600)).Should(Succeed())
+
+			By("check if the config is restored and keepalived is reloaded")
+			Eventually(func() error {
+				if err := checkKeepalivedConfig("\tunicast_src_ip 10.0.0.1\n"); err != nil {
+					return err
+				}
+				if getKeepalivedReloads() <= reloads {
+					return fmt.Errorf("keepalived not reloaded")
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove unicast peers")
+			patch = []byte(`{"status":{"vrrpUnicastPeers":null}}`)
+			Expect(k8sClient.Status().Patch(ctx, &lbm, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+
+			By("check if multicast is used again")
+			Eventually(func() error {
+				config, err := os.ReadFile(keepalivedConfig)
+				if err != nil {
+					return err
+				}
+				if strings.Contains(string(config), "unicast_peer") {
+					return fmt.Errorf("unicast peers not removed: %s", config)
+				}
+				return nil
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("use the vrrp auth password of the keepalived secret", func() {
+			By("create the keepalived secret and reference it")
+			secret := v1.Secret{
+				ObjectMeta: metav1.ObjectMeta{Name: "test-lb-keepalived", Namespace: "testns"},
+				Data:       map[string][]byte{helper.KeepalivedAuthPassKey: []byte("password")},
+			}
+			Expect(k8sClient.Create(ctx, &secret)).Should(Succeed())
+			patch := []byte(`{"spec":{"keepalivedSecretRef":{"name":"test-lb-keepalived","namespace":"testns"}}}`)
+			Expect(k8sClient.Patch(ctx, &lbm, client.RawPatch(types.MergePatchType, patch))).Should(Succeed())
+
+			checkAuthPass := func(authPass string) error {
+				if err := checkKeepalivedConfig("\t\tauth_pass " + authPass + "\n"); err != nil {
+					return err
+				}
+				var current v1.Secret
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: secret.Name, Namespace: "testns"}, &current); err != nil {
+					return err
+				}
+				var act yawolv1beta1.LoadBalancerMachine
+				if err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-lbm", Namespace: "testns"}, &act); err != nil {
+					return err
+				}
+				if act.Status.KeepalivedSecretVersion == nil || *act.Status.KeepalivedSecretVersion != current.ResourceVersion {
+					return fmt.Errorf("keepalived secret version %v is not reported", current.ResourceVersion)
+				}
+				return nil
+			}
+
+			By("check if the password is used and the secret version is reported")
+			Eventually(func() error {
+				return checkAuthPass("password")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("add the next password")
+			secret.Data[helper.KeepalivedNextAuthPassKey] = []byte("nextpass")
+			Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+
+			By("check if the password is kept and the new secret version is reported")
+			Eventually(func() error {
+				return checkAuthPass("password")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("switch to the next password")
+			secret.Data = map[string][]byte{helper.KeepalivedAuthPassKey: []byte("nextpass")}
+			Expect(k8sClient.Update(ctx, &secret)).Should(Succeed())
+
+			By("check if the next password is used")
+			Eventually(func() error {
+				return checkAuthPass("nextpass")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("lower the vrrp priority without healthy backends", func() {
+			By("add a port without a listening backend")
+			oldPorts := lb.Spec.Ports
+			oldEndpoints := lb.Spec.Endpoints
+			lb.Spec.Ports = []v1.ServicePort{{
+				Name:       "port",
+				Protocol:   "TCP",
+				Port:       8095,
+				TargetPort: intstr.IntOrString{IntVal: 8095},
+				NodePort:   12495,
+			}}
+			lb.Spec.Endpoints = []yawolv1beta1.LoadBalancerEndpoint{{
+				Name:      "localhost",
+				Addresses: []string{"127.0.0.1"},
+			}}
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+
+			By("check if the vrrp priority is lowered after the grace period")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.BackendsHealthy, helper.ConditionFalse, "NoHealthyBackends"); err != nil {
+					return err
+				}
+				return checkKeepalivedTrackFile("1\n")
+			}, helper.KeepalivedTrackGracePeriod+time.Second*30, time.Second*1).Should(Succeed())
+
+			By("start the backend")
+			backend, err := net.Listen("tcp", "127.0.0.1:12495")
+			Expect(err).ToNot(HaveOccurred())
+			go func() {
+				for {
+					conn, err := backend.Accept()
+					if err != nil {
+						return
+					}
+					_ = conn.Close()
+				}
+			}()
+
+			By("check if the vrrp priority is restored")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.BackendsHealthy, helper.ConditionTrue, "BackendsHealthy"); err != nil {
+					return err
+				}
+				return checkKeepalivedTrackFile("0\n")
+			}, time.Second*30, time.Second*1).Should(Succeed())
+
+			By("stop the backend and reset ports")
+			Expect(backend.Close()).Should(Succeed())
+			lb.Spec.Ports = oldPorts
+			lb.Spec.Endpoints = oldEndpoints
+			Expect(k8sClient.Update(ctx, &lb)).Should(Succeed())
+		})
+		It("update the keepalived status from the notify fifo", func() {
+			By("write a stats file that is older than the notifications")
+			Expect(writeKeepalivedStats(0, 0, time.Now())).Should(Succeed())
+
+			By("send a master notification")
+			Expect(writeKeepalivedNotification(`INSTANCE "ENVOY" MASTER 200`)).Should(Succeed())
+
+			By("check if the lbm is master")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionTrue, "KeepalivedNotification"); err != nil {
+					return err
+				}
+				return checkLBMMetric(ctx, helper.MetricKeepalivedPriority, "200")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("send a backup notification")
+			Expect(writeKeepalivedNotification(`INSTANCE "ENVOY" BACKUP 150`)).Should(Succeed())
+
+			By("check if the lbm is not master")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionFalse, "KeepalivedNotification"); err != nil {
+					return err
+				}
+				if err := checkLBMMetric(ctx, helper.MetricKeepalivedPriority, "150"); err != nil {
+					return err
+				}
+				return checkLBMMetric(ctx, helper.MetricKeepalivedTransitions, "2")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("update the keepalived status from a newer stats file", func() {
+			By("send a master notification")
+			Expect(writeKeepalivedNotification(`INSTANCE "ENVOY" MASTER 200`)).Should(Succeed())
+			Eventually(func() error {
+				return checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionTrue, "KeepalivedNotification")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("write a backup stats file after the notification")
+			Expect(writeKeepalivedStats(1, 1, time.Now().Add(time.Second))).Should(Succeed())
+
+			By("check if the lbm is not master")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionFalse, "KeepalivedStatus"); err != nil {
+					return err
+				}
+				return checkLBMMetric(ctx, helper.MetricKeepalivedIsMaster, "0")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("update the keepalived status if the stats file is stale", func() {
+			By("send a master notification")
+			Expect(writeKeepalivedStats(1, 0, time.Now())).Should(Succeed())
+			Expect(writeKeepalivedNotification(`INSTANCE "ENVOY" MASTER 200`)).Should(Succeed())
+			Eventually(func() error {
+				return checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionTrue, "KeepalivedNotification")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("let the stats file become older than the staleness limit")
+			staleTime := time.Now().Add(-helper.KeepalivedStatsMaxAge - time.Minute)
+			Expect(os.Chtimes(keepalivedStatsFile, staleTime, staleTime)).Should(Succeed())
+
+			By("check if the master status is unknown")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedStatsFile, helper.ConditionFalse, "StatsNotUpToDate"); err != nil {
+					return err
+				}
+				if err := checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionUnknown, "StatsNotUpToDate"); err != nil {
+					return err
+				}
+				return checkLBMMetric(ctx, helper.MetricKeepalivedIsMaster, "0")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
+		It("update the keepalived status if the stats file is unreadable", func() {
+			By("send a master notification")
+			Expect(writeKeepalivedStats(1, 0, time.Now())).Should(Succeed())
+			Expect(writeKeepalivedNotification(`INSTANCE "ENVOY" MASTER 200`)).Should(Succeed())
+			Eventually(func() error {
+				return checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionTrue, "KeepalivedNotification")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+
+			By("remove the stats file")
+			Expect(os.Remove(keepalivedStatsFile)).Should(Succeed())
+
+			By("check if the lbm is not master")
+			Eventually(func() error {
+				if err := checkLBMCondition(ctx, helper.KeepalivedStatsFile, helper.ConditionFalse, "CouldNotReadStats"); err != nil {
+					return err
+				}
+				if err := checkLBMCondition(ctx, helper.KeepalivedMaster, helper.ConditionFalse, "CouldNotReadStats"); err != nil {
+					return err
+				}
+				return checkLBMMetric(ctx, helper.MetricKeepalivedIsMaster, "0")
+			}, time.Second*15, time.Second*1).Should(Succeed())
+		})
 		It("test envoy not up to date", func() {
 			By("kill envoy process")
 			err := envoyCmd.Process.Kill()
@@ -623,3 +1363,234 @@ func checkConditions(
 	}
 	return nil
 }
+
+// generateTLSCertificate returns a self-signed certificate and key with the serial number in PEM format
+func generateTLSCertificate(serial int64) (certPEM, keyPEM []byte) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	Expect(err).ToNot(HaveOccurred())
+
+	template := x509.Certificate{
+		SerialNumber: big.NewInt(serial),
+		Subject:      pkix.Name{CommonName: "yawol.test"},
+		DNSNames:     []string{"yawol.test"},
+		NotBefore:    time.Now().Add(-time.Hour),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	cert, err := x509.CreateCertificate(rand.Reader, &template, &template, &key.PublicKey, key)
+	Expect(err).ToNot(HaveOccurred())
+
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	Expect(err).ToNot(HaveOccurred())
+
+	return pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: cert}),
+		pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+}
+
+// checkCertificate checks that envoy uses a certificate with the serial number
+func checkCertificate(serial string) error {
+	resp, err := http.Get("http://127.0.0.1:9000/certs")
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close() //nolint:errcheck // don't handle error in defer
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return err
+	}
+	if !strings.Contains(string(body), `"serial_number": "`+serial+`"`) {
+		return fmt.Errorf("certificate with serial number %s not found", serial)
+	}
+	return nil
+}
+
+// checkClusterLbPolicy checks the lb_policy of the cluster in the envoy config dump,
+// an empty policy is the default (ROUND_ROBIN), which is omitted in the dump
+func checkClusterLbPolicy(name, policy string) error {
+	cluster, err := getEnvoyClusterConfig(name)
+	if err != nil {
+		return err
+	}
+	if lbPolicy, _ := cluster["lb_policy"].(string); lbPolicy != policy {
+		return fmt.Errorf("cluster %s has lb policy %q instead of %q", name, lbPolicy, policy)
+	}
+	return nil
+}
+
+// checkClusterHealthCheck checks that the cluster in the envoy config dump has a health check
+// which contains the expected value, e.g. the path of an HTTP health check
+func checkClusterHealthCheck(name, expected string) error {
+	cluster, err := getEnvoyClusterConfig(name)
+	if err != nil {
+		return err
+	}
+	healthChecks, err := json.Marshal(cluster["health_checks"])
+	if err != nil {
+		return err
+	}
+	if cluster["health_checks"] == nil || !strings.Contains(string(healthChecks), expected) {
+		return fmt.Errorf("cluster %s has health checks %s without %s", name, healthChecks, expected)
+	}
+	return nil
+}
+
+// getEnvoyClusterConfig returns the config of the dynamic cluster from the envoy config dump
+func checkSourceRangesCondition(ctx context.Context, reason, message string) error {
+	lbm := yawolv1beta1.LoadBalancerMachine{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-lbm", Namespace: "testns"}, &lbm); err != nil {
+		return err
+	}
+	if lbm.Status.Conditions == nil {
+		return fmt.Errorf("no conditions set")
+	}
+	for _, condition := range *lbm.Status.Conditions {
+		if string(condition.Type) != string(helper.SourceRangesEnforced) {
+			continue
+		}
+		if condition.Reason != reason || condition.Message != message {
+			return fmt.Errorf("wrong source ranges condition %s: %s", condition.Reason, condition.Message)
+		}
+		return nil
+	}
+	return fmt.Errorf("condition %s not found", helper.SourceRangesEnforced)
+}
+
+func checkKeepalivedConfig(expected ...string) error {
+	config, err := os.ReadFile(keepalivedConfig)
+	if err != nil {
+		return err
+	}
+	for _, e := range expected {
+		if !strings.Contains(string(config), e) {
+			return fmt.Errorf("%q not found in keepalived config: %s", e, config)
+		}
+	}
+	return nil
+}
+
+func checkKeepalivedTrackFile(expected string) error {
+	value, err := os.ReadFile(keepalivedTrackFile)
+	if err != nil {
+		return err
+	}
+	if string(value) != expected {
+		return fmt.Errorf("wrong keepalived track file value: %q", value)
+	}
+	return nil
+}
+
+func getKeepalivedReloads() int {
+	reloads, err := os.ReadFile(keepalivedReloads)
+	if err != nil {
+		return 0
+	}
+	return strings.Count(string(reloads), "reload")
+}
+
+func checkLBMCondition(
+	ctx context.Context,
+	conditionType helper.LoadbalancerCondition,
+	status helper.LoadbalancerConditionStatus,
+	reason string,
+) error {
+	lbm := yawolv1beta1.LoadBalancerMachine{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-lbm", Namespace: "testns"}, &lbm); err != nil {
+		return err
+	}
+	if lbm.Status.Conditions == nil {
+		return fmt.Errorf("no conditions set")
+	}
+	for _, condition := range *lbm.Status.Conditions {
+		if string(condition.Type) != string(conditionType) {
+			continue
+		}
+		if string(condition.Status) != string(status) || (reason != "" && condition.Reason != reason) {
+			return fmt.Errorf("wrong %s condition %s: %s", conditionType, condition.Status, condition.Reason)
+		}
+		return nil
+	}
+	return fmt.Errorf("condition %s not found", conditionType)
+}
+
+func getEnvoyClusterProxyProtocolVersion(name string) (string, error) {
+	cluster, err := getEnvoyClusterConfig(name)
+	if err != nil {
+		return "", err
+	}
+	transportSocket, ok := cluster["transport_socket"].(map[string]interface{})
+	if !ok {
+		return "", fmt.Errorf("cluster %s has no transport socket", name)
+	}
+	typedConfig, _ := transportSocket["typed_config"].(map[string]interface{})
+	config, _ := typedConfig["config"].(map[string]interface{})
+	version, _ := config["version"].(string)
+	return version, nil
+}
+
+func getEnvoyClusterConfig(name string) (map[string]interface{}, error) {
+	resp, err := http.Get("http://127.0.0.1:9000/config_dump?resource=dynamic_active_clusters")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // don't handle error in defer
+
+	var dump struct {
+		Configs []struct {
+			Cluster map[string]interface{} `json:"cluster"`
+		} `json:"configs"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&dump); err != nil {
+		return nil, err
+	}
+	for _, config := range dump.Configs {
+		if config.Cluster["name"] == name {
+			return config.Cluster, nil
+		}
+	}
+	return nil, fmt.Errorf("cluster %s not found", name)
+}
+
+func writeKeepalivedNotification(line string) error {
+	fifo, err := os.OpenFile(keepalivedNotifyFIFO, os.O_WRONLY, 0)
+	if err != nil {
+		return err
+	}
+	if _, err = fifo.WriteString(line + "\n"); err != nil {
+		_ = fifo.Close()
+		return err
+	}
+	return fifo.Close()
+}
+
+// writeKeepalivedStats writes a stats file of the vrrp instance with the given modification time.
+func writeKeepalivedStats(becameMaster, releasedMaster int, modTime time.Time) error {
+	stats := fmt.Sprintf("VRRP Instance: %s\n"+
+		"  Advertisements:\n"+
+		"    Received: 0\n"+
+		"    Sent: 0\n"+
+		"  Became master: %d\n"+
+		"  Released master: %d\n", helper.VRRPInstanceName, becameMaster, releasedMaster)
+	if err := os.WriteFile(keepalivedStatsFile, []byte(stats), 0600); err != nil {
+		return err
+	}
+	return os.Chtimes(keepalivedStatsFile, modTime, modTime)
+}
+
+func checkLBMMetric(ctx context.Context, metricType helper.LoadbalancerMetric, expected string) error {
+	lbm := yawolv1beta1.LoadBalancerMachine{}
+	if err := k8sClient.Get(ctx, types.NamespacedName{Name: "test-lbm", Namespace: "testns"}, &lbm); err != nil {
+		return err
+	}
+	if lbm.Status.Metrics == nil {
+		return fmt.Errorf("no metrics set")
+	}
+	for _, metric := range *lbm.Status.Metrics {
+		if metric.Type != string(metricType) {
+			continue
+		}
+		if metric.Value != expected {
+			return fmt.Errorf("wrong %s metric: %s", metricType, metric.Value)
+		}
+		return nil
+	}
+	return fmt.Errorf("metric %s not found", metricType)
+}
@@ -5,6 +5,7 @@ import (
 	"fmt"
 	"log"
 	"net"
+	"os"
 	"os/exec"
 	"path/filepath"
 	"testing"
@@ -40,6 +41,18 @@ var (
 	envoyCmd  *exec.Cmd
 	ctx       context.Context
 	cancel    context.CancelFunc
+	// nftablesRules is the file with the last ruleset that was passed to the fake nft command
+	nftablesRules string
+	// keepalivedConfig is the keepalived config file that is written by the yawollet
+	keepalivedConfig string
+	// keepalivedReloads is the file the fake keepalived reload command appends a line to
+	keepalivedReloads string
+	// keepalivedTrackFile is the track file that is written by the yawollet
+	keepalivedTrackFile string
+	// keepalivedNotifyFIFO is the notify fifo that is read by the yawollet
+	keepalivedNotifyFIFO string
+	// keepalivedStatsFile is the stats file that is read by the yawollet
+	keepalivedStatsFile string
 )
 
 func TestAPIs(t *testing.T) {
@@ -122,16 +135,45 @@ var _ = BeforeSuite(func(done Done) {
 	err = envoyCmd.Start()
 	Expect(err).ToNot(HaveOccurred())
 
+	// fake nft command that stores the ruleset instead of applying it
+	nftablesDir, err := os.MkdirTemp("", "yawollet-nftables")
+	Expect(err).ToNot(HaveOccurred())
+	nftablesRules = filepath.Join(nftablesDir, "rules.nft")
+	nftablesCommand := filepath.Join(nftablesDir, "nft")
+	err = os.WriteFile(nftablesCommand, []byte("#!/bin/sh\ncat > "+nftablesRules+"\n"), 0700) //nolint:gosec // test script
+	Expect(err).ToNot(HaveOccurred())
+
+	// fake keepalived reload command that counts the reloads
+	keepalivedDir, err := os.MkdirTemp("", "yawollet-keepalived")
+	Expect(err).ToNot(HaveOccurred())
+	keepalivedConfig = filepath.Join(keepalivedDir, "keepalived.conf")
+	keepalivedReloads = filepath.Join(keepalivedDir, "reloads")
+	keepalivedTrackFile = filepath.Join(keepalivedDir, "yawollet.track")
+	keepalivedNotifyFIFO = filepath.Join(keepalivedDir, "yawollet.fifo")
+	keepalivedStatsFile = filepath.Join(keepalivedDir, "keepalived.stats")
+	keepalivedReloadCommand := filepath.Join(keepalivedDir, "reload")
+	err = os.WriteFile(keepalivedReloadCommand, []byte("#!/bin/sh\necho reload >> "+keepalivedReloads+"\n"), 0700) //nolint:gosec // test script
+	Expect(err).ToNot(HaveOccurred())
+
 	err = (&LoadBalancerReconciler{
 		Client:                  k8sManager.GetClient(),
+		APIReader:               k8sManager.GetAPIReader(),
 		Log:                     ctrl.Log.WithName("controllers").WithName("LoadBalancer"),
 		Scheme:                  k8sManager.GetScheme(),
 		Recorder:                k8sManager.GetEventRecorderFor("Loadbalancer"),
 		LoadbalancerName:        "test-lb",
 		LoadbalancerMachineName: "test-lbm",
 		EnvoyCache:              cache,
-		ListenAddress:           "127.0.0.1",
+		ListenAddresses:         []string{"127.0.0.1"},
 		RequeueTime:             1,
+		NftablesCommand:         nftablesCommand,
+		KeepalivedConfigFile:    keepalivedConfig,
+		KeepalivedInterface:     "eth0",
+		KeepalivedReloadCommand: keepalivedReloadCommand,
+		KeepalivedTrackFile:     keepalivedTrackFile,
+		KeepalivedNotifyFIFO:    keepalivedNotifyFIFO,
+		KeepalivedStatsFile:     keepalivedStatsFile,
+		Namespace:               "testns",
 	}).SetupWithManager(k8sManager)
 	Expect(err).ToNot(HaveOccurred())
 
@@ -153,4 +195,8 @@ var _ = AfterSuite(func() {
 	Expect(err).ToNot(HaveOccurred())
 	err = envoyCmd.Process.Kill()
 	Expect(err).ToNot(HaveOccurred())
+	err = os.RemoveAll(filepath.Dir(nftablesRules))
+	Expect(err).ToNot(HaveOccurred())
+	err = os.RemoveAll(filepath.Dir(keepalivedConfig))
+	Expect(err).ToNot(HaveOccurred())
 })
@@ -8,6 +8,7 @@ supervisor="supervise-daemon"
 command="/usr/local/bin/yawollet"
 command_args="$YAWOLLET_ARGS"
 command_user="yawol"
+capabilities="^cap_net_admin,^cap_kill"
 
 depend() {
 	after net 
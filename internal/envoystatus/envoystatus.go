@@ -2,6 +2,7 @@
 package envoystatus
 
 import (
+	"fmt"
 	"io"
 	"net/http"
 	"strings"
@@ -108,3 +109,36 @@ func (c *Config) GetCurrentStats() ([]yawolv1beta1.LoadBalancerMachineMetric, er
 
 	return metrics, err
 }
+
+// GetClustersWithoutHealthyHosts returns the TCP and UDP clusters without a healthy host
+func (c *Config) GetClustersWithoutHealthyHosts() ([]string, error) {
+	resp, err := http.Get("http://" + c.AdminAddress + "/stats?filter=membership_healthy")
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close() //nolint:errcheck // don't use error in defer
+
+	if resp.StatusCode != 200 {
+		return nil, fmt.Errorf("envoy stats returned status code %d", resp.StatusCode)
+	}
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+
+	// example output from request:
+	// cluster.TCP-8081.membership_healthy: 1
+	var clusters []string
+	for _, stats := range strings.Split(string(body), "\n") {
+		stat := strings.Split(stats, ".")
+		if len(stat) != 3 || !(strings.Contains(stat[1], "TCP") || strings.Contains(stat[1], "UDP")) {
+			continue
+		}
+		if strings.TrimPrefix(stat[2], "membership_healthy: ") == "0" {
+			clusters = append(clusters, stat[1])
+		}
+	}
+
+	return clusters, nil
+}
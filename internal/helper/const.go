@@ -3,9 +3,16 @@ package helper
 import "time"
 
 const (
-	DefaultRequeueTime = 10 * time.Millisecond
-	RevisionAnnotation = "loadbalancer.yawol.stackit.cloud/revision"
-	HashLabel          = "lbm-template-hash"
-	LoadBalancerKind   = "LoadBalancer"
-	VRRPInstanceName   = "ENVOY"
+	DefaultRequeueTime          = 10 * time.Millisecond
+	RevisionAnnotation          = "loadbalancer.yawol.stackit.cloud/revision"
+	HashLabel                   = "lbm-template-hash"
+	LoadBalancerKind            = "LoadBalancer"
+	VRRPInstanceName            = "ENVOY"
+	DefaultRevisionHistoryLimit = 2
+	KeepalivedAuthPassKey       = "authPass"
+	KeepalivedNextAuthPassKey   = "nextAuthPass"
+	DefaultVRRPRouterID         = 100
+	DefaultKeepalivedAuthPass   = "yawol"
+	KeepalivedTrackGracePeriod  = 30 * time.Second
+	KeepalivedStatsMaxAge       = 5 * time.Minute
 )
@@ -6,6 +6,7 @@ var (
 	ErrFailToReadRevisionFromAnnotation      = errors.New("failed to read revision from annotation")
 	ErrNotAValidIP                           = errors.New("not a valid IP address")
 	ErrNoFixedIPForLBPort                    = errors.New("no fixed ip for loadbalancer port")
+	ErrNoFixedIPForIPFamily                  = errors.New("no fixed ip for ip family on loadbalancer port")
 	ErrCouldNotReadSvcNameSpacedNameFromAnno = errors.New("could not read service namespacedname from annotation")
 	ErrNoEventFound                          = errors.New("no event found")
 	ErrMaxTriesExceeded                      = errors.New("max tries exceeded")
@@ -29,6 +30,16 @@ var (
 	ErrLBOnlyOneIPFamily                     = errors.New("LB only has one IP family type")
 	ErrNoExistingFIP                         = errors.New("no existingFloatingIP set")
 	ErrFIPNotFound                           = errors.New("FIP not found")
+	ErrFIPInUse                              = errors.New("FIP is already associated with another port")
+	ErrLoadBalancerIPConflict                = errors.New("loadBalancerIP and existingFloatingIP differ")
+	ErrFixedIPConflict                       = errors.New("loadBalancerIP and fixedIP of internal LB differ")
+	ErrSubnetNotFound                        = errors.New("subnet not found in network of LB")
+	ErrFixedIPNotInSubnet                    = errors.New("fixed ip is not in a subnet of the network of LB")
+	ErrFixedIPInUse                          = errors.New("fixed ip is already used by another port")
+	ErrInvalidSharedIPKey                    = errors.New("invalid sharing key for shared ip")
+	ErrSharedIPPortCollision                 = errors.New("port is already used by another service with the same shared ip")
+	ErrSharedIPSettingsMismatch              = errors.New("settings differ from other services with the same shared ip")
+	ErrSharedIPNotSupported                  = errors.New("externalTrafficPolicy Local and pod endpoints are not supported with shared ip")
 	ErrInvalidClassname                      = errors.New("invalid classname LB created")
 	ErrInvalidProtocol                       = errors.New("invalid protocol LB created")
 	ErrLBNotCleanedUp                        = errors.New("load balancer didn't clean up")
@@ -61,4 +72,26 @@ var (
 	ErrCouldNotParseSourceRange              = errors.New("could not parse LoadBalancerSourceRange")
 	ErrListingChildLBMs                      = errors.New("unable to list child loadbalancerMachines")
 	ErrUnsupportedProtocol                   = errors.New("unsupported protocol used (TCP and UDP is supported)")
+	ErrNoImageRef                            = errors.New("no image id, name or search defined")
+	ErrImageNotFound                         = errors.New("no active image found")
+	ErrNoFlavorRef                           = errors.New("no flavor id, name or search defined")
+	ErrFlavorNotFound                        = errors.New("no flavor found")
+	ErrFlavorAmbiguous                       = errors.New("multiple flavors found")
+	ErrInvalidStrategy                       = errors.New("invalid rollout strategy")
+	ErrRollbackRevisionNotFound              = errors.New("no LoadBalancerSet found for rollback revision")
+	ErrTLSSecretNameMissing                  = errors.New("tls ports are set but no tls secret name")
+	ErrTLSSecretInvalid                      = errors.New("tls secret must contain tls.crt and tls.key")
+	ErrTLSPortNotTCP                         = errors.New("tls is only supported for TCP ports")
+	ErrSNIRouteInvalid                       = errors.New("sni routes are only supported for TCP ports without tls and node endpoints")
+	ErrInvalidSNIHostname                    = errors.New("invalid sni hostname")
+	ErrInvalidLoadBalancingPolicy            = errors.New("invalid load balancing policy")
+	ErrInvalidHealthCheck                    = errors.New("invalid health check")
+	ErrInvalidTimeout                        = errors.New("invalid timeout")
+	ErrInvalidProxyProtocolVersion           = errors.New("invalid proxy protocol version, must be 1 or 2")
+	ErrNftablesFailed                        = errors.New("could not apply nftables rules for udp source ranges")
+	ErrVRRPRouterIDCollision                 = errors.New("vrrp router id is already used by another loadbalancer in the network")
+	ErrNoFreeVRRPRouterID                    = errors.New("no free vrrp router id in the network")
+	ErrKeepalivedSecretInvalid               = errors.New("keepalived secret must contain authPass")
+	ErrKeepalivedInterfaceNotFound           = errors.New("no interface found for keepalived")
+	ErrKeepalivedVIPsNotSet                  = errors.New("vips of the loadbalancermachine are not set")
 )
@@ -0,0 +1,24 @@
+package kubernetes
+
+import (
+	"k8s.io/apimachinery/pkg/api/meta"
+	metav1 "k8s.io/apimachinery/pkg/apis/meta/v1"
+)
+
+// SetConditionIfChanged sets the condition in a copy of conditions.
+// Returns the new conditions and true if status, reason, message or observedGeneration of the condition changed.
+func SetConditionIfChanged(conditions []metav1.Condition, condition metav1.Condition) ([]metav1.Condition, bool) {
+	current := meta.FindStatusCondition(conditions, condition.Type)
+	if current != nil &&
+		current.Status == condition.Status &&
+		current.Reason == condition.Reason &&
+		current.Message == condition.Message &&
+		current.ObservedGeneration == condition.ObservedGeneration {
+		return conditions, false
+	}
+
+	newConditions := make([]metav1.Condition, len(conditions))
+	copy(newConditions, conditions)
+	meta.SetStatusCondition(&newConditions, condition)
+	return newConditions, true
+}
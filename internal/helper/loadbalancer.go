@@ -10,16 +10,29 @@ import (
 	"time"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+	"github.com/stackitcloud/yawol/internal/helper/kubernetes"
 	helpermetrics "github.com/stackitcloud/yawol/internal/metrics"
 
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
 	"github.com/prometheus/client_golang/prometheus"
+	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"k8s.io/client-go/tools/record"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
+// LoadBalancer and LoadBalancerSet condition name const
+const (
+	Paused             LoadbalancerCondition = "Paused"
+	FloatingIPReady    LoadbalancerCondition = "FloatingIPReady"
+	PortReady          LoadbalancerCondition = "PortReady"
+	SecurityGroupReady LoadbalancerCondition = "SecurityGroupReady"
+	RolloutInProgress  LoadbalancerCondition = "RolloutInProgress"
+	Available          LoadbalancerCondition = "Available"
+)
+
 // LoadBalancerOpenstackReconcileIsNeeded returns true if an openstack reconcile is needed.
 func LoadBalancerOpenstackReconcileIsNeeded(lb *yawolv1beta1.LoadBalancer) bool {
 	// LastOpenstackReconcile is nil, first run
@@ -86,9 +99,14 @@ func GetLoadBalancerForLoadBalancerSet(
 // GetOpenStackReconcileHash returns a 16 char hash for all openstack relevant data to check if an openstack reconcile is needed.
 func GetOpenStackReconcileHash(lb *yawolv1beta1.LoadBalancer) (string, error) {
 	return HashData(map[string]interface{}{
-		"ports":         lb.Spec.Ports,
-		"sourceRanges":  lb.Spec.Options.LoadBalancerSourceRanges,
-		"debugSettings": lb.Spec.DebugSettings,
+		"ports":          lb.Spec.Ports,
+		"sourceRanges":   lb.Spec.Options.LoadBalancerSourceRanges,
+		"debugSettings":  lb.Spec.DebugSettings,
+		"image":          lb.Spec.Infrastructure.Image,
+		"flavor":         lb.Spec.Infrastructure.Flavor,
+		"ipFamilies":     lb.Spec.Options.IPFamilies,
+		"loadBalancerIP": lb.Spec.LoadBalancerIP,
+		"fixedIP":        GetRequestedFixedIP(lb),
 	})
 }
 
@@ -113,6 +131,29 @@ func PatchLBStatus(
 	return sw.Patch(ctx, lb, client.RawPatch(types.MergePatchType, patch))
 }
 
+// PatchLBCondition sets the condition in the LoadBalancer status.
+// The status is only patched if the condition changed.
+func PatchLBCondition(
+	ctx context.Context,
+	sw client.StatusWriter,
+	lb *yawolv1beta1.LoadBalancer,
+	condition metaV1.Condition,
+) error {
+	conditions, changed := kubernetes.SetConditionIfChanged(lb.Status.Conditions, condition)
+	if !changed {
+		return nil
+	}
+
+	return PatchLBStatus(ctx, sw, lb, yawolv1beta1.LoadBalancerStatus{
+		Conditions: conditions,
+	})
+}
+
+// IsLoadBalancerPaused returns true if the reconciliation of the LoadBalancer is paused.
+func IsLoadBalancerPaused(lb *yawolv1beta1.LoadBalancer) bool {
+	return lb != nil && lb.Spec.Paused
+}
+
 // GetOwnersReferenceForLB returns OwnerReference for LoadBalancer
 func GetOwnersReferenceForLB(lb *yawolv1beta1.LoadBalancer) metaV1.OwnerReference {
 	return metaV1.OwnerReference{
@@ -123,6 +164,177 @@ func GetOwnersReferenceForLB(lb *yawolv1beta1.LoadBalancer) metaV1.OwnerReferenc
 	}
 }
 
+// GetVRRPRouterID returns the keepalived virtual router ID for the LoadBalancer.
+// The current ID is kept as long as no older LoadBalancer in the same network uses it,
+// otherwise the lowest ID which is not used in the network is returned and collision is true.
+func GetVRRPRouterID(
+	lb *yawolv1beta1.LoadBalancer,
+	lbs []yawolv1beta1.LoadBalancer,
+) (routerID int32, collision bool, err error) {
+	used := map[int32]bool{}
+	for i := range lbs {
+		other := &lbs[i]
+		if (other.Namespace == lb.Namespace && other.Name == lb.Name) ||
+			other.Spec.Infrastructure.NetworkID != lb.Spec.Infrastructure.NetworkID ||
+			other.Status.VRRPRouterID == nil {
+			continue
+		}
+		used[*other.Status.VRRPRouterID] = true
+
+		// the newer LoadBalancer has to choose another ID
+		if lb.Status.VRRPRouterID != nil && *lb.Status.VRRPRouterID == *other.Status.VRRPRouterID && isOlder(other, lb) {
+			collision = true
+		}
+	}
+
+	if lb.Status.VRRPRouterID != nil && !collision {
+		return *lb.Status.VRRPRouterID, false, nil
+	}
+
+	for id := int32(1); id <= 255; id++ {
+		if !used[id] {
+			return id, collision, nil
+		}
+	}
+	return 0, collision, ErrNoFreeVRRPRouterID
+}
+
+func isOlder(lb, other *yawolv1beta1.LoadBalancer) bool {
+	if !lb.CreationTimestamp.Equal(&other.CreationTimestamp) {
+		return lb.CreationTimestamp.Before(&other.CreationTimestamp)
+	}
+	return lb.Namespace+"/"+lb.Name < other.Namespace+"/"+other.Name
+}
+
+// GetTLSSecretName returns the name of the TLS secret for the TLSPorts of the LoadBalancer
+func GetTLSSecretName(lb *yawolv1beta1.LoadBalancer) string {
+	return lb.Name + "-tls"
+}
+
+// GetLoadBalancingPolicy returns the load balancing policy of the port, defaults to ROUND_ROBIN
+func GetLoadBalancingPolicy(options yawolv1beta1.LoadBalancerOptions, port int32) yawolv1beta1.LoadBalancingPolicy {
+	for _, portPolicy := range options.PortLoadBalancingPolicies {
+		if portPolicy.Port == port {
+			return portPolicy.Policy
+		}
+	}
+	if options.LoadBalancingPolicy != "" {
+		return options.LoadBalancingPolicy
+	}
+	return yawolv1beta1.LoadBalancingPolicyRoundRobin
+}
+
+// GetTCPProxyProtocolVersion returns the version of the HAProxy TCP Proxy Protocol of the port, defaults to 2
+func GetTCPProxyProtocolVersion(options yawolv1beta1.LoadBalancerOptions, port int32) int32 {
+	for _, portVersion := range options.TCPProxyProtocolPortVersions {
+		if portVersion.Port == port {
+			return portVersion.Version
+		}
+	}
+	if options.TCPProxyProtocolVersion != 0 {
+		return options.TCPProxyProtocolVersion
+	}
+	return 2
+}
+
+// ValidateTCPProxyProtocolVersions returns an error if a HAProxy TCP Proxy Protocol version is not 1 or 2
+func ValidateTCPProxyProtocolVersions(options yawolv1beta1.LoadBalancerOptions) error {
+	if options.TCPProxyProtocolVersion != 0 && !isValidProxyProtocolVersion(options.TCPProxyProtocolVersion) {
+		return fmt.Errorf("%w: %d", ErrInvalidProxyProtocolVersion, options.TCPProxyProtocolVersion)
+	}
+	for _, portVersion := range options.TCPProxyProtocolPortVersions {
+		if !isValidProxyProtocolVersion(portVersion.Version) {
+			return fmt.Errorf("%w: %d:%d", ErrInvalidProxyProtocolVersion, portVersion.Port, portVersion.Version)
+		}
+	}
+	return nil
+}
+
+func isValidProxyProtocolVersion(version int32) bool {
+	return version == 1 || version == 2
+}
+
+// IsValidLoadBalancingPolicy returns true if the policy is supported
+func IsValidLoadBalancingPolicy(policy yawolv1beta1.LoadBalancingPolicy) bool {
+	switch policy {
+	case yawolv1beta1.LoadBalancingPolicyRoundRobin,
+		yawolv1beta1.LoadBalancingPolicyLeastRequest,
+		yawolv1beta1.LoadBalancingPolicyRingHash,
+		yawolv1beta1.LoadBalancingPolicyMaglev:
+		return true
+	}
+	return false
+}
+
+// ValidateHealthCheck checks the health check settings, unset values use the defaults
+func ValidateHealthCheck(healthCheck *yawolv1beta1.LoadBalancerHealthCheck) error {
+	if healthCheck == nil {
+		return nil
+	}
+	switch healthCheck.Type {
+	case "", yawolv1beta1.HealthCheckTypeTCP, yawolv1beta1.HealthCheckTypeHTTP:
+	default:
+		return fmt.Errorf("%w: unknown type %s", ErrInvalidHealthCheck, healthCheck.Type)
+	}
+	if healthCheck.TimeoutSeconds < 0 || healthCheck.IntervalSeconds < 0 ||
+		healthCheck.UnhealthyThreshold < 0 || healthCheck.HealthyThreshold < 0 {
+		return fmt.Errorf("%w: timeouts and thresholds must not be negative", ErrInvalidHealthCheck)
+	}
+	if healthCheck.HTTPPath != "" && !strings.HasPrefix(healthCheck.HTTPPath, "/") {
+		return fmt.Errorf("%w: http path %s must start with /", ErrInvalidHealthCheck, healthCheck.HTTPPath)
+	}
+	for _, status := range healthCheck.HTTPExpectedStatuses {
+		if status < 100 || status > 599 {
+			return fmt.Errorf("%w: http status %d", ErrInvalidHealthCheck, status)
+		}
+	}
+	if healthCheck.UDPPort < 0 || healthCheck.UDPPort > 65535 {
+		return fmt.Errorf("%w: udp port %d", ErrInvalidHealthCheck, healthCheck.UDPPort)
+	}
+	return nil
+}
+
+// ValidateTimeouts checks that the timeouts are positive, only the TCP idle timeout can be disabled with 0
+func ValidateTimeouts(timeouts *yawolv1beta1.LoadBalancerTimeouts) error {
+	if timeouts == nil {
+		return nil
+	}
+	if timeouts.ConnectTimeout != nil && timeouts.ConnectTimeout.Duration <= 0 {
+		return fmt.Errorf("%w: connect timeout %s must be positive", ErrInvalidTimeout, timeouts.ConnectTimeout.Duration)
+	}
+	if timeouts.TCPIdleTimeout != nil && timeouts.TCPIdleTimeout.Duration < 0 {
+		return fmt.Errorf("%w: tcp idle timeout %s must not be negative", ErrInvalidTimeout, timeouts.TCPIdleTimeout.Duration)
+	}
+	if timeouts.UDPIdleTimeout != nil && timeouts.UDPIdleTimeout.Duration <= 0 {
+		return fmt.Errorf("%w: udp idle timeout %s must be positive", ErrInvalidTimeout, timeouts.UDPIdleTimeout.Duration)
+	}
+	return nil
+}
+
+// GetTimeouts returns the timeouts of the port, values of the port overwrite the values of all ports.
+// Unset values use the envoy defaults.
+func GetTimeouts(options yawolv1beta1.LoadBalancerOptions, port int32) yawolv1beta1.LoadBalancerTimeouts {
+	timeouts := yawolv1beta1.LoadBalancerTimeouts{}
+	if options.Timeouts != nil {
+		timeouts = *options.Timeouts.DeepCopy()
+	}
+	for _, portTimeouts := range options.PortTimeouts {
+		if portTimeouts.Port != port {
+			continue
+		}
+		if portTimeouts.ConnectTimeout != nil {
+			timeouts.ConnectTimeout = portTimeouts.ConnectTimeout.DeepCopy()
+		}
+		if portTimeouts.TCPIdleTimeout != nil {
+			timeouts.TCPIdleTimeout = portTimeouts.TCPIdleTimeout.DeepCopy()
+		}
+		if portTimeouts.UDPIdleTimeout != nil {
+			timeouts.UDPIdleTimeout = portTimeouts.UDPIdleTimeout.DeepCopy()
+		}
+	}
+	return timeouts
+}
+
 func PatchLoadBalancerRevision(ctx context.Context, c client.Client, lb *yawolv1beta1.LoadBalancer, revision int) error {
 	if revision < 1 {
 		return ErrInvalidRevision
@@ -187,6 +399,120 @@ func GetLoadBalancerSourceRanges(lb *yawolv1beta1.LoadBalancer) []string {
 	return []string{"0.0.0.0/0", "::/0"}
 }
 
+// GetIPFamilies returns the IPFamilies from the spec.
+// If not set it uses IPv4 as default.
+func GetIPFamilies(lb *yawolv1beta1.LoadBalancer) []coreV1.IPFamily {
+	if len(lb.Spec.Options.IPFamilies) >= 1 {
+		return lb.Spec.Options.IPFamilies
+	}
+	return []coreV1.IPFamily{coreV1.IPv4Protocol}
+}
+
+// GetVIPsForIPFamilies returns the first fixed ip of the port for each IP family of the LoadBalancer.
+// The VIP of the primary IP family is always the first one and is required.
+// IPs of secondary IP families are only required if the IPFamilyPolicy is RequireDualStack.
+func GetVIPsForIPFamilies(lb *yawolv1beta1.LoadBalancer, fixedIPs []ports.IP) ([]string, error) {
+	requireAll := lb.Spec.Options.IPFamilyPolicy != nil &&
+		*lb.Spec.Options.IPFamilyPolicy == coreV1.IPFamilyPolicyRequireDualStack
+
+	vips := []string{}
+	for i, ipFamily := range GetIPFamilies(lb) {
+		vip := getFixedIPForIPFamily(fixedIPs, ipFamily)
+		if vip == "" {
+			if i == 0 || requireAll {
+				return nil, fmt.Errorf("%w: %s", ErrNoFixedIPForIPFamily, ipFamily)
+			}
+			continue
+		}
+		vips = append(vips, vip)
+	}
+	return vips, nil
+}
+
+// GetVRRPUnicastSrcIP returns the fixed IP of a machine port that keepalived sends VRRP adverts from.
+// The adverts have to use the IP family of the first VIP.
+func GetVRRPUnicastSrcIP(lb *yawolv1beta1.LoadBalancer, fixedIPs []ports.IP) string {
+	return getFixedIPForIPFamily(fixedIPs, GetIPFamilies(lb)[0])
+}
+
+func getFixedIPForIPFamily(fixedIPs []ports.IP, ipFamily coreV1.IPFamily) string {
+	if fixedIP := getPortIPForIPFamily(fixedIPs, ipFamily); fixedIP != nil {
+		return fixedIP.IPAddress
+	}
+	return ""
+}
+
+func getPortIPForIPFamily(fixedIPs []ports.IP, ipFamily coreV1.IPFamily) *ports.IP {
+	for i := range fixedIPs {
+		ip := net.ParseIP(fixedIPs[i].IPAddress)
+		if ip != nil && getIPFamily(ip) == ipFamily {
+			return &fixedIPs[i]
+		}
+	}
+	return nil
+}
+
+func getIPFamily(ip net.IP) coreV1.IPFamily {
+	if ip.To4() != nil {
+		return coreV1.IPv4Protocol
+	}
+	return coreV1.IPv6Protocol
+}
+
+// GetRequestedFixedIP returns the requested fixed ip of the LoadBalancer port.
+// The FixedIP option takes precedence over the LoadBalancerIP of internal LoadBalancers.
+// Returns nil if neither a subnet nor a fixed ip is requested.
+func GetRequestedFixedIP(lb *yawolv1beta1.LoadBalancer) *ports.IP {
+	fixedIP := ports.IP{
+		SubnetID:  lb.Spec.Options.SubnetID,
+		IPAddress: lb.Spec.Options.FixedIP,
+	}
+	if fixedIP.IPAddress == "" && lb.Spec.Options.InternalLB && lb.Spec.LoadBalancerIP != nil {
+		fixedIP.IPAddress = *lb.Spec.LoadBalancerIP
+	}
+	if fixedIP.SubnetID == "" && fixedIP.IPAddress == "" {
+		return nil
+	}
+	return &fixedIP
+}
+
+// HasRequestedFixedIP returns true if the fixed ips of the port already fulfill the requested fixed ip.
+// A requested address must be the VIP of its IP family, a requested subnet must contain one of the fixed ips.
+func HasRequestedFixedIP(fixedIPs []ports.IP, requested ports.IP) bool {
+	if requested.IPAddress == "" {
+		for _, fixedIP := range fixedIPs {
+			if fixedIP.SubnetID == requested.SubnetID {
+				return true
+			}
+		}
+		return false
+	}
+
+	requestedIP := net.ParseIP(requested.IPAddress)
+	if requestedIP == nil {
+		return false
+	}
+
+	vip := getPortIPForIPFamily(fixedIPs, getIPFamily(requestedIP))
+	return vip != nil &&
+		requestedIP.Equal(net.ParseIP(vip.IPAddress)) &&
+		(requested.SubnetID == "" || requested.SubnetID == vip.SubnetID)
+}
+
+// ReplaceFixedIPForIPFamily returns the fixed ips of the port where all fixed ips of the IP family
+// are replaced with the requested fixed ip. Fixed ips of other IP families are kept.
+func ReplaceFixedIPForIPFamily(fixedIPs []ports.IP, requested ports.IP, ipFamily coreV1.IPFamily) []ports.IP {
+	desiredFixedIPs := []ports.IP{requested}
+	for _, fixedIP := range fixedIPs {
+		ip := net.ParseIP(fixedIP.IPAddress)
+		if ip == nil || getIPFamily(ip) == ipFamily {
+			continue
+		}
+		desiredFixedIPs = append(desiredFixedIPs, fixedIP)
+	}
+	return desiredFixedIPs
+}
+
 // GetDesiredSecGroupRules returns all SecGroupRules that are needed.
 // Based on default rules, ports, debug settings.
 func GetDesiredSecGroupRulesForLoadBalancer(r record.EventRecorder, lb *yawolv1beta1.LoadBalancer, secGroupID string) []rules.SecGroupRule {
@@ -5,12 +5,20 @@ import (
 	"encoding/base64"
 	"encoding/json"
 	"fmt"
+	"sort"
 	"strconv"
+	"strings"
 	"time"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
+	"github.com/stackitcloud/yawol/internal/keepalived"
 	helpermetrics "github.com/stackitcloud/yawol/internal/metrics"
+	"github.com/stackitcloud/yawol/internal/openstack"
 
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+
+	coreV1 "k8s.io/api/core/v1"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
 	"sigs.k8s.io/controller-runtime/pkg/client"
@@ -37,14 +45,52 @@ func GetHashForLoadBalancerMachineSpecFromLoadBalancer(lb *yawolv1beta1.LoadBala
 		portID = *lb.Status.PortID
 	}
 
-	return HashData(yawolv1beta1.LoadBalancerMachineSpec{
+	spec := yawolv1beta1.LoadBalancerMachineSpec{
 		Infrastructure: lb.Spec.Infrastructure,
 		PortID:         portID,
 		LoadBalancerRef: yawolv1beta1.LoadBalancerRef{
 			Namespace: lb.Namespace,
 			Name:      lb.Name,
 		},
-	})
+	}
+
+	hashData := map[string]interface{}{}
+
+	// a resolved image is part of the hash, so that a new image for the same
+	// image name or search results in a new LoadBalancerSet
+	if lb.Status.ImageID != nil {
+		hashData["imageID"] = *lb.Status.ImageID
+	}
+
+	// the same applies to a resolved flavor
+	if lb.Status.FlavorID != nil {
+		hashData["flavorID"] = *lb.Status.FlavorID
+	}
+
+	// the VIPs of the machines depend on the ip families,
+	// the default IPv4 is not part of the hash to keep existing LoadBalancerSets
+	if ipFamilies := GetIPFamilies(lb); len(ipFamilies) > 1 || ipFamilies[0] != coreV1.IPv4Protocol {
+		hashData["ipFamilies"] = ipFamilies
+	}
+
+	// the keepalived config of the machines contains the VIP, so a new
+	// requested fixed ip results in a new LoadBalancerSet
+	if fixedIP := GetRequestedFixedIP(lb); fixedIP != nil {
+		hashData["fixedIP"] = *fixedIP
+	}
+
+	// the keepalived config of the machines contains the router ID, so a new
+	// router ID (e.g. after a collision) results in a new LoadBalancerSet
+	if lb.Status.VRRPRouterID != nil {
+		hashData["vrrpRouterID"] = *lb.Status.VRRPRouterID
+	}
+
+	if len(hashData) == 0 {
+		return HashData(spec)
+	}
+
+	hashData["spec"] = spec
+	return HashData(hashData)
 }
 
 func ParseLoadBalancerMachineMetrics(
@@ -115,40 +161,188 @@ func RemoveLoadBalancerMachineMetrics(
 	})
 }
 
+// IsKeepalivedMaster returns true if the KeepalivedMaster condition of the LoadBalancerMachine is true.
+func IsKeepalivedMaster(lbm *yawolv1beta1.LoadBalancerMachine) bool {
+	if lbm.Status.Conditions == nil {
+		return false
+	}
+	for _, condition := range *lbm.Status.Conditions {
+		if string(condition.Type) == string(KeepalivedMaster) {
+			return string(condition.Status) == string(ConditionTrue)
+		}
+	}
+	return false
+}
+
 // RemoveFromLBMStatus removes key from loadbalancermachine status.
 func RemoveFromLBMStatus(ctx context.Context, sw client.StatusWriter, lbm *yawolv1beta1.LoadBalancerMachine, key string) error {
 	patch := []byte(`{"status":{"` + key + `": null}}`)
 	return sw.Patch(ctx, lbm, client.RawPatch(types.MergePatchType, patch))
 }
 
-func GetImageID(spec yawolv1beta1.OpenstackImageRef) (string, error) {
+// GetImageID returns the openstack image ID for the given image reference.
+// ImageName and ImageSearch are resolved to the newest active image which matches the reference.
+func GetImageID(
+	ctx context.Context,
+	imageClient openstack.ImageClient,
+	spec yawolv1beta1.OpenstackImageRef,
+) (string, error) {
 	if spec.ImageID != nil {
 		return *spec.ImageID, nil
 	}
+
+	if spec.ImageName == nil && spec.ImageSearch == nil {
+		return "", ErrNoImageRef
+	}
+
+	opts := images.ListOpts{Status: images.ImageStatusActive}
 	if spec.ImageName != nil {
-		// TODO get imageid from name
-		return "", nil
+		opts.Name = *spec.ImageName
+	}
+
+	imageList, err := imageClient.List(ctx, opts)
+	if err != nil {
+		return "", err
+	}
+
+	var newest *images.Image
+	for i := range imageList {
+		if imageList[i].Status != images.ImageStatusActive {
+			continue
+		}
+		if spec.ImageName != nil && imageList[i].Name != *spec.ImageName {
+			continue
+		}
+		if spec.ImageName == nil && !imageMatchesSearch(&imageList[i], *spec.ImageSearch) {
+			continue
+		}
+		if newest == nil || imageIsNewer(&imageList[i], newest) {
+			newest = &imageList[i]
+		}
+	}
+
+	if newest == nil {
+		return "", fmt.Errorf("%w for %s", ErrImageNotFound, imageRefString(spec))
+	}
+
+	return newest.ID, nil
+}
+
+// imageMatchesSearch returns true if the search string equals a tag or a property of the image.
+// Properties are compared in the form key=value.
+func imageMatchesSearch(image *images.Image, search string) bool {
+	for _, tag := range image.Tags {
+		if tag == search {
+			return true
+		}
+	}
+	for key, value := range image.Properties {
+		if fmt.Sprintf("%s=%v", key, value) == search {
+			return true
+		}
+	}
+	return false
+}
+
+// imageIsNewer returns true if image a was created after image b.
+// Images with the same creation time are ordered by ID, so that the result does not depend on the list order.
+func imageIsNewer(a, b *images.Image) bool {
+	if !a.CreatedAt.Equal(b.CreatedAt) {
+		return a.CreatedAt.After(b.CreatedAt)
 	}
-	if spec.ImageSearch != nil {
-		// TODO get imageid by metadata search
-		return "", nil
+	return a.ID > b.ID
+}
+
+func imageRefString(spec yawolv1beta1.OpenstackImageRef) string {
+	if spec.ImageName != nil {
+		return "name " + *spec.ImageName
 	}
-	return "", fmt.Errorf("imageid not found")
+	return "search " + *spec.ImageSearch
 }
 
-func GetFlavorID(spec yawolv1beta1.OpenstackFlavorRef) (string, error) {
+// GetFlavorID returns the openstack flavor ID for the given flavor reference.
+// FlavorName must match exactly one flavor. FlavorSearch is matched against the extra specs of all flavors,
+// exact matches are preferred over partial matches. An error is returned if the best match is not unique.
+func GetFlavorID(
+	ctx context.Context,
+	flavorClient openstack.FlavorClient,
+	spec yawolv1beta1.OpenstackFlavorRef,
+) (string, error) {
 	if spec.FlavorID != nil {
 		return *spec.FlavorID, nil
 	}
-	if spec.FlavorName != nil {
-		// TODO get flavorid from name
-		return "", nil
+
+	if spec.FlavorName == nil && spec.FlavorSearch == nil {
+		return "", ErrNoFlavorRef
+	}
+
+	flavorList, err := flavorClient.List(ctx, flavors.ListOpts{})
+	if err != nil {
+		return "", err
+	}
+
+	var bestScore int
+	var matches []string
+	for i := range flavorList {
+		var score int
+		if spec.FlavorName != nil {
+			if flavorList[i].Name == *spec.FlavorName {
+				score = 1
+			}
+		} else {
+			var extraSpecs map[string]string
+			extraSpecs, err = flavorClient.ListExtraSpecs(ctx, flavorList[i].ID)
+			if err != nil {
+				return "", err
+			}
+			score = flavorSearchScore(extraSpecs, *spec.FlavorSearch)
+		}
+
+		switch {
+		case score == 0 || score < bestScore:
+			continue
+		case score > bestScore:
+			bestScore = score
+			matches = []string{flavorList[i].ID}
+		default:
+			matches = append(matches, flavorList[i].ID)
+		}
+	}
+
+	if len(matches) == 0 {
+		return "", fmt.Errorf("%w for %s", ErrFlavorNotFound, flavorRefString(spec))
 	}
-	if spec.FlavorSearch != nil {
-		// TODO get flavorid by metadata search
-		return "", nil
+
+	if len(matches) > 1 {
+		sort.Strings(matches)
+		return "", fmt.Errorf("%w for %s: %s", ErrFlavorAmbiguous, flavorRefString(spec), strings.Join(matches, ", "))
 	}
-	return "", fmt.Errorf("flavorid not found")
+
+	return matches[0], nil
+}
+
+// flavorSearchScore rates how good the extra specs of a flavor match the search string.
+// Returns 2 if the search equals an extra spec value or an extra spec in the form key=value,
+// 1 if it is only contained in one of them and 0 if it does not match at all.
+func flavorSearchScore(extraSpecs map[string]string, search string) int {
+	var score int
+	for key, value := range extraSpecs {
+		keyValue := key + "=" + value
+		if value == search || keyValue == search {
+			return 2
+		}
+		if strings.Contains(keyValue, search) {
+			score = 1
+		}
+	}
+	return score
+}
+
+func flavorRefString(spec yawolv1beta1.OpenstackFlavorRef) string {
+	if spec.FlavorName != nil {
+		return "name " + *spec.FlavorName
+	}
+	return "search " + *spec.FlavorSearch
 }
 
 // PatchLBMStatus patch loadbalancermachine status
@@ -172,12 +366,17 @@ func GenerateUserData(
 	loadBalancerMachineName string,
 	namespace string,
 	debug bool,
-	vip string,
+	vips []string,
+	vrrpRouterID int32,
+	keepalivedAuthPass string,
+	vrrpUnicastSrcIP string,
+	vrrpUnicastPeers []string,
 ) string {
 	bk := base64.StdEncoding.EncodeToString([]byte(kubeconfig))
-	keepalivedConfig := base64.StdEncoding.EncodeToString(
-		[]byte(generateKeepalivedConfig(vip)),
-	)
+	// the keepalived config is kept up to date by the yawollet once it is running
+	keepalivedConfig := base64.StdEncoding.EncodeToString([]byte(GenerateKeepalivedConfig(
+		keepalived.DefaultInterface, vips, vrrpRouterID, keepalivedAuthPass, vrrpUnicastSrcIP, vrrpUnicastPeers,
+	)))
 
 	var systemctlSshd, openrcSshd, openrcState string
 	if debug {
@@ -200,17 +399,27 @@ write_files:
   permissions: '0600'
 - encoding: b64
   content: ` + keepalivedConfig + `
-  owner: root:root
-  path: /etc/keepalived/keepalived.conf
+  owner: yawol:yawol
+  path: ` + keepalived.ConfigFile + `
+  permissions: '0600'
+- content: "0"
+  owner: yawol:yawol
+  path: ` + keepalived.TrackFile + `
   permissions: '0644'
 - content: >
     YAWOLLET_ARGS="-namespace=` + namespace + `
     -loadbalancer-name=` + loadBalancerName + `
     -loadbalancer-machine-name=` + loadBalancerMachineName + `
-    -listen-address=` + vip + `
+    -listen-address=` + strings.Join(vips, ",") + `
+    -nftables-command=nft
+    -keepalived-config=` + keepalived.ConfigFile + `
+    -keepalived-track-file=` + keepalived.TrackFile + `
+    -keepalived-notify-fifo=` + keepalived.NotifyFIFO + `
     -kubeconfig /etc/yawol/kubeconfig"
   path: /etc/yawol/env.conf
 runcmd:
+  - [ mkfifo, -m, '0600', ` + keepalived.NotifyFIFO + ` ]
+  - [ chown, yawol:yawol, /etc/keepalived, ` + keepalived.NotifyFIFO + ` ]
   - [ /sbin/rc-service, sshd, ` + openrcState + ` ]
   - [ /sbin/rc-update, ` + openrcSshd + `, sshd, default ]
   - [ systemctl, ` + systemctlSshd + `, ssh.service, --now ]
@@ -226,13 +435,53 @@ runcmd:
 	return tpl
 }
 
-func generateKeepalivedConfig(vip string) string {
+// GenerateKeepalivedConfig returns the keepalived config for the VIPs.
+// A vrrp instance only supports a single IP family in virtual_ipaddress,
+// the VIPs of all other IP families are added as virtual_ipaddress_excluded.
+// The default router ID and password are used if they are not set.
+// VRRP adverts are sent with multicast if the unicast source IP or the peers are unknown.
+// A running envoy adds 100 to the priority, the yawollet lowers it by 50 if the machine is unhealthy.
+func GenerateKeepalivedConfig(
+	iface string,
+	vips []string,
+	vrrpRouterID int32,
+	authPass string,
+	unicastSrcIP string,
+	unicastPeers []string,
+) string {
+	if vrrpRouterID == 0 {
+		vrrpRouterID = DefaultVRRPRouterID
+	}
+	if authPass == "" {
+		authPass = DefaultKeepalivedAuthPass
+	}
+
+	var excludedVIPs string
+	if len(vips) > 1 {
+		excludedVIPs = `
+
+	virtual_ipaddress_excluded {
+		` + strings.Join(vips[1:], "\n\t\t") + `
+	}`
+	}
+
+	var unicast string
+	if unicastSrcIP != "" && len(unicastPeers) > 0 {
+		unicast = `
+
+	unicast_src_ip ` + unicastSrcIP + `
+	unicast_peer {
+		` + strings.Join(unicastPeers, "\n\t\t") + `
+	}`
+	}
+
 	return `
 ! Configuration File for keepalived
 
 global_defs {
 	router_id envoy
 	max_auto_priority -1
+	notify_fifo ` + keepalived.NotifyFIFO + `
 }
 
 vrrp_track_process envoy {
@@ -240,25 +489,34 @@ vrrp_track_process envoy {
 	weight 100
 }
 
+vrrp_track_file yawollet {
+	file "` + keepalived.TrackFile + `"
+	weight -50
+}
+
 vrrp_instance ` + VRRPInstanceName + ` {
 	state MASTER
-	interface eth0
-	virtual_router_id 100
+	interface ` + iface + `
+	virtual_router_id ` + strconv.Itoa(int(vrrpRouterID)) + `
 	priority 100
-	advert_int 1
+	advert_int 1` + unicast + `
 
 	authentication {
 		auth_type PASS
-		auth_pass yawol
+		auth_pass ` + authPass + `
 	}
 
 	virtual_ipaddress {
-		` + vip + `
-	}
+		` + vips[0] + `
+	}` + excludedVIPs + `
 
 	track_process {
 		envoy
 	}
+
+	track_file {
+		yawollet
+	}
 }
 	`
 }
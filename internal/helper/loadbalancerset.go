@@ -2,10 +2,14 @@ package helper
 
 import (
 	"context"
+	"crypto/rand"
+	"encoding/base64"
 	"fmt"
+	"math/big"
 	"strconv"
 
 	v1 "k8s.io/api/core/v1"
+	apierrors "k8s.io/apimachinery/pkg/api/errors"
 	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
@@ -13,7 +17,7 @@ import (
 
 	"k8s.io/apimachinery/pkg/labels"
 	"k8s.io/apimachinery/pkg/types"
-	ctrl "sigs.k8s.io/controller-runtime"
+	"k8s.io/apimachinery/pkg/util/intstr"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -24,13 +28,24 @@ func CreateLoadBalancerSet(
 	machineSpec *yawolv1beta1.LoadBalancerMachineSpec,
 	hash string,
 	revision int,
+	replicas int,
 ) error {
 	lbsetLabels := GetLoadBalancerSetLabelsFromLoadBalancer(lb)
 	lbsetLabels[HashLabel] = hash
+	lbsetName := lb.Name + "-" + hash
+
+	// all LoadBalancerSets of the LoadBalancer use the same VRRP auth password,
+	// so machines of old and new LoadBalancerSets accept each others adverts during a rollout
+	spec := *machineSpec
+	secretRef, err := createKeepalivedSecret(ctx, c, lb, revision)
+	if err != nil {
+		return err
+	}
+	spec.KeepalivedSecretRef = secretRef
 
 	lbset := yawolv1beta1.LoadBalancerSet{
 		ObjectMeta: metaV1.ObjectMeta{
-			Name:      lb.Name + "-" + hash,
+			Name:      lbsetName,
 			Namespace: lb.Namespace,
 			OwnerReferences: []metaV1.OwnerReference{
 				GetOwnersReferenceForLB(lb),
@@ -46,9 +61,9 @@ func CreateLoadBalancerSet(
 			},
 			Template: yawolv1beta1.LoadBalancerMachineTemplateSpec{
 				Labels: lbsetLabels,
-				Spec:   *machineSpec,
+				Spec:   spec,
 			},
-			Replicas: lb.Spec.Replicas,
+			Replicas: replicas,
 		},
 	}
 	if err := c.Create(ctx, &lbset); err != nil {
@@ -57,90 +72,229 @@ func CreateLoadBalancerSet(
 	return nil
 }
 
-// PatchLoadBalancerSetReplicas sets replicas in LoadBalancerSet
-func PatchLoadBalancerSetReplicas(ctx context.Context, c client.Client, lbs *yawolv1beta1.LoadBalancerSet, replicas int) error {
-	patch := []byte(`{"spec": {"replicas": ` + strconv.Itoa(replicas) + `}}`)
-	return c.Patch(ctx, lbs, client.RawPatch(types.MergePatchType, patch))
+// GetKeepalivedSecretName returns the name of the secret with the VRRP auth password of the LoadBalancer
+func GetKeepalivedSecretName(lb *yawolv1beta1.LoadBalancer) string {
+	return lb.Name + "-keepalived"
 }
 
-// ScaleDownAllLoadBalancerSetsForLBBut Scales down all LoadBalancerSets deriving from a LB but the one with the name of exceptionName
-// This will use getLoadBalancerSetsForLoadBalancer to identify the LBS deriving from the given LB
-// See error handling getLoadBalancerSetsForLoadBalancer
-// See error handling patchLoadBalancerSetReplicas
-// Requests requeue when a LBS has been downscaled
-func ScaleDownAllLoadBalancerSetsForLBBut(
+// createKeepalivedSecret creates the secret with a random VRRP auth password for the LoadBalancer.
+// An existing secret is kept, it is only rotated by RotateKeepalivedSecretIfNeeded after a rollout.
+func createKeepalivedSecret(
 	ctx context.Context,
 	c client.Client,
 	lb *yawolv1beta1.LoadBalancer,
-	exceptionName string,
-) (ctrl.Result, error) {
-	loadBalancerSetList, err := GetLoadBalancerSetsForLoadBalancer(ctx, c, lb)
+	revision int,
+) (*v1.SecretReference, error) {
+	authPass, err := generateKeepalivedAuthPass()
 	if err != nil {
-		return ctrl.Result{}, err
+		return nil, err
 	}
 
-	var scaledDown bool
-	for i := range loadBalancerSetList.Items {
-		if loadBalancerSetList.Items[i].Name != exceptionName && loadBalancerSetList.Items[i].Spec.Replicas > 0 {
-			if err := PatchLoadBalancerSetReplicas(ctx, c, &loadBalancerSetList.Items[i], 0); err != nil {
-				return ctrl.Result{}, err
-			}
-			scaledDown = true
+	secret := v1.Secret{
+		ObjectMeta: metaV1.ObjectMeta{
+			Name:      GetKeepalivedSecretName(lb),
+			Namespace: lb.Namespace,
+			OwnerReferences: []metaV1.OwnerReference{{
+				APIVersion: yawolv1beta1.GroupVersion.String(),
+				Kind:       LoadBalancerKind,
+				Name:       lb.Name,
+				UID:        lb.UID,
+			}},
+			Annotations: map[string]string{
+				RevisionAnnotation: strconv.Itoa(revision),
+			},
+		},
+		Data: map[string][]byte{
+			KeepalivedAuthPassKey: []byte(authPass),
+		},
+	}
+	if err := c.Create(ctx, &secret); err != nil && !apierrors.IsAlreadyExists(err) {
+		return nil, err
+	}
+	return &v1.SecretReference{Name: secret.Name, Namespace: secret.Namespace}, nil
+}
+
+// RotateKeepalivedSecretIfNeeded generates a new VRRP auth password once for every revision of the LoadBalancer.
+// It must only be called if no old LoadBalancerSet has replicas. Machines with different passwords reject each
+// other's adverts, so the password is switched in two phases: the new password is added as nextAuthPass first and
+// only replaces the authPass once the keepalived configs of all machines are generated from the secret with the
+// new password. The yawollets watch the secret and switch at the same time.
+// Returns true if the rotation is still in progress.
+func RotateKeepalivedSecretIfNeeded(
+	ctx context.Context,
+	c client.Client,
+	lb *yawolv1beta1.LoadBalancer,
+	revision int,
+) (bool, error) {
+	secret := v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: lb.Namespace,
+		Name:      GetKeepalivedSecretName(lb),
+	}, &secret); err != nil {
+		return false, client.IgnoreNotFound(err)
+	}
+
+	if nextAuthPass, ok := secret.Data[KeepalivedNextAuthPassKey]; ok {
+		upToDate, err := keepalivedConfigsUpToDate(ctx, c, lb, secret.ResourceVersion)
+		if err != nil || !upToDate {
+			return true, err
 		}
+
+		patch := []byte(`{"data":{"` + KeepalivedAuthPassKey + `":"` + base64.StdEncoding.EncodeToString(nextAuthPass) + `",` +
+			`"` + KeepalivedNextAuthPassKey + `":null}}`)
+		return false, c.Patch(ctx, &secret, client.RawPatch(types.MergePatchType, patch))
 	}
 
-	if scaledDown {
-		return ctrl.Result{RequeueAfter: DefaultRequeueTime}, nil
+	if secret.Annotations[RevisionAnnotation] == strconv.Itoa(revision) {
+		return false, nil
 	}
-	return ctrl.Result{}, nil
+
+	authPass, err := generateKeepalivedAuthPass()
+	if err != nil {
+		return false, err
+	}
+
+	patch := []byte(`{"metadata":{"annotations":{"` + RevisionAnnotation + `":"` + strconv.Itoa(revision) + `"}},` +
+		`"data":{"` + KeepalivedNextAuthPassKey + `":"` + base64.StdEncoding.EncodeToString([]byte(authPass)) + `"}}`)
+	return true, c.Patch(ctx, &secret, client.RawPatch(types.MergePatchType, patch))
 }
 
-func LoadBalancerSetIsReady(
+// keepalivedConfigsUpToDate returns true if the keepalived configs of all machines of the LoadBalancer
+// are generated from the given version of the keepalived secret.
+func keepalivedConfigsUpToDate(
 	ctx context.Context,
 	c client.Client,
 	lb *yawolv1beta1.LoadBalancer,
-	currentSet *yawolv1beta1.LoadBalancerSet,
+	secretVersion string,
 ) (bool, error) {
-	loadBalancerSetList, err := GetLoadBalancerSetsForLoadBalancer(ctx, c, lb)
-	if err != nil {
+	var loadBalancerMachineList yawolv1beta1.LoadBalancerMachineList
+	if err := c.List(ctx, &loadBalancerMachineList, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(GetLoadBalancerSetLabelsFromLoadBalancer(lb)),
+		Namespace:     lb.Namespace,
+	}); err != nil {
 		return false, err
 	}
 
-	for i := range loadBalancerSetList.Items {
-		if loadBalancerSetList.Items[i].Name != currentSet.Name {
+	for i := range loadBalancerMachineList.Items {
+		lbm := &loadBalancerMachineList.Items[i]
+		if lbm.DeletionTimestamp != nil {
 			continue
 		}
-
-		desiredReplicas := currentSet.Spec.Replicas
-
-		if loadBalancerSetList.Items[i].Spec.Replicas != desiredReplicas {
+		if lbm.Status.KeepalivedSecretVersion == nil || *lbm.Status.KeepalivedSecretVersion != secretVersion {
 			return false, nil
 		}
+	}
+	return true, nil
+}
 
-		if loadBalancerSetList.Items[i].Status.Replicas == nil ||
-			loadBalancerSetList.Items[i].Status.ReadyReplicas == nil {
-			return false, nil
+// generateKeepalivedAuthPass returns a random password with the maximum length of 8 characters of keepalived
+func generateKeepalivedAuthPass() (string, error) {
+	const chars = "abcdefghijklmnopqrstuvwxyzABCDEFGHIJKLMNOPQRSTUVWXYZ0123456789"
+	authPass := make([]byte, 8)
+	for i := range authPass {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(chars))))
+		if err != nil {
+			return "", err
 		}
+		authPass[i] = chars[n.Int64()]
+	}
+	return string(authPass), nil
+}
 
-		if *loadBalancerSetList.Items[i].Status.Replicas != desiredReplicas ||
-			*loadBalancerSetList.Items[i].Status.ReadyReplicas != desiredReplicas {
-			return false, nil
+// GetKeepalivedAuthPass returns the VRRP auth password of the LoadBalancerMachine and the resource version of the
+// keepalived secret. LoadBalancerMachines without keepalived secret use the default password.
+func GetKeepalivedAuthPass(
+	ctx context.Context,
+	c client.Reader,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+) (authPass, secretVersion string, err error) {
+	if lbm.Spec.KeepalivedSecretRef == nil {
+		return DefaultKeepalivedAuthPass, "", nil
+	}
+	secret := v1.Secret{}
+	if err := c.Get(ctx, types.NamespacedName{
+		Namespace: lbm.Spec.KeepalivedSecretRef.Namespace,
+		Name:      lbm.Spec.KeepalivedSecretRef.Name,
+	}, &secret); err != nil {
+		return "", "", err
+	}
+	if len(secret.Data[KeepalivedAuthPassKey]) == 0 {
+		return "", "", fmt.Errorf("%w: %s", ErrKeepalivedSecretInvalid, secret.Name)
+	}
+	return string(secret.Data[KeepalivedAuthPassKey]), secret.ResourceVersion, nil
+}
+
+// PatchLoadBalancerSetReplicas sets replicas in LoadBalancerSet
+func PatchLoadBalancerSetReplicas(ctx context.Context, c client.Client, lbs *yawolv1beta1.LoadBalancerSet, replicas int) error {
+	patch := []byte(`{"spec": {"replicas": ` + strconv.Itoa(replicas) + `}}`)
+	return c.Patch(ctx, lbs, client.RawPatch(types.MergePatchType, patch))
+}
+
+// GetMaxSurgeAndMaxUnavailable returns the absolute maxSurge and maxUnavailable for a rollout of the LoadBalancer.
+// Without a strategy maxSurge is 100% and maxUnavailable is 0, so all LoadBalancerMachines are replaced at once
+// as soon as the new LoadBalancerSet is ready.
+// Returns an error if one of the values is invalid.
+func GetMaxSurgeAndMaxUnavailable(lb *yawolv1beta1.LoadBalancer) (maxSurge, maxUnavailable int, err error) {
+	surge := intstr.FromString("100%")
+	unavailable := intstr.FromInt(0)
+	if lb.Spec.Strategy != nil {
+		if lb.Spec.Strategy.MaxSurge != nil {
+			surge = *lb.Spec.Strategy.MaxSurge
+		}
+		if lb.Spec.Strategy.MaxUnavailable != nil {
+			unavailable = *lb.Spec.Strategy.MaxUnavailable
 		}
+	}
 
-		return true, nil
+	maxSurge, err = intstr.GetScaledValueFromIntOrPercent(&surge, lb.Spec.Replicas, true)
+	if err != nil || maxSurge < 0 {
+		return 0, 0, fmt.Errorf("%w: maxSurge %s", ErrInvalidStrategy, surge.String())
 	}
 
-	return false, fmt.Errorf("active LoadBalancerSet not found")
+	maxUnavailable, err = intstr.GetScaledValueFromIntOrPercent(&unavailable, lb.Spec.Replicas, false)
+	if err != nil || maxUnavailable < 0 {
+		return 0, 0, fmt.Errorf("%w: maxUnavailable %s", ErrInvalidStrategy, unavailable.String())
+	}
+
+	// a rollout could never proceed if neither surge nor unavailability is allowed
+	if maxSurge == 0 && maxUnavailable == 0 {
+		maxSurge = 1
+	}
+
+	return maxSurge, maxUnavailable, nil
 }
 
-// Checks if LoadBalancerSets deriving from LoadBalancers are downscaled except for the LoadBalancerSet with the name of exceptionName
-// Returns true if all are downscaled; false if not
-// Follows error contract of getLoadBalancerSetsForLoadBalancer
-func AreAllLoadBalancerSetsForLBButDownscaled(
+// GetKeepalivedMasterLoadBalancerSetHash returns the hash of the LoadBalancerSet
+// which contains the current keepalived master of the LoadBalancer.
+// Returns an empty string if there is no keepalived master.
+func GetKeepalivedMasterLoadBalancerSetHash(
 	ctx context.Context,
 	c client.Client,
 	lb *yawolv1beta1.LoadBalancer,
-	exceptionName string,
+) (string, error) {
+	var loadBalancerMachineList yawolv1beta1.LoadBalancerMachineList
+	if err := c.List(ctx, &loadBalancerMachineList, &client.ListOptions{
+		LabelSelector: labels.SelectorFromSet(GetLoadBalancerSetLabelsFromLoadBalancer(lb)),
+		Namespace:     lb.Namespace,
+	}); err != nil {
+		return "", err
+	}
+
+	for i := range loadBalancerMachineList.Items {
+		if loadBalancerMachineList.Items[i].DeletionTimestamp == nil &&
+			IsKeepalivedMaster(&loadBalancerMachineList.Items[i]) {
+			return loadBalancerMachineList.Items[i].Labels[HashLabel], nil
+		}
+	}
+
+	return "", nil
+}
+
+func LoadBalancerSetIsReady(
+	ctx context.Context,
+	c client.Client,
+	lb *yawolv1beta1.LoadBalancer,
+	currentSet *yawolv1beta1.LoadBalancerSet,
 ) (bool, error) {
 	loadBalancerSetList, err := GetLoadBalancerSetsForLoadBalancer(ctx, c, lb)
 	if err != nil {
@@ -148,21 +302,30 @@ func AreAllLoadBalancerSetsForLBButDownscaled(
 	}
 
 	for i := range loadBalancerSetList.Items {
-		if loadBalancerSetList.Items[i].Name == exceptionName {
+		if loadBalancerSetList.Items[i].Name != currentSet.Name {
 			continue
 		}
-		if loadBalancerSetList.Items[i].Spec.Replicas != 0 {
+
+		desiredReplicas := currentSet.Spec.Replicas
+
+		if loadBalancerSetList.Items[i].Spec.Replicas != desiredReplicas {
 			return false, nil
 		}
-		if loadBalancerSetList.Items[i].Status.Replicas != nil && *loadBalancerSetList.Items[i].Status.Replicas != 0 {
+
+		if loadBalancerSetList.Items[i].Status.Replicas == nil ||
+			loadBalancerSetList.Items[i].Status.ReadyReplicas == nil {
 			return false, nil
 		}
-		if loadBalancerSetList.Items[i].Status.ReadyReplicas != nil && *loadBalancerSetList.Items[i].Status.ReadyReplicas != 0 {
+
+		if *loadBalancerSetList.Items[i].Status.Replicas != desiredReplicas ||
+			*loadBalancerSetList.Items[i].Status.ReadyReplicas != desiredReplicas {
 			return false, nil
 		}
+
+		return true, nil
 	}
 
-	return true, nil
+	return false, fmt.Errorf("active LoadBalancerSet not found")
 }
 
 // This returns all LoadBalancerSets for a given LoadBalancer
@@ -239,6 +402,36 @@ func GetLoadBalancerSetForHash(
 	return &highestGenLBS, nil
 }
 
+// GetLoadBalancerSetForRevision returns the LoadBalancerSet of the LoadBalancer with the given revision.
+// Returns nil if no matching exists
+func GetLoadBalancerSetForRevision(
+	ctx context.Context,
+	c client.Client,
+	lb *yawolv1beta1.LoadBalancer,
+	revision int,
+) (*yawolv1beta1.LoadBalancerSet, error) {
+	loadBalancerSetList, err := GetLoadBalancerSetsForLoadBalancer(ctx, c, lb)
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range loadBalancerSetList.Items {
+		if loadBalancerSetList.Items[i].Annotations[RevisionAnnotation] == strconv.Itoa(revision) {
+			return &loadBalancerSetList.Items[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+// GetRevisionHistoryLimit returns the number of old LoadBalancerSets to retain for the LoadBalancer.
+func GetRevisionHistoryLimit(lb *yawolv1beta1.LoadBalancer) int {
+	if lb.Spec.RevisionHistoryLimit == nil || *lb.Spec.RevisionHistoryLimit < 0 {
+		return DefaultRevisionHistoryLimit
+	}
+	return *lb.Spec.RevisionHistoryLimit
+}
+
 func copyLabelMap(lbs map[string]string) map[string]string {
 	targetMap := make(map[string]string)
 	for key, value := range lbs {
@@ -8,18 +8,24 @@ import (
 	"github.com/stackitcloud/yawol/internal/openstack"
 
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
+	"k8s.io/utils/pointer"
 )
 
 // CreateFIP creates a FIP and returns it.
+// If the LoadBalancer requests a LoadBalancerIP, the FIP is allocated with this address.
 func CreateFIP(
 	ctx context.Context,
 	fipClient openstack.FipClient,
 	lb *yawolv1beta1.LoadBalancer,
 ) (*floatingips.FloatingIP, error) {
-	fip, err := fipClient.Create(ctx, floatingips.CreateOpts{
+	opts := floatingips.CreateOpts{
 		Description:       *lb.Status.FloatingName,
 		FloatingNetworkID: *lb.Spec.Infrastructure.FloatingNetID,
-	})
+	}
+	if lb.Spec.LoadBalancerIP != nil {
+		opts.FloatingIP = *lb.Spec.LoadBalancerIP
+	}
+	fip, err := fipClient.Create(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
@@ -98,6 +104,15 @@ func GetFIPByID(
 	return fip, nil
 }
 
+// UnbindFIP removes the port association of a fip
+func UnbindFIP(
+	ctx context.Context,
+	fipClient openstack.FipClient,
+	fipID string,
+) error {
+	return BindFIPToPort(ctx, fipClient, fipID, pointer.String(""))
+}
+
 // BindFIPToPort binds a fip to a port
 func BindFIPToPort(
 	ctx context.Context,
@@ -33,16 +33,22 @@ func GetPortByName(
 }
 
 // CreatePort creates a port in openstack
+// If fixedIPs are set, the port only gets these fixed ips.
 func CreatePort(
 	ctx context.Context,
 	portClient openstack.PortClient,
 	portName string,
 	networkID string,
+	fixedIPs []ports.IP,
 ) (*ports.Port, error) {
-	port, err := portClient.Create(ctx, ports.CreateOpts{
+	opts := ports.CreateOpts{
 		Name:      portName,
 		NetworkID: networkID,
-	})
+	}
+	if len(fixedIPs) > 0 {
+		opts.FixedIPs = fixedIPs
+	}
+	port, err := portClient.Create(ctx, opts)
 	if err != nil {
 		return nil, err
 	}
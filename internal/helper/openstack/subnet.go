@@ -0,0 +1,106 @@
+package openstack
+
+import (
+	"context"
+	"fmt"
+	"net"
+
+	"github.com/stackitcloud/yawol/internal/helper"
+	"github.com/stackitcloud/yawol/internal/openstack"
+
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+)
+
+// ValidateFixedIP validates a requested fixed ip for a port in the network.
+// The subnet must be part of the network and the address must be in the subnet and not be used by another port.
+// Returns the fixed ip with the subnet of the address and the ip version of the subnet.
+func ValidateFixedIP(
+	ctx context.Context,
+	subnetClient openstack.SubnetClient,
+	portClient openstack.PortClient,
+	networkID string,
+	portID string,
+	requested ports.IP,
+) (ports.IP, int, error) {
+	subnetList, err := subnetClient.List(ctx, subnets.ListOpts{NetworkID: networkID})
+	if err != nil {
+		return ports.IP{}, 0, err
+	}
+
+	var ip net.IP
+	if requested.IPAddress != "" {
+		if ip = net.ParseIP(requested.IPAddress); ip == nil {
+			return ports.IP{}, 0, fmt.Errorf("%w: %s", helper.ErrNotAValidIP, requested.IPAddress)
+		}
+	}
+
+	var subnet *subnets.Subnet
+	for i := range subnetList {
+		if requested.SubnetID != "" && subnetList[i].ID != requested.SubnetID {
+			continue
+		}
+		if ip != nil && !subnetContainsIP(&subnetList[i], ip) {
+			continue
+		}
+		subnet = &subnetList[i]
+		break
+	}
+
+	if subnet == nil {
+		if ip == nil {
+			return ports.IP{}, 0, fmt.Errorf("%w: %s", helper.ErrSubnetNotFound, requested.SubnetID)
+		}
+		return ports.IP{}, 0, fmt.Errorf("%w: %s", helper.ErrFixedIPNotInSubnet, requested.IPAddress)
+	}
+
+	if ip == nil {
+		return ports.IP{SubnetID: subnet.ID}, subnet.IPVersion, nil
+	}
+
+	portList, err := portClient.List(ctx, ports.ListOpts{
+		NetworkID: networkID,
+		FixedIPs:  []ports.FixedIPOpts{{IPAddress: requested.IPAddress, SubnetID: subnet.ID}},
+	})
+	if err != nil {
+		return ports.IP{}, 0, err
+	}
+
+	for i := range portList {
+		if portList[i].ID != portID {
+			return ports.IP{}, 0, fmt.Errorf("%w: %s is used by port %s", helper.ErrFixedIPInUse, requested.IPAddress, portList[i].ID)
+		}
+	}
+
+	return ports.IP{SubnetID: subnet.ID, IPAddress: requested.IPAddress}, subnet.IPVersion, nil
+}
+
+// GetSubnetForIPVersion returns the first subnet of the network with the ip version.
+// Returns nil if the network has no subnet with the ip version.
+func GetSubnetForIPVersion(
+	ctx context.Context,
+	subnetClient openstack.SubnetClient,
+	networkID string,
+	ipVersion int,
+) (*subnets.Subnet, error) {
+	subnetList, err := subnetClient.List(ctx, subnets.ListOpts{NetworkID: networkID, IPVersion: ipVersion})
+	if err != nil {
+		return nil, err
+	}
+
+	for i := range subnetList {
+		if subnetList[i].IPVersion == ipVersion {
+			return &subnetList[i], nil
+		}
+	}
+
+	return nil, nil
+}
+
+func subnetContainsIP(subnet *subnets.Subnet, ip net.IP) bool {
+	_, cidr, err := net.ParseCIDR(subnet.CIDR)
+	if err != nil {
+		return false
+	}
+	return cidr.Contains(ip)
+}
@@ -2,15 +2,22 @@ package helper
 
 import (
 	"context"
+	"crypto/sha256"
+	"encoding/base32"
 	"encoding/json"
 	"fmt"
+	"reflect"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
 
 	yawolv1beta1 "github.com/stackitcloud/yawol/api/v1beta1"
 
 	coreV1 "k8s.io/api/core/v1"
+	metaV1 "k8s.io/apimachinery/pkg/apis/meta/v1"
 	"k8s.io/apimachinery/pkg/types"
+	"k8s.io/apimachinery/pkg/util/validation"
 	"sigs.k8s.io/controller-runtime/pkg/client"
 )
 
@@ -54,10 +61,55 @@ func GetOptions(svc *coreV1.Service) yawolv1beta1.LoadBalancerOptions {
 		options.TCPProxyProtocol, _ = strconv.ParseBool(svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocol])
 	}
 	if svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocolPortsFilter] != "" {
-		options.TCPProxyProtocolPortsFilter = getTCPProxyProtocolPortsFilter(
+		options.TCPProxyProtocolPortsFilter = getPortsFilter(
 			svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocolPortsFilter],
 		)
 	}
+	if svc.Spec.IPFamilies != nil {
+		options.IPFamilies = svc.Spec.IPFamilies
+	}
+	if svc.Spec.IPFamilyPolicy != nil {
+		options.IPFamilyPolicy = svc.Spec.IPFamilyPolicy
+	}
+	if svc.Annotations[yawolv1beta1.ServicePodEndpoints] != "" {
+		options.PodEndpoints, _ = strconv.ParseBool(svc.Annotations[yawolv1beta1.ServicePodEndpoints])
+	}
+	if svc.Spec.ExternalTrafficPolicy == coreV1.ServiceExternalTrafficPolicyTypeLocal {
+		options.HealthCheckNodePort = svc.Spec.HealthCheckNodePort
+	}
+	if svc.Annotations[yawolv1beta1.ServiceSubnetID] != "" {
+		options.SubnetID = svc.Annotations[yawolv1beta1.ServiceSubnetID]
+	}
+	if svc.Annotations[yawolv1beta1.ServiceFixedIP] != "" {
+		options.FixedIP = svc.Annotations[yawolv1beta1.ServiceFixedIP]
+	}
+	if svc.Annotations[yawolv1beta1.ServiceTLSPorts] != "" {
+		options.TLSPorts = getPortsFilter(svc.Annotations[yawolv1beta1.ServiceTLSPorts])
+	}
+	if svc.Annotations[yawolv1beta1.ServiceLoadBalancingPolicy] != "" {
+		options.LoadBalancingPolicy, options.PortLoadBalancingPolicies, _ = getLoadBalancingPolicies(
+			svc.Annotations[yawolv1beta1.ServiceLoadBalancingPolicy],
+		)
+	}
+	if svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocolVersion] != "" {
+		options.TCPProxyProtocolVersion, options.TCPProxyProtocolPortVersions, _ = getProxyProtocolVersions(
+			svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocolVersion],
+		)
+	}
+	if svc.Annotations[yawolv1beta1.ServiceDownstreamProxyProtocol] != "" {
+		options.DownstreamProxyProtocol, _ = strconv.ParseBool(svc.Annotations[yawolv1beta1.ServiceDownstreamProxyProtocol])
+	}
+	if svc.Annotations[yawolv1beta1.ServiceDownstreamProxyProtocolPortsFilter] != "" {
+		options.DownstreamProxyProtocolPortsFilter = getPortsFilter(
+			svc.Annotations[yawolv1beta1.ServiceDownstreamProxyProtocolPortsFilter],
+		)
+	}
+	options.HealthCheck, _ = getHealthCheck(svc)
+	options.Timeouts, options.PortTimeouts, _ = getTimeouts(svc)
+	// session affinity to the client ip is done by hashing the source ip
+	if svc.Spec.SessionAffinity == coreV1.ServiceAffinityClientIP && options.LoadBalancingPolicy == "" {
+		options.LoadBalancingPolicy = yawolv1beta1.LoadBalancingPolicyMaglev
+	}
 	return options
 }
 
@@ -79,6 +131,15 @@ func GetExistingFloatingIPFromAnnotation(svc *coreV1.Service) *string {
 	return nil
 }
 
+// GetLoadBalancerIP returns the requested ip from the service spec.loadBalancerIP
+func GetLoadBalancerIP(svc *coreV1.Service) *string {
+	if svc.Spec.LoadBalancerIP != "" {
+		loadBalancerIP := svc.Spec.LoadBalancerIP
+		return &loadBalancerIP
+	}
+	return nil
+}
+
 // GetReplicasFromService retruns replicas from Annotation. Default is 1
 func GetReplicasFromService(service *coreV1.Service) int {
 	replicaString, found := service.Annotations[yawolv1beta1.ServiceReplicas]
@@ -93,7 +154,61 @@ func GetReplicasFromService(service *coreV1.Service) int {
 	return replicas
 }
 
+// GetTLSSecretNameFromService returns the name of the TLS secret from the ServiceTLSSecretName annotation
+func GetTLSSecretNameFromService(service *coreV1.Service) string {
+	return service.Annotations[yawolv1beta1.ServiceTLSSecretName]
+}
+
+// GetSNIHostnames returns the hostnames from the ServiceSNIHostnames annotation
+func GetSNIHostnames(service *coreV1.Service) []string {
+	if service.Annotations[yawolv1beta1.ServiceSNIHostnames] == "" {
+		return nil
+	}
+	var hostnames []string
+	for _, hostname := range strings.Split(service.Annotations[yawolv1beta1.ServiceSNIHostnames], ",") {
+		if hostname = strings.TrimSpace(hostname); hostname != "" {
+			hostnames = append(hostnames, hostname)
+		}
+	}
+	return hostnames
+}
+
+// GetSNIRoutesFromService returns a SNI route for every TCP port without TLS termination
+// if the service has SNI hostnames. Connections with other server names are rejected.
+func GetSNIRoutesFromService(service *coreV1.Service) []yawolv1beta1.LoadBalancerSNIRoute {
+	hostnames := GetSNIHostnames(service)
+	if len(hostnames) == 0 {
+		return nil
+	}
+	tlsPorts := GetOptions(service).TLSPorts
+	var routes []yawolv1beta1.LoadBalancerSNIRoute
+	for _, port := range service.Spec.Ports {
+		if port.Protocol != coreV1.ProtocolTCP || isPortInList(tlsPorts, port.Port) {
+			continue
+		}
+		routes = append(routes, yawolv1beta1.LoadBalancerSNIRoute{
+			Port:      port.Port,
+			Hostnames: hostnames,
+			NodePort:  port.NodePort,
+		})
+	}
+	return routes
+}
+
+// GetSharedIPKey returns the sharing key from the ServiceAllowSharedIP annotation
+func GetSharedIPKey(service *coreV1.Service) string {
+	return service.Annotations[yawolv1beta1.ServiceAllowSharedIP]
+}
+
+// GetLoadBalancerNameFromService returns the name of the LoadBalancer for the service.
+// Services with a sharing key share the LoadBalancer of the key. Its name contains a hash of the key
+// and starts with a digit after the namespace, which is invalid for service names (DNS-1035 labels),
+// so that it never matches the LoadBalancer name of a single service.
 func GetLoadBalancerNameFromService(service *coreV1.Service) string {
+	if key := GetSharedIPKey(service); key != "" {
+		hash := sha256.Sum256([]byte(key))
+		return service.Namespace + "--0shared-" + strings.ToLower(base32.StdEncoding.EncodeToString(hash[:]))[:16]
+	}
 	return service.Namespace + "--" + service.Name
 }
 
@@ -107,16 +222,233 @@ func ValidateService(svc *coreV1.Service) error {
 			return fmt.Errorf("%w: %v)", ErrUnsupportedProtocol, port.Protocol)
 		}
 	}
+	if key := GetSharedIPKey(svc); key != "" {
+		if errs := validation.IsDNS1123Label(key); len(errs) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidSharedIPKey, strings.Join(errs, ", "))
+		}
+	}
+	if svc.Annotations[yawolv1beta1.ServiceTLSPorts] != "" && GetTLSSecretNameFromService(svc) == "" {
+		return ErrTLSSecretNameMissing
+	}
+	if value := svc.Annotations[yawolv1beta1.ServiceLoadBalancingPolicy]; value != "" {
+		if _, _, err := getLoadBalancingPolicies(value); err != nil {
+			return err
+		}
+	}
+	if value := svc.Annotations[yawolv1beta1.ServiceTCPProxyProtocolVersion]; value != "" {
+		if _, _, err := getProxyProtocolVersions(value); err != nil {
+			return err
+		}
+	}
+	if _, err := getHealthCheck(svc); err != nil {
+		return err
+	}
+	if _, _, err := getTimeouts(svc); err != nil {
+		return err
+	}
+	if len(GetSNIHostnames(svc)) > 0 && GetOptions(svc).PodEndpoints {
+		return ErrSNIRouteInvalid
+	}
+	for _, hostname := range GetSNIHostnames(svc) {
+		errs := validation.IsDNS1123Subdomain(hostname)
+		if strings.HasPrefix(hostname, "*.") {
+			errs = validation.IsWildcardDNS1123Subdomain(hostname)
+		}
+		if len(errs) > 0 {
+			return fmt.Errorf("%w: %s", ErrInvalidSNIHostname, strings.Join(errs, ", "))
+		}
+	}
 	return nil
 }
 
-// getTCPProxyProtocolPortsFilter return port list from annotation
-func getTCPProxyProtocolPortsFilter(tcpProxyProtocolPortsFilter string) []int32 {
-	if tcpProxyProtocolPortsFilter == "" {
+// getHealthCheck returns the health check settings from the health check annotations,
+// returns nil if none of them is set
+func getHealthCheck(svc *coreV1.Service) (*yawolv1beta1.LoadBalancerHealthCheck, error) {
+	healthCheck := yawolv1beta1.LoadBalancerHealthCheck{
+		Type:       yawolv1beta1.LoadBalancerHealthCheckType(svc.Annotations[yawolv1beta1.ServiceHealthCheckType]),
+		HTTPPath:   svc.Annotations[yawolv1beta1.ServiceHealthCheckHTTPPath],
+		TCPSend:    svc.Annotations[yawolv1beta1.ServiceHealthCheckTCPSend],
+		TCPReceive: svc.Annotations[yawolv1beta1.ServiceHealthCheckTCPReceive],
+	}
+
+	for annotation, value := range map[string]*int32{
+		yawolv1beta1.ServiceHealthCheckTimeoutSeconds:     &healthCheck.TimeoutSeconds,
+		yawolv1beta1.ServiceHealthCheckIntervalSeconds:    &healthCheck.IntervalSeconds,
+		yawolv1beta1.ServiceHealthCheckUnhealthyThreshold: &healthCheck.UnhealthyThreshold,
+		yawolv1beta1.ServiceHealthCheckHealthyThreshold:   &healthCheck.HealthyThreshold,
+		yawolv1beta1.ServiceHealthCheckUDPPort:            &healthCheck.UDPPort,
+	} {
+		if svc.Annotations[annotation] == "" {
+			continue
+		}
+		parsed, err := strconv.ParseInt(svc.Annotations[annotation], 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%w: %s: %v", ErrInvalidHealthCheck, annotation, err)
+		}
+		*value = int32(parsed)
+	}
+
+	if statuses := svc.Annotations[yawolv1beta1.ServiceHealthCheckHTTPExpectedStatuses]; statuses != "" {
+		for _, status := range strings.Split(statuses, ",") {
+			parsed, err := strconv.ParseInt(strings.TrimSpace(status), 10, 32)
+			if err != nil {
+				return nil, fmt.Errorf("%w: %s: %v", ErrInvalidHealthCheck, yawolv1beta1.ServiceHealthCheckHTTPExpectedStatuses, err)
+			}
+			healthCheck.HTTPExpectedStatuses = append(healthCheck.HTTPExpectedStatuses, int32(parsed))
+		}
+	}
+
+	if reflect.DeepEqual(healthCheck, yawolv1beta1.LoadBalancerHealthCheck{}) {
+		return nil, nil
+	}
+	if err := ValidateHealthCheck(&healthCheck); err != nil {
+		return nil, err
+	}
+	return &healthCheck, nil
+}
+
+// getTimeouts returns the timeouts of all ports and the timeouts of single ports from the timeout annotations
+func getTimeouts(svc *coreV1.Service) (
+	*yawolv1beta1.LoadBalancerTimeouts,
+	[]yawolv1beta1.LoadBalancerPortTimeouts,
+	error,
+) {
+	timeouts := yawolv1beta1.LoadBalancerTimeouts{}
+	portTimeouts := map[int32]*yawolv1beta1.LoadBalancerTimeouts{}
+
+	for _, annotation := range []string{
+		yawolv1beta1.ServiceConnectTimeout,
+		yawolv1beta1.ServiceTCPIdleTimeout,
+		yawolv1beta1.ServiceUDPIdleTimeout,
+	} {
+		if svc.Annotations[annotation] == "" {
+			continue
+		}
+		for _, entry := range strings.Split(svc.Annotations[annotation], ",") {
+			entry = strings.TrimSpace(entry)
+			target := &timeouts
+			if parts := strings.SplitN(entry, ":", 2); len(parts) == 2 {
+				port, err := strconv.Atoi(parts[0])
+				if err != nil || port < 1 || port > 65535 {
+					return nil, nil, fmt.Errorf("%w: %s: invalid port %s", ErrInvalidTimeout, annotation, parts[0])
+				}
+				if portTimeouts[int32(port)] == nil {
+					portTimeouts[int32(port)] = &yawolv1beta1.LoadBalancerTimeouts{}
+				}
+				target, entry = portTimeouts[int32(port)], parts[1]
+			}
+
+			duration, err := time.ParseDuration(entry)
+			if err != nil {
+				return nil, nil, fmt.Errorf("%w: %s: %v", ErrInvalidTimeout, annotation, err)
+			}
+			switch annotation {
+			case yawolv1beta1.ServiceConnectTimeout:
+				target.ConnectTimeout = &metaV1.Duration{Duration: duration}
+			case yawolv1beta1.ServiceTCPIdleTimeout:
+				target.TCPIdleTimeout = &metaV1.Duration{Duration: duration}
+			case yawolv1beta1.ServiceUDPIdleTimeout:
+				target.UDPIdleTimeout = &metaV1.Duration{Duration: duration}
+			}
+		}
+	}
+
+	if err := ValidateTimeouts(&timeouts); err != nil {
+		return nil, nil, err
+	}
+	var defaultTimeouts *yawolv1beta1.LoadBalancerTimeouts
+	if !reflect.DeepEqual(timeouts, yawolv1beta1.LoadBalancerTimeouts{}) {
+		defaultTimeouts = &timeouts
+	}
+
+	var ports []yawolv1beta1.LoadBalancerPortTimeouts
+	for port, portTimeout := range portTimeouts {
+		if err := ValidateTimeouts(portTimeout); err != nil {
+			return nil, nil, err
+		}
+		ports = append(ports, yawolv1beta1.LoadBalancerPortTimeouts{Port: port, LoadBalancerTimeouts: *portTimeout})
+	}
+	sort.Slice(ports, func(i, j int) bool {
+		return ports[i].Port < ports[j].Port
+	})
+
+	return defaultTimeouts, ports, nil
+}
+
+// getLoadBalancingPolicies returns the default policy and the policies of single ports from annotation
+func getLoadBalancingPolicies(value string) (
+	yawolv1beta1.LoadBalancingPolicy,
+	[]yawolv1beta1.LoadBalancerPortPolicy,
+	error,
+) {
+	var defaultPolicy yawolv1beta1.LoadBalancingPolicy
+	var portPolicies []yawolv1beta1.LoadBalancerPortPolicy
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, ":") {
+			if !IsValidLoadBalancingPolicy(yawolv1beta1.LoadBalancingPolicy(entry)) {
+				return "", nil, fmt.Errorf("%w: %s", ErrInvalidLoadBalancingPolicy, entry)
+			}
+			defaultPolicy = yawolv1beta1.LoadBalancingPolicy(entry)
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		port, err := strconv.Atoi(parts[0])
+		if err != nil || port < 1 || port > 65535 ||
+			!IsValidLoadBalancingPolicy(yawolv1beta1.LoadBalancingPolicy(parts[1])) {
+			return "", nil, fmt.Errorf("%w: %s", ErrInvalidLoadBalancingPolicy, entry)
+		}
+		portPolicies = append(portPolicies, yawolv1beta1.LoadBalancerPortPolicy{
+			Port:   int32(port),
+			Policy: yawolv1beta1.LoadBalancingPolicy(parts[1]),
+		})
+	}
+	return defaultPolicy, portPolicies, nil
+}
+
+// getPortsFilter return port list from annotation
+func getProxyProtocolVersions(value string) (
+	int32,
+	[]yawolv1beta1.LoadBalancerPortProxyProtocolVersion,
+	error,
+) {
+	var defaultVersion int32
+	var portVersions []yawolv1beta1.LoadBalancerPortProxyProtocolVersion
+	for _, entry := range strings.Split(value, ",") {
+		entry = strings.TrimSpace(entry)
+		if !strings.Contains(entry, ":") {
+			version, err := strconv.Atoi(entry)
+			if err != nil || !isValidProxyProtocolVersion(int32(version)) {
+				return 0, nil, fmt.Errorf("%w: %s", ErrInvalidProxyProtocolVersion, entry)
+			}
+			defaultVersion = int32(version)
+			continue
+		}
+
+		parts := strings.SplitN(entry, ":", 2)
+		port, err := strconv.Atoi(parts[0])
+		if err != nil || port < 1 || port > 65535 {
+			return 0, nil, fmt.Errorf("%w: %s", ErrInvalidProxyProtocolVersion, entry)
+		}
+		version, err := strconv.Atoi(parts[1])
+		if err != nil || !isValidProxyProtocolVersion(int32(version)) {
+			return 0, nil, fmt.Errorf("%w: %s", ErrInvalidProxyProtocolVersion, entry)
+		}
+		portVersions = append(portVersions, yawolv1beta1.LoadBalancerPortProxyProtocolVersion{
+			Port:    int32(port),
+			Version: int32(version),
+		})
+	}
+	return defaultVersion, portVersions, nil
+}
+
+func getPortsFilter(portsFilter string) []int32 {
+	if portsFilter == "" {
 		return nil
 	}
 	var portFilter []int32
-	for _, port := range strings.Split(tcpProxyProtocolPortsFilter, ",") {
+	for _, port := range strings.Split(portsFilter, ",") {
 		intPort, err := strconv.Atoi(port) //nolint:gosec // ints are always under int16
 		if err != nil {
 			return nil
@@ -125,3 +457,12 @@ func getTCPProxyProtocolPortsFilter(tcpProxyProtocolPortsFilter string) []int32
 	}
 	return portFilter
 }
+
+func isPortInList(ports []int32, port int32) bool {
+	for _, p := range ports {
+		if p == port {
+			return true
+		}
+	}
+	return false
+}
@@ -2,6 +2,7 @@ package helper
 
 import (
 	"context"
+	"encoding/hex"
 	"encoding/json"
 	"fmt"
 	"net"
@@ -13,6 +14,8 @@ import (
 	"github.com/golang/protobuf/ptypes/duration"
 	"github.com/golang/protobuf/ptypes/wrappers"
 	"google.golang.org/protobuf/types/known/anypb"
+	"google.golang.org/protobuf/types/known/durationpb"
+	"google.golang.org/protobuf/types/known/structpb"
 	corev1 "k8s.io/api/core/v1"
 	rbac "k8s.io/api/rbac/v1"
 	v1 "k8s.io/apimachinery/pkg/apis/meta/v1"
@@ -25,16 +28,20 @@ import (
 	"github.com/stackitcloud/yawol/internal/helper/kubernetes"
 	"github.com/stackitcloud/yawol/internal/hostmetrics"
 	"github.com/stackitcloud/yawol/internal/keepalived"
+	"github.com/stackitcloud/yawol/internal/nftables"
 
 	envoycluster "github.com/envoyproxy/go-control-plane/envoy/config/cluster/v3"
 	envoycore "github.com/envoyproxy/go-control-plane/envoy/config/core/v3"
 	envoyendpoint "github.com/envoyproxy/go-control-plane/envoy/config/endpoint/v3"
 	envoylistener "github.com/envoyproxy/go-control-plane/envoy/config/listener/v3"
 	envoyrbacconfig "github.com/envoyproxy/go-control-plane/envoy/config/rbac/v3"
+	envoylistenerproxyprotocol "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/proxy_protocol/v3"
+	envoytlsinspector "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/listener/tls_inspector/v3"
 	envoyrbac "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/rbac/v3"
 	envoytcp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/network/tcp_proxy/v3"
 	envoyudp "github.com/envoyproxy/go-control-plane/envoy/extensions/filters/udp/udp_proxy/v3"
 	envoyproxyprotocol "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/proxy_protocol/v3"
+	envoytls "github.com/envoyproxy/go-control-plane/envoy/extensions/transport_sockets/tls/v3"
 	envoytypev3 "github.com/envoyproxy/go-control-plane/envoy/type/v3"
 	envoytypes "github.com/envoyproxy/go-control-plane/pkg/cache/types"
 	envoycache "github.com/envoyproxy/go-control-plane/pkg/cache/v3"
@@ -53,17 +60,21 @@ type LoadbalancerMetric string
 
 // Condition status const
 const (
-	ConditionTrue  LoadbalancerConditionStatus = "True"
-	ConditionFalse LoadbalancerConditionStatus = "False"
+	ConditionTrue    LoadbalancerConditionStatus = "True"
+	ConditionFalse   LoadbalancerConditionStatus = "False"
+	ConditionUnknown LoadbalancerConditionStatus = "Unknown"
 )
 
 // Condition name const
 const (
-	ConfigReady         LoadbalancerCondition = "ConfigReady"
-	EnvoyReady          LoadbalancerCondition = "EnvoyReady"
-	EnvoyUpToDate       LoadbalancerCondition = "EnvoyUpToDate"
-	KeepalivedStatsFile LoadbalancerCondition = "KeepalivedStatsFile"
-	KeepalivedMaster    LoadbalancerCondition = "KeepalivedMaster"
+	ConfigReady           LoadbalancerCondition = "ConfigReady"
+	EnvoyReady            LoadbalancerCondition = "EnvoyReady"
+	EnvoyUpToDate         LoadbalancerCondition = "EnvoyUpToDate"
+	KeepalivedStatsFile   LoadbalancerCondition = "KeepalivedStatsFile"
+	KeepalivedMaster      LoadbalancerCondition = "KeepalivedMaster"
+	KeepalivedConfigReady LoadbalancerCondition = "KeepalivedConfigReady"
+	BackendsHealthy       LoadbalancerCondition = "BackendsHealthy"
+	SourceRangesEnforced  LoadbalancerCondition = "SourceRangesEnforced"
 )
 
 // Metric name const
@@ -81,16 +92,26 @@ const (
 	MetricKeepalivedReleasedMaster         LoadbalancerMetric = "keepalivedReleasedMaster "
 	MetricKeepalivedAdvertisementsSent     LoadbalancerMetric = "keepalivedAdvertisementsSent"
 	MetricKeepalivedAdvertisementsReceived LoadbalancerMetric = "keepalivedAdvertisementsReceived"
+	MetricKeepalivedPriority               LoadbalancerMetric = "keepalivedPriority"
+	MetricKeepalivedTransitions            LoadbalancerMetric = "keepalivedTransitions"
+	MetricKeepalivedLastTransition         LoadbalancerMetric = "keepalivedLastTransition"
 )
 
-// Envoy health check parameters
+// Envoy cluster and health check parameters
 const (
-	envoyHealthCheckTimeout            int64  = 5
-	envoyHealthCheckInterval           int64  = 5
-	envoyHealthCheckUnhealthyThreshold uint32 = 3
-	envoyHealthCheckHealthyThreshold   uint32 = 2
+	envoyConnectTimeout                int64  = 5
+	envoyHealthCheckTimeout            int32  = 5
+	envoyHealthCheckInterval           int32  = 5
+	envoyHealthCheckUnhealthyThreshold int32  = 3
+	envoyHealthCheckHealthyThreshold   int32  = 2
+	envoyHealthCheckHTTPPath           string = "/"
+	envoyHealthCheckKubeProxyHTTPPath  string = "/healthz"
+	envoyHealthCheckTransportSocket    string = "healthCheck"
 )
 
+// envoyTLSSecretName is the name of the SDS secret with the certificate for the TLS ports
+const envoyTLSSecretName string = "tls"
+
 // Supported Protocols
 const (
 	protocolTCP string = "TCP"
@@ -106,7 +127,8 @@ func CreateEnvoyConfig(
 	r record.EventRecorder,
 	oldSnapshot *envoycache.Snapshot,
 	lb *yawolv1beta1.LoadBalancer,
-	listen string,
+	tlsSecret *corev1.Secret,
+	listenAddresses []string,
 ) (bool, envoycache.Snapshot, error) {
 	for _, port := range lb.Spec.Ports {
 		if string(port.Protocol) != protocolTCP && string(port.Protocol) != protocolUDP {
@@ -115,11 +137,74 @@ func CreateEnvoyConfig(
 		if port.Port > 65535 || port.Port < 1 {
 			return false, envoycache.Snapshot{}, ErrPortInvalidRange
 		}
-		if port.NodePort > 65535 || port.NodePort < 1 {
+		// pod endpoints use the target ports of the endpoints instead of the NodePort
+		if !lb.Spec.Options.PodEndpoints && (port.NodePort > 65535 || port.NodePort < 1) {
 			return false, envoycache.Snapshot{}, ErrNodePortInvalidRange
 		}
 	}
 
+	for _, tlsPort := range lb.Spec.Options.TLSPorts {
+		for _, port := range lb.Spec.Ports {
+			if port.Port == tlsPort && string(port.Protocol) != protocolTCP {
+				return false, envoycache.Snapshot{}, ErrTLSPortNotTCP
+			}
+		}
+	}
+
+	if err := ValidateHealthCheck(lb.Spec.Options.HealthCheck); err != nil {
+		return false, envoycache.Snapshot{}, err
+	}
+
+	if err := ValidateTimeouts(lb.Spec.Options.Timeouts); err != nil {
+		return false, envoycache.Snapshot{}, err
+	}
+	for i := range lb.Spec.Options.PortTimeouts {
+		if err := ValidateTimeouts(&lb.Spec.Options.PortTimeouts[i].LoadBalancerTimeouts); err != nil {
+			return false, envoycache.Snapshot{}, err
+		}
+	}
+
+	if err := ValidateTCPProxyProtocolVersions(lb.Spec.Options); err != nil {
+		return false, envoycache.Snapshot{}, err
+	}
+
+	if lb.Spec.Options.LoadBalancingPolicy != "" && !IsValidLoadBalancingPolicy(lb.Spec.Options.LoadBalancingPolicy) {
+		return false, envoycache.Snapshot{}, ErrInvalidLoadBalancingPolicy
+	}
+	for _, portPolicy := range lb.Spec.Options.PortLoadBalancingPolicies {
+		if !IsValidLoadBalancingPolicy(portPolicy.Policy) {
+			return false, envoycache.Snapshot{}, ErrInvalidLoadBalancingPolicy
+		}
+	}
+
+	// every server name (or the default route without server names) can only be used once per port
+	sniServerNames := map[string]bool{}
+	for _, route := range lb.Spec.SNIRoutes {
+		port, found := getPortForSNIRoute(lb, route)
+		if !found || tlsEnabled(lb.Spec.Options, port) || lb.Spec.Options.PodEndpoints {
+			return false, envoycache.Snapshot{}, ErrSNIRouteInvalid
+		}
+		if route.NodePort > 65535 || route.NodePort < 1 {
+			return false, envoycache.Snapshot{}, ErrNodePortInvalidRange
+		}
+		serverNames := route.Hostnames
+		if len(serverNames) == 0 {
+			serverNames = []string{""}
+		}
+		for _, serverName := range serverNames {
+			key := fmt.Sprintf("%d/%s", route.Port, serverName)
+			if sniServerNames[key] {
+				return false, envoycache.Snapshot{}, fmt.Errorf("%w: %s used twice", ErrSNIRouteInvalid, key)
+			}
+			sniServerNames[key] = true
+		}
+	}
+
+	secrets, err := createEnvoySecrets(lb, tlsSecret)
+	if err != nil {
+		return false, envoycache.Snapshot{}, err
+	}
+
 	for _, endpoints := range lb.Spec.Endpoints {
 		if endpoints.Addresses == nil {
 			return false, envoycache.Snapshot{}, ErrEndpointAddressesNil
@@ -129,6 +214,11 @@ func CreateEnvoyConfig(
 				return false, envoycache.Snapshot{}, ErrEndpointAddressWrongFormat
 			}
 		}
+		for _, port := range endpoints.Ports {
+			if port.Port > 65535 || port.Port < 1 {
+				return false, envoycache.Snapshot{}, ErrPortInvalidRange
+			}
+		}
 	}
 
 	versionInt, err := strconv.Atoi(oldSnapshot.GetVersion(resource.ListenerType))
@@ -141,13 +231,15 @@ func CreateEnvoyConfig(
 		nil, // endpoints
 		createEnvoyCluster(lb),
 		nil,
-		createEnvoyListener(r, lb, listen),
+		createEnvoyListener(r, lb, listenAddresses),
 		nil, // runtimes
-		nil, // secrets
+		secrets,
 	)
 
+	// a changed certificate only changes the secret, envoy keeps the listeners and their connections
 	if fmt.Sprint(newSnapshot.GetResources(resource.ClusterType)) == fmt.Sprint(oldSnapshot.GetResources(resource.ClusterType)) &&
-		fmt.Sprint(newSnapshot.GetResources(resource.ListenerType)) == fmt.Sprint(oldSnapshot.GetResources(resource.ListenerType)) {
+		fmt.Sprint(newSnapshot.GetResources(resource.ListenerType)) == fmt.Sprint(oldSnapshot.GetResources(resource.ListenerType)) &&
+		fmt.Sprint(newSnapshot.GetResources(resource.SecretType)) == fmt.Sprint(oldSnapshot.GetResources(resource.SecretType)) {
 		// nothing to change
 		return false, envoycache.Snapshot{}, nil
 	}
@@ -155,142 +247,396 @@ func CreateEnvoyConfig(
 	return true, newSnapshot, nil
 }
 
+// createEnvoySecrets returns the SDS secret with the certificate for the TLS ports.
+// Returns no secret if TLS is not used.
+func createEnvoySecrets(lb *yawolv1beta1.LoadBalancer, tlsSecret *corev1.Secret) ([]envoytypes.Resource, error) {
+	if len(lb.Spec.Options.TLSPorts) == 0 {
+		return nil, nil
+	}
+	if tlsSecret == nil ||
+		len(tlsSecret.Data[corev1.TLSCertKey]) == 0 ||
+		len(tlsSecret.Data[corev1.TLSPrivateKeyKey]) == 0 {
+		return nil, ErrTLSSecretInvalid
+	}
+
+	return []envoytypes.Resource{
+		&envoytls.Secret{
+			Name: envoyTLSSecretName,
+			Type: &envoytls.Secret_TlsCertificate{
+				TlsCertificate: &envoytls.TlsCertificate{
+					CertificateChain: &envoycore.DataSource{
+						Specifier: &envoycore.DataSource_InlineBytes{InlineBytes: tlsSecret.Data[corev1.TLSCertKey]},
+					},
+					PrivateKey: &envoycore.DataSource{
+						Specifier: &envoycore.DataSource_InlineBytes{InlineBytes: tlsSecret.Data[corev1.TLSPrivateKeyKey]},
+					},
+				},
+			},
+		},
+	}, nil
+}
+
 func createEnvoyCluster(lb *yawolv1beta1.LoadBalancer) []envoytypes.Resource {
-	clusters := make([]envoytypes.Resource, len(lb.Spec.Ports))
-	for i, port := range lb.Spec.Ports {
-		var protocol envoycore.SocketAddress_Protocol
-		var healthChecks []*envoycore.HealthCheck
-		var transportSocket *envoycore.TransportSocket
-
-		if string(port.Protocol) == protocolTCP {
-			protocol = envoycore.SocketAddress_TCP
-			healthChecks = []*envoycore.HealthCheck{{
-				Timeout:            &duration.Duration{Seconds: envoyHealthCheckTimeout},
-				Interval:           &duration.Duration{Seconds: envoyHealthCheckInterval},
-				UnhealthyThreshold: &wrappers.UInt32Value{Value: envoyHealthCheckUnhealthyThreshold},
-				HealthyThreshold:   &wrappers.UInt32Value{Value: envoyHealthCheckHealthyThreshold},
-				HealthChecker: &envoycore.HealthCheck_TcpHealthCheck_{
-					TcpHealthCheck: &envoycore.HealthCheck_TcpHealthCheck{
-						Send:    nil,
-						Receive: []*envoycore.HealthCheck_Payload{},
-					}},
-			}}
+	clusters := make([]envoytypes.Resource, 0, len(lb.Spec.Ports)+len(lb.Spec.SNIRoutes))
+	for _, port := range lb.Spec.Ports {
+		clusters = append(clusters, createEnvoyClusterForPort(lb, port, getEnvoyClusterName(port)))
+	}
+
+	// SNI routes to other NodePorts than the one of the port get an own cluster
+	sniClusters := map[string]bool{}
+	for _, route := range lb.Spec.SNIRoutes {
+		port, found := getPortForSNIRoute(lb, route)
+		if !found {
+			continue
+		}
+		name := getEnvoySNIClusterName(port, route)
+		if name == getEnvoyClusterName(port) || sniClusters[name] {
+			continue
+		}
+		sniClusters[name] = true
+		port.NodePort = route.NodePort
+		clusters = append(clusters, createEnvoyClusterForPort(lb, port, name))
+	}
+
+	return clusters
+}
+
+// createEnvoyClusterForPort returns the cluster with the endpoints of the port
+func createEnvoyClusterForPort(
+	lb *yawolv1beta1.LoadBalancer,
+	port corev1.ServicePort,
+	name string,
+) *envoycluster.Cluster {
+	var protocol envoycore.SocketAddress_Protocol
+	var healthChecks []*envoycore.HealthCheck
+	var healthCheckConfig *envoyendpoint.Endpoint_HealthCheckConfig
+	var transportSocket *envoycore.TransportSocket
+	var transportSocketMatches []*envoycluster.Cluster_TransportSocketMatch
 
-			if proxyProtocolEnabled(lb.Spec.Options, port) {
-				if config, err := anypb.New(&envoyproxyprotocol.ProxyProtocolUpstreamTransport{
-					Config: &envoycore.ProxyProtocolConfig{Version: 2},
+	if string(port.Protocol) == protocolTCP {
+		protocol = envoycore.SocketAddress_TCP
+		healthChecks = []*envoycore.HealthCheck{createEnvoyHealthCheck(lb)}
+
+		if kubeProxyHealthCheckEnabled(lb.Spec.Options) {
+			healthCheckConfig = &envoyendpoint.Endpoint_HealthCheckConfig{
+				PortValue: uint32(lb.Spec.Options.HealthCheckNodePort),
+			}
+		}
+
+		if proxyProtocolEnabled(lb.Spec.Options, port) {
+			if config, err := anypb.New(&envoyproxyprotocol.ProxyProtocolUpstreamTransport{
+				Config: &envoycore.ProxyProtocolConfig{Version: getEnvoyProxyProtocolVersion(lb.Spec.Options, port)},
+				TransportSocket: &envoycore.TransportSocket{
+					Name: envoywellknown.TransportSocketRawBuffer,
+				},
+			}); err == nil {
+				transportSocket = &envoycore.TransportSocket{
+					// TODO constant is not in envoy.wellknown
+					Name: "envoy.transport_sockets.upstream_proxy_protocol",
+					ConfigType: &envoycore.TransportSocket_TypedConfig{
+						TypedConfig: config,
+					},
+				}
+			}
+
+			// kube-proxy does not understand the proxy protocol, the HTTP health check uses a raw socket
+			if healthCheckConfig != nil {
+				transportSocketMatches = []*envoycluster.Cluster_TransportSocketMatch{{
+					Name:  envoyHealthCheckTransportSocket,
+					Match: envoyHealthCheckTransportSocketMatch(),
 					TransportSocket: &envoycore.TransportSocket{
 						Name: envoywellknown.TransportSocketRawBuffer,
 					},
-				}); err == nil {
-					transportSocket = &envoycore.TransportSocket{
-						// TODO constant is not in envoy.wellknown
-						Name: "envoy.transport_sockets.upstream_proxy_protocol",
-						ConfigType: &envoycore.TransportSocket_TypedConfig{
-							TypedConfig: config,
-						},
-					}
-				}
+				}}
+			}
+		}
+	} else if string(port.Protocol) == protocolUDP {
+		protocol = envoycore.SocketAddress_UDP
+
+		// UDP can not be checked directly, the kube-proxy health check or a separate TCP port is checked instead
+		if kubeProxyHealthCheckEnabled(lb.Spec.Options) {
+			healthChecks = []*envoycore.HealthCheck{createEnvoyHealthCheck(lb)}
+			healthCheckConfig = &envoyendpoint.Endpoint_HealthCheckConfig{
+				PortValue: uint32(lb.Spec.Options.HealthCheckNodePort),
+			}
+		} else if healthCheck := lb.Spec.Options.HealthCheck; healthCheck != nil && healthCheck.UDPPort > 0 {
+			healthChecks = []*envoycore.HealthCheck{createEnvoyHealthCheck(lb)}
+			healthCheckConfig = &envoyendpoint.Endpoint_HealthCheckConfig{
+				PortValue: uint32(healthCheck.UDPPort),
 			}
-		} else if string(port.Protocol) == protocolUDP {
-			protocol = envoycore.SocketAddress_UDP
-			// health checks are only implemented for TCP
-		}
-
-		endpoints := make([]*envoyendpoint.LocalityLbEndpoints, len(lb.Spec.Endpoints))
-		for iEndpoint, endpointSpec := range lb.Spec.Endpoints {
-			addressEndpoints := make([]*envoyendpoint.LbEndpoint, len(endpointSpec.Addresses))
-			for iAddresses, address := range endpointSpec.Addresses {
-				addressEndpoints[iAddresses] = &envoyendpoint.LbEndpoint{
-					HostIdentifier: &envoyendpoint.LbEndpoint_Endpoint{
-						Endpoint: &envoyendpoint.Endpoint{
-							Address: &envoycore.Address{
-								Address: &envoycore.Address_SocketAddress{
-									SocketAddress: &envoycore.SocketAddress{
-										Protocol: protocol,
-										Address:  address,
-										PortSpecifier: &envoycore.SocketAddress_PortValue{
-											PortValue: uint32(port.NodePort),
-										},
+		}
+	}
+
+	endpoints := make([]*envoyendpoint.LocalityLbEndpoints, 0, len(lb.Spec.Endpoints))
+	for _, endpointSpec := range lb.Spec.Endpoints {
+		endpointPort, ok := getEnvoyEndpointPort(lb, endpointSpec, port)
+		if !ok {
+			continue
+		}
+
+		addressEndpoints := make([]*envoyendpoint.LbEndpoint, len(endpointSpec.Addresses))
+		for iAddresses, address := range endpointSpec.Addresses {
+			addressEndpoints[iAddresses] = &envoyendpoint.LbEndpoint{
+				HostIdentifier: &envoyendpoint.LbEndpoint_Endpoint{
+					Endpoint: &envoyendpoint.Endpoint{
+						Address: &envoycore.Address{
+							Address: &envoycore.Address_SocketAddress{
+								SocketAddress: &envoycore.SocketAddress{
+									Protocol: protocol,
+									Address:  address,
+									PortSpecifier: &envoycore.SocketAddress_PortValue{
+										PortValue: endpointPort,
 									},
 								},
 							},
 						},
+						HealthCheckConfig: healthCheckConfig,
 					},
-				}
-			}
-
-			endpoints[iEndpoint] = &envoyendpoint.LocalityLbEndpoints{
-				LbEndpoints: addressEndpoints,
+				},
 			}
 		}
-		clusterPort := &envoycluster.Cluster{
-			Name:                 fmt.Sprintf("%v-%v", port.Protocol, port.Port),
-			ConnectTimeout:       &duration.Duration{Seconds: 5},
-			ClusterDiscoveryType: &envoycluster.Cluster_Type{Type: envoycluster.Cluster_STATIC},
-			CommonLbConfig: &envoycluster.Cluster_CommonLbConfig{
-				HealthyPanicThreshold: &envoytypev3.Percent{Value: 0},
+
+		endpoints = append(endpoints, &envoyendpoint.LocalityLbEndpoints{
+			LbEndpoints: addressEndpoints,
+		})
+	}
+	return &envoycluster.Cluster{
+		Name:                 name,
+		ConnectTimeout:       getEnvoyConnectTimeout(lb, port),
+		ClusterDiscoveryType: &envoycluster.Cluster_Type{Type: envoycluster.Cluster_STATIC},
+		CommonLbConfig: &envoycluster.Cluster_CommonLbConfig{
+			HealthyPanicThreshold: &envoytypev3.Percent{Value: 0},
+		},
+		LbPolicy: getEnvoyLbPolicy(GetLoadBalancingPolicy(lb.Spec.Options, port.Port)),
+		LoadAssignment: &envoyendpoint.ClusterLoadAssignment{
+			ClusterName: name,
+			Endpoints:   endpoints,
+		},
+		DnsLookupFamily:               envoycluster.Cluster_V4_ONLY,
+		HealthChecks:                  healthChecks,
+		TransportSocket:               transportSocket,
+		TransportSocketMatches:        transportSocketMatches,
+		PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 32768}, // 32Kib
+		CircuitBreakers: &envoycluster.CircuitBreakers{
+			Thresholds: []*envoycluster.CircuitBreakers_Thresholds{
+				{
+					Priority:           envoycore.RoutingPriority_DEFAULT,
+					MaxConnections:     &wrappers.UInt32Value{Value: 10000 * uint32(hostmetrics.GetCPUNum())},
+					MaxRequests:        &wrappers.UInt32Value{Value: 8000 * uint32(hostmetrics.GetCPUNum())},
+					MaxPendingRequests: &wrappers.UInt32Value{Value: 2000 * uint32(hostmetrics.GetCPUNum())},
+				},
 			},
-			LbPolicy: envoycluster.Cluster_ROUND_ROBIN,
-			LoadAssignment: &envoyendpoint.ClusterLoadAssignment{
-				ClusterName: fmt.Sprintf("%v-%v", port.Protocol, port.Port),
-				Endpoints:   endpoints,
+		},
+	}
+}
+
+// getEnvoyConnectTimeout returns the connect timeout of the port, defaults to 5s
+func getEnvoyConnectTimeout(lb *yawolv1beta1.LoadBalancer, port corev1.ServicePort) *duration.Duration {
+	if timeout := GetTimeouts(lb.Spec.Options, port.Port).ConnectTimeout; timeout != nil {
+		return durationpb.New(timeout.Duration)
+	}
+	return &duration.Duration{Seconds: envoyConnectTimeout}
+}
+
+// getEnvoyLbPolicy returns the envoy cluster policy for the load balancing policy
+func getEnvoyLbPolicy(policy yawolv1beta1.LoadBalancingPolicy) envoycluster.Cluster_LbPolicy {
+	switch policy {
+	case yawolv1beta1.LoadBalancingPolicyLeastRequest:
+		return envoycluster.Cluster_LEAST_REQUEST
+	case yawolv1beta1.LoadBalancingPolicyRingHash:
+		return envoycluster.Cluster_RING_HASH
+	case yawolv1beta1.LoadBalancingPolicyMaglev:
+		return envoycluster.Cluster_MAGLEV
+	default:
+		return envoycluster.Cluster_ROUND_ROBIN
+	}
+}
+
+func isHashLoadBalancingPolicy(policy yawolv1beta1.LoadBalancingPolicy) bool {
+	return policy == yawolv1beta1.LoadBalancingPolicyRingHash || policy == yawolv1beta1.LoadBalancingPolicyMaglev
+}
+
+// getEnvoyClusterName returns the name of the cluster of a port
+func getEnvoyClusterName(port corev1.ServicePort) string {
+	return fmt.Sprintf("%v-%v", port.Protocol, port.Port)
+}
+
+// getEnvoySNIClusterName returns the name of the cluster of a SNI route,
+// routes to the NodePort of the port use the cluster of the port
+func getEnvoySNIClusterName(port corev1.ServicePort, route yawolv1beta1.LoadBalancerSNIRoute) string {
+	if route.NodePort == port.NodePort {
+		return getEnvoyClusterName(port)
+	}
+	return fmt.Sprintf("%v-%v-%v", port.Protocol, port.Port, route.NodePort)
+}
+
+// getPortForSNIRoute returns the TCP port of the LoadBalancer for the SNI route
+func getPortForSNIRoute(lb *yawolv1beta1.LoadBalancer, route yawolv1beta1.LoadBalancerSNIRoute) (corev1.ServicePort, bool) {
+	for _, port := range lb.Spec.Ports {
+		if port.Port == route.Port && string(port.Protocol) == protocolTCP {
+			return port, true
+		}
+	}
+	return corev1.ServicePort{}, false
+}
+
+// createEnvoyHealthCheck returns a TCP health check for the node ports.
+// If a HealthCheckNodePort is set (externalTrafficPolicy Local) the kube-proxy /healthz endpoint
+// is checked instead, which is only healthy on nodes with local endpoints for the service.
+func createEnvoyHealthCheck(lb *yawolv1beta1.LoadBalancer) *envoycore.HealthCheck {
+	settings := getHealthCheckSettings(lb.Spec.Options)
+	healthCheck := &envoycore.HealthCheck{
+		Timeout:            &duration.Duration{Seconds: int64(settings.TimeoutSeconds)},
+		Interval:           &duration.Duration{Seconds: int64(settings.IntervalSeconds)},
+		UnhealthyThreshold: &wrappers.UInt32Value{Value: uint32(settings.UnhealthyThreshold)},
+		HealthyThreshold:   &wrappers.UInt32Value{Value: uint32(settings.HealthyThreshold)},
+	}
+
+	switch {
+	case kubeProxyHealthCheckEnabled(lb.Spec.Options):
+		healthCheck.HealthChecker = &envoycore.HealthCheck_HttpHealthCheck_{
+			HttpHealthCheck: &envoycore.HealthCheck_HttpHealthCheck{
+				Path: envoyHealthCheckKubeProxyHTTPPath,
 			},
-			DnsLookupFamily:               envoycluster.Cluster_V4_ONLY,
-			HealthChecks:                  healthChecks,
-			TransportSocket:               transportSocket,
-			PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 32768}, // 32Kib
-			CircuitBreakers: &envoycluster.CircuitBreakers{
-				Thresholds: []*envoycluster.CircuitBreakers_Thresholds{
-					{
-						Priority:           envoycore.RoutingPriority_DEFAULT,
-						MaxConnections:     &wrappers.UInt32Value{Value: 10000 * uint32(hostmetrics.GetCPUNum())},
-						MaxRequests:        &wrappers.UInt32Value{Value: 8000 * uint32(hostmetrics.GetCPUNum())},
-						MaxPendingRequests: &wrappers.UInt32Value{Value: 2000 * uint32(hostmetrics.GetCPUNum())},
-					},
-				},
+		}
+		healthCheck.TransportSocketMatchCriteria = envoyHealthCheckTransportSocketMatch()
+	case settings.Type == yawolv1beta1.HealthCheckTypeHTTP:
+		expectedStatuses := make([]*envoytypev3.Int64Range, 0, len(settings.HTTPExpectedStatuses))
+		for _, status := range settings.HTTPExpectedStatuses {
+			// the end of the range is exclusive
+			expectedStatuses = append(expectedStatuses, &envoytypev3.Int64Range{Start: int64(status), End: int64(status) + 1})
+		}
+		healthCheck.HealthChecker = &envoycore.HealthCheck_HttpHealthCheck_{
+			HttpHealthCheck: &envoycore.HealthCheck_HttpHealthCheck{
+				Path:             settings.HTTPPath,
+				ExpectedStatuses: expectedStatuses,
 			},
 		}
-		clusters[i] = clusterPort
+	default:
+		// payloads are hex encoded
+		var send *envoycore.HealthCheck_Payload
+		if settings.TCPSend != "" {
+			send = &envoycore.HealthCheck_Payload{
+				Payload: &envoycore.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(settings.TCPSend))},
+			}
+		}
+		receive := []*envoycore.HealthCheck_Payload{}
+		if settings.TCPReceive != "" {
+			receive = append(receive, &envoycore.HealthCheck_Payload{
+				Payload: &envoycore.HealthCheck_Payload_Text{Text: hex.EncodeToString([]byte(settings.TCPReceive))},
+			})
+		}
+		healthCheck.HealthChecker = &envoycore.HealthCheck_TcpHealthCheck_{
+			TcpHealthCheck: &envoycore.HealthCheck_TcpHealthCheck{
+				Send:    send,
+				Receive: receive,
+			}}
 	}
 
-	return clusters
+	return healthCheck
+}
+
+// getHealthCheckSettings returns the health check settings of the options with defaults for unset values
+func getHealthCheckSettings(options yawolv1beta1.LoadBalancerOptions) yawolv1beta1.LoadBalancerHealthCheck {
+	settings := yawolv1beta1.LoadBalancerHealthCheck{}
+	if options.HealthCheck != nil {
+		settings = *options.HealthCheck.DeepCopy()
+	}
+	if settings.Type == "" {
+		settings.Type = yawolv1beta1.HealthCheckTypeTCP
+	}
+	if settings.TimeoutSeconds == 0 {
+		settings.TimeoutSeconds = envoyHealthCheckTimeout
+	}
+	if settings.IntervalSeconds == 0 {
+		settings.IntervalSeconds = envoyHealthCheckInterval
+	}
+	if settings.UnhealthyThreshold == 0 {
+		settings.UnhealthyThreshold = envoyHealthCheckUnhealthyThreshold
+	}
+	if settings.HealthyThreshold == 0 {
+		settings.HealthyThreshold = envoyHealthCheckHealthyThreshold
+	}
+	if settings.HTTPPath == "" {
+		settings.HTTPPath = envoyHealthCheckHTTPPath
+	}
+	if len(settings.HTTPExpectedStatuses) == 0 {
+		settings.HTTPExpectedStatuses = []int32{200}
+	}
+	return settings
+}
+
+// kubeProxyHealthCheckEnabled returns true if the nodes are checked with the kube-proxy health check.
+// Pod endpoints are checked directly on their target ports.
+func kubeProxyHealthCheckEnabled(options yawolv1beta1.LoadBalancerOptions) bool {
+	return options.HealthCheckNodePort > 0 && !options.PodEndpoints
+}
+
+// getEnvoyEndpointPort returns the port of the endpoint for a port of the LoadBalancer.
+// Pod endpoints use the target port with the same name and protocol, node endpoints the NodePort.
+func getEnvoyEndpointPort(
+	lb *yawolv1beta1.LoadBalancer,
+	endpoint yawolv1beta1.LoadBalancerEndpoint,
+	port corev1.ServicePort,
+) (uint32, bool) {
+	if !lb.Spec.Options.PodEndpoints {
+		return uint32(port.NodePort), true
+	}
+	for _, endpointPort := range endpoint.Ports {
+		if endpointPort.Name == port.Name && endpointPort.Protocol == port.Protocol {
+			return uint32(endpointPort.Port), true
+		}
+	}
+	return 0, false
+}
+
+// envoyHealthCheckTransportSocketMatch returns the match for the transport socket of HTTP health checks
+func envoyHealthCheckTransportSocketMatch() *structpb.Struct {
+	return &structpb.Struct{
+		Fields: map[string]*structpb.Value{
+			envoyHealthCheckTransportSocket: structpb.NewBoolValue(true),
+		},
+	}
 }
 
 // createEnvoyListener create envoylistener for envoy snapshot
+// with a listener per port and listen address (one per ip family)
 func createEnvoyListener(
 	r record.EventRecorder,
 	lb *yawolv1beta1.LoadBalancer,
-	listenAddress string,
+	listenAddresses []string,
 ) []envoytypes.Resource {
-	listeners := make([]envoytypes.Resource, len(lb.Spec.Ports))
-	for i, port := range lb.Spec.Ports {
-		// unsupported protocol is already checked earlier
-		if string(port.Protocol) == protocolTCP {
-			listeners[i] = createEnvoyTCPListener(r, lb, listenAddress, port)
-		} else if string(port.Protocol) == protocolUDP {
-			listeners[i] = createEnvoyUDPListener(listenAddress, port)
+	listeners := make([]envoytypes.Resource, 0, len(lb.Spec.Ports)*len(listenAddresses))
+	for _, listenAddress := range listenAddresses {
+		for _, port := range lb.Spec.Ports {
+			// unsupported protocol is already checked earlier
+			if string(port.Protocol) == protocolTCP {
+				listeners = append(listeners, createEnvoyTCPListener(r, lb, listenAddress, port))
+			} else if string(port.Protocol) == protocolUDP {
+				listeners = append(listeners, createEnvoyUDPListener(lb, listenAddress, port))
+			}
 		}
 	}
 
 	return listeners
 }
 
+// getEnvoyListenerName returns the listener name for a port,
+// listeners on an IPv6 address get an additional suffix.
+func getEnvoyListenerName(listenAddress string, port corev1.ServicePort) string {
+	name := fmt.Sprintf("%v-%v", port.Protocol, port.Port)
+	if ip := net.ParseIP(listenAddress); ip != nil && ip.To4() == nil {
+		name += "-IPv6"
+	}
+	return name
+}
+
 func createEnvoyTCPListener(
 	r record.EventRecorder,
 	lb *yawolv1beta1.LoadBalancer,
 	listenAddress string,
 	port corev1.ServicePort,
 ) *envoylistener.Listener {
-	listenPort, err := anypb.New(&envoytcp.TcpProxy{
-		StatPrefix:       "envoytcp",
-		ClusterSpecifier: &envoytcp.TcpProxy_Cluster{Cluster: fmt.Sprintf("%v-%v", port.Protocol, port.Port)},
-	})
-
-	if err != nil {
-		panic(err)
-	}
-
 	filters := []*envoylistener.Filter{}
 
 	// ip whitelisting via RBAC according to loadBalancerSourceRanges
@@ -306,8 +652,73 @@ func createEnvoyTCPListener(
 		}
 	}
 
+	// terminate TLS with the certificate from SDS, so a new certificate does not change the listener
+	var transportSocket *envoycore.TransportSocket
+	if tlsEnabled(lb.Spec.Options, port) {
+		tlsContext, err := anypb.New(&envoytls.DownstreamTlsContext{
+			CommonTlsContext: &envoytls.CommonTlsContext{
+				TlsCertificateSdsSecretConfigs: []*envoytls.SdsSecretConfig{{
+					Name: envoyTLSSecretName,
+					SdsConfig: &envoycore.ConfigSource{
+						ConfigSourceSpecifier: &envoycore.ConfigSource_Ads{Ads: &envoycore.AggregatedConfigSource{}},
+						ResourceApiVersion:    envoycore.ApiVersion_V3,
+					},
+				}},
+			},
+		})
+		if err != nil {
+			panic(err)
+		}
+		transportSocket = &envoycore.TransportSocket{
+			Name: envoywellknown.TransportSocketTls,
+			ConfigType: &envoycore.TransportSocket_TypedConfig{
+				TypedConfig: tlsContext,
+			},
+		}
+	}
+
+	filterChains := []*envoylistener.FilterChain{
+		createEnvoyTCPFilterChain(lb, port, filters, getEnvoyClusterName(port), nil, transportSocket),
+	}
+	var listenerFilters []*envoylistener.ListenerFilter
+
+	// route by the server name of the TLS client hello without terminating TLS,
+	// connections without a matching filter chain are closed
+	if routes := getSNIRoutesForPort(lb, port); len(routes) > 0 {
+		filterChains = make([]*envoylistener.FilterChain, 0, len(routes))
+		for _, route := range routes {
+			filterChains = append(filterChains,
+				createEnvoyTCPFilterChain(lb, port, filters, getEnvoySNIClusterName(port, route), route.Hostnames, nil))
+		}
+
+		tlsInspector, err := anypb.New(&envoytlsinspector.TlsInspector{})
+		if err != nil {
+			panic(err)
+		}
+		listenerFilters = append(listenerFilters, &envoylistener.ListenerFilter{
+			Name: envoywellknown.TlsInspector,
+			ConfigType: &envoylistener.ListenerFilter_TypedConfig{
+				TypedConfig: tlsInspector,
+			},
+		})
+	}
+
+	// the proxy protocol header is read before the TLS client hello
+	if downstreamProxyProtocolEnabled(lb.Spec.Options, port) {
+		proxyProtocol, err := anypb.New(&envoylistenerproxyprotocol.ProxyProtocol{})
+		if err != nil {
+			panic(err)
+		}
+		listenerFilters = append([]*envoylistener.ListenerFilter{{
+			Name: envoywellknown.ProxyProtocol,
+			ConfigType: &envoylistener.ListenerFilter_TypedConfig{
+				TypedConfig: proxyProtocol,
+			},
+		}}, listenerFilters...)
+	}
+
 	return &envoylistener.Listener{
-		Name: fmt.Sprintf("%v-%v", port.Protocol, port.Port),
+		Name: getEnvoyListenerName(listenAddress, port),
 		Address: &envoycore.Address{
 			Address: &envoycore.Address_SocketAddress{
 				SocketAddress: &envoycore.SocketAddress{
@@ -319,38 +730,113 @@ func createEnvoyTCPListener(
 				},
 			},
 		},
-		FilterChains: []*envoylistener.FilterChain{{
-			// proxy filter has to be the last in the chain
-			Filters: append(filters, &envoylistener.Filter{
-				Name: envoywellknown.TCPProxy,
-				ConfigType: &envoylistener.Filter_TypedConfig{
-					TypedConfig: listenPort,
-				},
-			}),
-		}},
+		ListenerFilters:               listenerFilters,
+		FilterChains:                  filterChains,
 		Freebind:                      &wrappers.BoolValue{Value: true},
 		ReusePort:                     true,
 		PerConnectionBufferLimitBytes: &wrappers.UInt32Value{Value: 32768}, // 32 Kib
 	}
 }
 
+// createEnvoyTCPFilterChain returns a filter chain that proxies to the cluster,
+// it only matches the server names if they are set
+func createEnvoyTCPFilterChain(
+	lb *yawolv1beta1.LoadBalancer,
+	port corev1.ServicePort,
+	filters []*envoylistener.Filter,
+	clusterName string,
+	serverNames []string,
+	transportSocket *envoycore.TransportSocket,
+) *envoylistener.FilterChain {
+	// hash based policies need the source ip as hash key, otherwise a random endpoint is selected
+	var hashPolicy []*envoytypev3.HashPolicy
+	if isHashLoadBalancingPolicy(GetLoadBalancingPolicy(lb.Spec.Options, port.Port)) {
+		hashPolicy = []*envoytypev3.HashPolicy{{
+			PolicySpecifier: &envoytypev3.HashPolicy_SourceIp_{SourceIp: &envoytypev3.HashPolicy_SourceIp{}},
+		}}
+	}
+
+	var idleTimeout *duration.Duration
+	if timeout := GetTimeouts(lb.Spec.Options, port.Port).TCPIdleTimeout; timeout != nil {
+		idleTimeout = durationpb.New(timeout.Duration)
+	}
+
+	tcpProxy, err := anypb.New(&envoytcp.TcpProxy{
+		StatPrefix:       "envoytcp",
+		ClusterSpecifier: &envoytcp.TcpProxy_Cluster{Cluster: clusterName},
+		HashPolicy:       hashPolicy,
+		IdleTimeout:      idleTimeout,
+	})
+	if err != nil {
+		panic(err)
+	}
+
+	var filterChainMatch *envoylistener.FilterChainMatch
+	if len(serverNames) > 0 {
+		filterChainMatch = &envoylistener.FilterChainMatch{ServerNames: serverNames}
+	}
+
+	chainFilters := make([]*envoylistener.Filter, 0, len(filters)+1)
+	chainFilters = append(chainFilters, filters...)
+	return &envoylistener.FilterChain{
+		FilterChainMatch: filterChainMatch,
+		// proxy filter has to be the last in the chain
+		Filters: append(chainFilters, &envoylistener.Filter{
+			Name: envoywellknown.TCPProxy,
+			ConfigType: &envoylistener.Filter_TypedConfig{
+				TypedConfig: tcpProxy,
+			},
+		}),
+		TransportSocket: transportSocket,
+	}
+}
+
+// getSNIRoutesForPort returns the SNI routes of a TCP port
+func getSNIRoutesForPort(lb *yawolv1beta1.LoadBalancer, port corev1.ServicePort) []yawolv1beta1.LoadBalancerSNIRoute {
+	if string(port.Protocol) != protocolTCP {
+		return nil
+	}
+	var routes []yawolv1beta1.LoadBalancerSNIRoute
+	for _, route := range lb.Spec.SNIRoutes {
+		if route.Port == port.Port {
+			routes = append(routes, route)
+		}
+	}
+	return routes
+}
+
 func createEnvoyUDPListener(
+	lb *yawolv1beta1.LoadBalancer,
 	listenAddress string,
 	port corev1.ServicePort,
 ) *envoylistener.Listener {
+	var hashPolicies []*envoyudp.UdpProxyConfig_HashPolicy
+	if isHashLoadBalancingPolicy(GetLoadBalancingPolicy(lb.Spec.Options, port.Port)) {
+		hashPolicies = []*envoyudp.UdpProxyConfig_HashPolicy{{
+			PolicySpecifier: &envoyudp.UdpProxyConfig_HashPolicy_SourceIp{SourceIp: true},
+		}}
+	}
+
+	var idleTimeout *duration.Duration
+	if timeout := GetTimeouts(lb.Spec.Options, port.Port).UDPIdleTimeout; timeout != nil {
+		idleTimeout = durationpb.New(timeout.Duration)
+	}
+
 	listenPort, err := anypb.New(&envoyudp.UdpProxyConfig{
 		StatPrefix:     "envoyudp",
 		RouteSpecifier: &envoyudp.UdpProxyConfig_Cluster{Cluster: fmt.Sprintf("%v-%v", port.Protocol, port.Port)},
+		HashPolicies:   hashPolicies,
+		IdleTimeout:    idleTimeout,
 	})
 	if err != nil {
 		panic(err)
 	}
 
-	// TODO UDP only supports a single filter at the moment
+	// UDP only supports a single filter at the moment
 	// ref: https://www.envoyproxy.io/docs/envoy/latest/api-v3/config/listener/v3/listener.proto -> listener_filters
-	// -> load-balancer-source-ranges are only guaranteed via OpenStack
+	// -> load-balancer-source-ranges are enforced with nftables by UpdateSourceRangesStatus
 	return &envoylistener.Listener{
-		Name: fmt.Sprintf("%v-%v", port.Protocol, port.Port),
+		Name: getEnvoyListenerName(listenAddress, port),
 		Address: &envoycore.Address{
 			Address: &envoycore.Address_SocketAddress{
 				SocketAddress: &envoycore.SocketAddress{
@@ -381,7 +867,7 @@ func GetRoleRules(
 	loadBalancer *yawolv1beta1.LoadBalancer,
 	loadBalancerMachine *yawolv1beta1.LoadBalancerMachine,
 ) []rbac.PolicyRule {
-	return []rbac.PolicyRule{{
+	rules := []rbac.PolicyRule{{
 		Verbs:     []string{"create"},
 		APIGroups: []string{""},
 		Resources: []string{"events"},
@@ -408,7 +894,24 @@ func GetRoleRules(
 		APIGroups:     []string{"yawol.stackit.cloud"},
 		Resources:     []string{"loadbalancermachines/status"},
 		ResourceNames: []string{loadBalancerMachine.Name},
+	}, {
+		Verbs:         []string{"get"},
+		APIGroups:     []string{""},
+		Resources:     []string{"secrets"},
+		ResourceNames: []string{GetTLSSecretName(loadBalancer)},
 	}}
+
+	// the yawollet writes the VRRP password to the keepalived config and watches the secret
+	// with a metadata.name field selector, which is required to list and watch with resource names
+	if loadBalancerMachine.Spec.KeepalivedSecretRef != nil {
+		rules = append(rules, rbac.PolicyRule{
+			Verbs:         []string{"get", "list", "watch"},
+			APIGroups:     []string{""},
+			Resources:     []string{"secrets"},
+			ResourceNames: []string{loadBalancerMachine.Spec.KeepalivedSecretRef.Name},
+		})
+	}
+	return rules
 }
 
 func createEnvoyRBACRules(
@@ -489,6 +992,31 @@ func proxyProtocolEnabled(options yawolv1beta1.LoadBalancerOptions, port corev1.
 	return false
 }
 
+// getEnvoyProxyProtocolVersion returns the envoy version of the HAProxy TCP Proxy Protocol for the given port
+func getEnvoyProxyProtocolVersion(
+	options yawolv1beta1.LoadBalancerOptions,
+	port corev1.ServicePort,
+) envoycore.ProxyProtocolConfig_Version {
+	if GetTCPProxyProtocolVersion(options, port.Port) == 1 {
+		return envoycore.ProxyProtocolConfig_V1
+	}
+	return envoycore.ProxyProtocolConfig_V2
+}
+
+// downstreamProxyProtocolEnabled returns true if the HAProxy TCP Proxy Protocol is accepted on the given port
+func downstreamProxyProtocolEnabled(options yawolv1beta1.LoadBalancerOptions, port corev1.ServicePort) bool {
+	if !options.DownstreamProxyProtocol || string(port.Protocol) != protocolTCP {
+		return false
+	}
+	return len(options.DownstreamProxyProtocolPortsFilter) == 0 ||
+		isPortInList(options.DownstreamProxyProtocolPortsFilter, port.Port)
+}
+
+// tlsEnabled returns true if TLS should be terminated for the given port
+func tlsEnabled(options yawolv1beta1.LoadBalancerOptions, port corev1.ServicePort) bool {
+	return string(port.Protocol) == protocolTCP && isPortInList(options.TLSPorts, port.Port)
+}
+
 // UpdateLBMConditions update a given condition in lbm object
 func UpdateLBMConditions(
 	ctx context.Context,
@@ -537,6 +1065,7 @@ func WriteLBMMetrics(
 	ctx context.Context,
 	c client.StatusWriter,
 	keepalivedStatsFile string,
+	notifications *keepalived.Notifications,
 	lbm *yawolv1beta1.LoadBalancerMachine,
 ) error {
 	metrics := []yawolv1beta1.LoadBalancerMachineMetric{}
@@ -593,18 +1122,35 @@ func WriteLBMMetrics(
 		metrics = append(metrics, envoyMetrics...)
 	}
 
-	if keepalivedStatsFile != "" {
-		if keepalivedStats, _, err := keepalived.ReadStatsForInstanceName(VRRPInstanceName, keepalivedStatsFile); err == nil {
-			masterInt := "0"
-			if keepalivedStats.IsMaster() {
-				masterInt = "1"
-			}
+	var notification *keepalived.Notification
+	if notifications != nil {
+		if lastNotification, transitions, ok := notifications.Last(VRRPInstanceName); ok {
+			notification = &lastNotification
 			metrics = append(metrics, []yawolv1beta1.LoadBalancerMachineMetric{
 				{
-					Type:  string(MetricKeepalivedIsMaster),
-					Value: masterInt,
+					Type:  string(MetricKeepalivedPriority),
+					Value: strconv.Itoa(notification.Priority),
+					Time:  v1.Now(),
+				}, {
+					Type:  string(MetricKeepalivedTransitions),
+					Value: strconv.Itoa(transitions),
 					Time:  v1.Now(),
 				}, {
+					Type:  string(MetricKeepalivedLastTransition),
+					Value: strconv.FormatInt(notification.Time.Unix(), 10),
+					Time:  v1.Now(),
+				}}...)
+		}
+	}
+
+	var keepalivedStats keepalived.VRRPInstance
+	var modTime time.Time
+	var statsErr error
+	if keepalivedStatsFile != "" {
+		keepalivedStats, modTime, statsErr = keepalived.ReadStatsForInstanceName(VRRPInstanceName, keepalivedStatsFile)
+		if statsErr == nil {
+			metrics = append(metrics, []yawolv1beta1.LoadBalancerMachineMetric{
+				{
 					Type:  string(MetricKeepalivedReleasedMaster),
 					Value: strconv.Itoa(keepalivedStats.ReleasedMaster),
 					Time:  v1.Now(),
@@ -624,6 +1170,20 @@ func WriteLBMMetrics(
 		}
 	}
 
+	if keepalivedIsMaster, _, _ := getKeepalivedMasterStatus(
+		notification, keepalivedStatsFile, keepalivedStats, modTime, statsErr,
+	); keepalivedIsMaster != "" {
+		masterInt := "0"
+		if keepalivedIsMaster == ConditionTrue {
+			masterInt = "1"
+		}
+		metrics = append(metrics, yawolv1beta1.LoadBalancerMachineMetric{
+			Type:  string(MetricKeepalivedIsMaster),
+			Value: masterInt,
+			Time:  v1.Now(),
+		})
+	}
+
 	return updateLBMMetrics(ctx, c, lbm, metrics)
 }
 
@@ -678,52 +1238,405 @@ func UpdateEnvoyStatus(
 	return UpdateLBMConditions(ctx, c, lbm, EnvoyReady, ConditionFalse, "EnvoyNotReady", "envoy response not with 200")
 }
 
-func UpdateKeepalivedStatus(
+// UpdateSourceRangesStatus enforces the source ranges of the UDP ports with nftables and reports
+// the enforcement paths in the SourceRangesEnforced condition. The OpenStack security group is always
+// used, TCP ports are also filtered by envoy. UDP ports are only filtered on the machine if nftablesCommand is set.
+func UpdateSourceRangesStatus(
 	ctx context.Context,
 	c client.StatusWriter,
-	keepalivedStatsFile string,
+	r record.EventRecorder,
+	nftablesCommand string,
+	lb *yawolv1beta1.LoadBalancer,
 	lbm *yawolv1beta1.LoadBalancerMachine,
 ) error {
-	if keepalivedStatsFile == "" {
+	var tcpPort bool
+	var udpPorts []int32
+	for _, port := range lb.Spec.Ports {
+		switch string(port.Protocol) {
+		case protocolTCP:
+			tcpPort = true
+		case protocolUDP:
+			if !isPortInList(udpPorts, port.Port) {
+				udpPorts = append(udpPorts, port.Port)
+			}
+		}
+	}
+
+	// the ruleset is applied on every reconcile to restore rules that were removed on the machine
+	var nftablesErr error
+	if nftablesCommand != "" {
+		nftablesErr = nftables.Apply(nftablesCommand,
+			nftables.UDPSourceRangesRuleset(udpPorts, lb.Spec.Options.LoadBalancerSourceRanges))
+	}
+
+	if len(lb.Spec.Options.LoadBalancerSourceRanges) == 0 {
+		return UpdateLBMConditions(ctx, c, lbm,
+			SourceRangesEnforced, ConditionTrue,
+			"NoSourceRanges", "No source ranges are set")
+	}
+
+	paths := []string{"openstack security group"}
+	if tcpPort {
+		paths = append(paths, "envoy rbac for tcp ports")
+	}
+	if len(udpPorts) > 0 {
+		if nftablesCommand == "" {
+			return UpdateLBMConditions(ctx, c, lbm,
+				SourceRangesEnforced, ConditionFalse,
+				"UDPNotEnforcedOnMachine", "Source ranges are enforced by "+strings.Join(paths, ", "))
+		}
+		if nftablesErr != nil {
+			_ = kubernetes.SendErrorAsEvent(r, fmt.Errorf("%w: %v", ErrNftablesFailed, nftablesErr), lbm)
+			return UpdateLBMConditions(ctx, c, lbm,
+				SourceRangesEnforced, ConditionFalse,
+				"NftablesFailed", "Source ranges are enforced by "+strings.Join(paths, ", "))
+		}
+		paths = append(paths, "nftables for udp ports")
+	}
+
+	return UpdateLBMConditions(ctx, c, lbm,
+		SourceRangesEnforced, ConditionTrue,
+		"SourceRangesEnforced", "Source ranges are enforced by "+strings.Join(paths, ", "))
+}
+
+// UpdateKeepalivedConfigStatus writes the keepalived config for the lbm and reloads keepalived if the config has changed.
+// The config is written on every reconcile, so that changes on the machine are reverted.
+// A failed reload is retried as long as the KeepalivedConfigReady condition is false.
+// The interface with the unicast source IP is used if no interface is set.
+func UpdateKeepalivedConfigStatus(
+	ctx context.Context,
+	c client.StatusWriter,
+	r record.EventRecorder,
+	reader client.Reader,
+	configFile string,
+	iface string,
+	reloadCommand string,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+) error {
+	if configFile == "" {
 		return nil
 	}
 
-	keepalivedStats, modTime, err := keepalived.ReadStatsForInstanceName(VRRPInstanceName, keepalivedStatsFile)
+	config, secretVersion, err := getKeepalivedConfig(ctx, reader, iface, lbm)
 	if err != nil {
-		if err := UpdateLBMConditions(ctx, c, lbm,
-			KeepalivedStatsFile,
-			ConditionFalse,
-			"CouldNotReadStats", "Could not get stats file"); err != nil {
-			return err
-		}
-		if err := UpdateLBMConditions(ctx, c, lbm,
-			KeepalivedMaster, ConditionFalse,
-			"CouldNotReadStats", "Could not get stats file"); err != nil {
+		_ = kubernetes.SendErrorAsEvent(r, err, lbm)
+		return UpdateLBMConditions(ctx, c, lbm,
+			KeepalivedConfigReady, ConditionFalse,
+			"KeepalivedConfigFailed", "Keepalived config could not be generated")
+	}
+
+	changed, err := keepalived.WriteFile(configFile, config)
+	if err != nil {
+		_ = kubernetes.SendErrorAsEvent(r, fmt.Errorf("%w: unable to write keepalived config", err), lbm)
+		return UpdateLBMConditions(ctx, c, lbm,
+			KeepalivedConfigReady, ConditionFalse,
+			"KeepalivedConfigFailed", "Keepalived config could not be written")
+	}
+
+	if !changed && isLBMConditionTrue(lbm, KeepalivedConfigReady) {
+		if err := patchKeepalivedSecretVersion(ctx, c, lbm, secretVersion); err != nil {
 			return err
 		}
+		return UpdateLBMConditions(ctx, c, lbm,
+			KeepalivedConfigReady, ConditionTrue,
+			"KeepalivedConfigUpToDate", "Keepalived config is already up to date")
+	}
+
+	if err := keepalived.Reload(reloadCommand); err != nil {
+		_ = kubernetes.SendErrorAsEvent(r, fmt.Errorf("%w: unable to reload keepalived", err), lbm)
+		return UpdateLBMConditions(ctx, c, lbm,
+			KeepalivedConfigReady, ConditionFalse,
+			"KeepalivedReloadFailed", "Keepalived config is written but keepalived could not be reloaded")
+	}
+
+	r.Event(lbm, corev1.EventTypeNormal, "Update", "Keepalived is reloaded with an updated config")
+	if err := patchKeepalivedSecretVersion(ctx, c, lbm, secretVersion); err != nil {
+		return err
+	}
+	return UpdateLBMConditions(ctx, c, lbm,
+		KeepalivedConfigReady, ConditionTrue,
+		"KeepalivedConfigUpdated", "Keepalived config is successfully updated")
+}
+
+// patchKeepalivedSecretVersion reports the version of the keepalived secret the running keepalived config is
+// generated from. The yawol-controller switches to a new VRRP auth password once all machines know it.
+func patchKeepalivedSecretVersion(
+	ctx context.Context,
+	c client.StatusWriter,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+	secretVersion string,
+) error {
+	if secretVersion == "" ||
+		(lbm.Status.KeepalivedSecretVersion != nil && *lbm.Status.KeepalivedSecretVersion == secretVersion) {
 		return nil
 	}
+	return PatchLBMStatus(ctx, c, lbm, yawolv1beta1.LoadBalancerMachineStatus{
+		KeepalivedSecretVersion: &secretVersion,
+	})
+}
 
-	keepalivedIsMaster := ConditionFalse
-	if keepalivedStats.IsMaster() {
-		keepalivedIsMaster = ConditionTrue
+// UpdateKeepalivedTrackFile updates the BackendsHealthy condition and writes the track file of keepalived.
+// The machine is healthy if envoy is ready, up to date and has healthy hosts for all ports.
+// If the machine is unhealthy for longer than the grace period the file contains 1, otherwise 0.
+// keepalived lowers the VRRP priority of the machine if the file contains 1,
+// so that the VIP moves to another machine with working backends.
+// Must be called after the EnvoyReady and EnvoyUpToDate conditions are updated.
+func UpdateKeepalivedTrackFile(
+	ctx context.Context,
+	c client.StatusWriter,
+	r record.EventRecorder,
+	trackFile string,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+) error {
+	if trackFile == "" {
+		return nil
 	}
-	if err := UpdateLBMConditions(ctx, c, lbm,
-		KeepalivedMaster,
-		keepalivedIsMaster,
-		"KeepalivedStatus", "Read master status from stats file"); err != nil {
+
+	status, reason, message := ConditionTrue, "BackendsHealthy", "Envoy has healthy backends for all ports"
+	switch {
+	case !isLBMConditionTrue(lbm, EnvoyReady):
+		status, reason, message = ConditionFalse, "EnvoyNotReady", "Envoy is not ready"
+	case !isLBMConditionTrue(lbm, EnvoyUpToDate):
+		status, reason, message = ConditionFalse, "EnvoyNotUpToDate", "Envoy is not up to date"
+	default:
+		envoyStatus := envoystatus.Config{AdminAddress: "127.0.0.1:9000"}
+		clusters, err := envoyStatus.GetClustersWithoutHealthyHosts()
+		if err != nil {
+			status, reason, message = ConditionFalse, "BackendHealthUnknown", "Unable to get backend health from envoy"
+		} else if len(clusters) > 0 {
+			status, reason, message = ConditionFalse, "NoHealthyBackends", "No healthy backends for "+strings.Join(clusters, ", ")
+		}
+	}
+	if err := UpdateLBMConditions(ctx, c, lbm, BackendsHealthy, status, reason, message); err != nil {
 		return err
 	}
 
-	if modTime.Before(time.Now().Add(-5 * time.Minute)) {
-		return UpdateLBMConditions(ctx, c, lbm,
-			KeepalivedStatsFile,
-			ConditionFalse,
-			"StatsNotUpToDate", "Keepalived stat file is older than 5 min")
+	// short outages e.g. during an envoy update or the first health checks do not move the VIP
+	value := "0\n"
+	if condition := getLBMCondition(lbm, BackendsHealthy); condition != nil &&
+		string(condition.Status) == string(ConditionFalse) &&
+		time.Since(condition.LastTransitionTime.Time) >= KeepalivedTrackGracePeriod {
+		value = "1\n"
+	}
+	changed, err := keepalived.WriteFile(trackFile, value)
+	if err != nil {
+		return kubernetes.SendErrorAsEvent(r, fmt.Errorf("%w: unable to write keepalived track file", err), lbm)
+	}
+	if !changed {
+		return nil
 	}
 
-	return UpdateLBMConditions(ctx, c, lbm,
-		KeepalivedStatsFile,
-		ConditionTrue,
-		"StatsUpToDate", "Keepalived stat file is newer than 5 min")
+	if value == "1\n" {
+		r.Event(lbm, corev1.EventTypeWarning, "VRRPPriority", "VRRP priority is lowered: "+message)
+	} else {
+		r.Event(lbm, corev1.EventTypeNormal, "VRRPPriority", "VRRP priority is restored")
+	}
+	return nil
+}
+
+// getKeepalivedConfig returns the keepalived config with the VIPs and the VRRP settings of the lbm
+// and the resource version of the keepalived secret.
+func getKeepalivedConfig(
+	ctx context.Context,
+	reader client.Reader,
+	iface string,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+) (config, secretVersion string, err error) {
+	if lbm.Status.VIPs == nil || len(*lbm.Status.VIPs) == 0 {
+		return "", "", ErrKeepalivedVIPsNotSet
+	}
+
+	authPass, secretVersion, err := GetKeepalivedAuthPass(ctx, reader, lbm)
+	if err != nil {
+		return "", "", err
+	}
+
+	var srcIP string
+	if lbm.Status.VRRPUnicastSrcIP != nil {
+		srcIP = *lbm.Status.VRRPUnicastSrcIP
+	}
+	var peers []string
+	if lbm.Status.VRRPUnicastPeers != nil {
+		peers = *lbm.Status.VRRPUnicastPeers
+	}
+
+	if iface == "" {
+		if iface, err = getInterfaceForIP(srcIP); err != nil {
+			return "", "", err
+		}
+	}
+
+	return GenerateKeepalivedConfig(iface, *lbm.Status.VIPs, lbm.Spec.VRRPRouterID, authPass, srcIP, peers),
+		secretVersion, nil
+}
+
+// isLBMConditionTrue returns true if the condition of the lbm is true.
+func isLBMConditionTrue(lbm *yawolv1beta1.LoadBalancerMachine, condition LoadbalancerCondition) bool {
+	c := getLBMCondition(lbm, condition)
+	return c != nil && string(c.Status) == string(ConditionTrue)
+}
+
+// getLBMCondition returns the condition of the lbm or nil if it is not set.
+func getLBMCondition(lbm *yawolv1beta1.LoadBalancerMachine, condition LoadbalancerCondition) *corev1.NodeCondition {
+	if lbm.Status.Conditions == nil {
+		return nil
+	}
+	for i := range *lbm.Status.Conditions {
+		if string((*lbm.Status.Conditions)[i].Type) == string(condition) {
+			return &(*lbm.Status.Conditions)[i]
+		}
+	}
+	return nil
+}
+
+// getInterfaceForIP returns the interface with the IP address.
+// Without an IP the first interface that is up and not a loopback is returned.
+func getInterfaceForIP(ip string) (string, error) {
+	ifaces, err := net.Interfaces()
+	if err != nil {
+		return "", err
+	}
+	for i := range ifaces {
+		if ifaces[i].Flags&net.FlagUp == 0 || ifaces[i].Flags&net.FlagLoopback != 0 {
+			continue
+		}
+		if ip == "" {
+			return ifaces[i].Name, nil
+		}
+		addrs, err := ifaces[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, addr := range addrs {
+			if ipNet, ok := addr.(*net.IPNet); ok && ipNet.IP.Equal(net.ParseIP(ip)) {
+				return ifaces[i].Name, nil
+			}
+		}
+	}
+	return "", fmt.Errorf("%w: %s", ErrKeepalivedInterfaceNotFound, ip)
+}
+
+// UpdateKeepalivedStatus updates the KeepalivedMaster and KeepalivedStatsFile conditions of the lbm
+// and records the pending keepalived notifications as events.
+func UpdateKeepalivedStatus(
+	ctx context.Context,
+	c client.StatusWriter,
+	r record.EventRecorder,
+	keepalivedStatsFile string,
+	notifications *keepalived.Notifications,
+	lbm *yawolv1beta1.LoadBalancerMachine,
+) error {
+	var notification *keepalived.Notification
+	if notifications != nil {
+		for _, pending := range notifications.Pending() {
+			if pending.Name != VRRPInstanceName {
+				continue
+			}
+			eventType := corev1.EventTypeNormal
+			if pending.State != "MASTER" && pending.State != "BACKUP" {
+				eventType = corev1.EventTypeWarning
+			}
+			r.Event(lbm, eventType, "Keepalived"+pending.State, fmt.Sprintf(
+				"Keepalived changed to %s with priority %d at %s: %s", pending.State, pending.Priority,
+				pending.Time.UTC().Format(time.RFC3339), describeVRRPPriority(pending.Priority)))
+		}
+
+		if lastNotification, _, ok := notifications.Last(VRRPInstanceName); ok {
+			notification = &lastNotification
+		}
+	}
+
+	var keepalivedStats keepalived.VRRPInstance
+	var modTime time.Time
+	var statsErr error
+	if keepalivedStatsFile != "" {
+		keepalivedStats, modTime, statsErr = keepalived.ReadStatsForInstanceName(VRRPInstanceName, keepalivedStatsFile)
+		switch {
+		case statsErr != nil:
+			if err := UpdateLBMConditions(ctx, c, lbm,
+				KeepalivedStatsFile,
+				ConditionFalse,
+				"CouldNotReadStats", "Could not get stats file"); err != nil {
+				return err
+			}
+		case isKeepalivedStatsStale(modTime):
+			if err := UpdateLBMConditions(ctx, c, lbm,
+				KeepalivedStatsFile,
+				ConditionFalse,
+				"StatsNotUpToDate", "Keepalived stat file is older than 5 min"); err != nil {
+				return err
+			}
+		default:
+			if err := UpdateLBMConditions(ctx, c, lbm,
+				KeepalivedStatsFile,
+				ConditionTrue,
+				"StatsUpToDate", "Keepalived stat file is newer than 5 min"); err != nil {
+				return err
+			}
+		}
+	}
+
+	keepalivedIsMaster, reason, message := getKeepalivedMasterStatus(
+		notification, keepalivedStatsFile, keepalivedStats, modTime, statsErr)
+	if keepalivedIsMaster == "" {
+		return nil
+	}
+	return UpdateLBMConditions(ctx, c, lbm, KeepalivedMaster, keepalivedIsMaster, reason, message)
+}
+
+// getKeepalivedMasterStatus returns the status, reason and message of the KeepalivedMaster condition.
+// The last notification is used unless the stats file is newer. Keepalived sends no notification if it is killed,
+// so the status is False if the stats file can not be read and Unknown if it is stale.
+// Returns an empty status if neither a notification nor a stats file is available.
+func getKeepalivedMasterStatus(
+	notification *keepalived.Notification,
+	keepalivedStatsFile string,
+	keepalivedStats keepalived.VRRPInstance,
+	modTime time.Time,
+	statsErr error,
+) (status LoadbalancerConditionStatus, reason, message string) {
+	switch {
+	case keepalivedStatsFile != "" && statsErr != nil:
+		return ConditionFalse, "CouldNotReadStats", "Could not get stats file"
+	case keepalivedStatsFile != "" && isKeepalivedStatsStale(modTime):
+		return ConditionUnknown, "StatsNotUpToDate", "Keepalived stat file is older than 5 min"
+	case notification != nil && (keepalivedStatsFile == "" || !modTime.After(notification.Time)):
+		status = ConditionFalse
+		if notification.IsMaster() {
+			status = ConditionTrue
+		}
+		return status, "KeepalivedNotification", fmt.Sprintf("Keepalived is %s with priority %d",
+			notification.State, notification.Priority)
+	case keepalivedStatsFile != "":
+		status = ConditionFalse
+		if keepalivedStats.IsMaster() {
+			status = ConditionTrue
+		}
+		return status, "KeepalivedStatus", "Read master status from stats file"
+	}
+	return "", "", ""
+}
+
+// isKeepalivedStatsStale returns true if the stats file was not updated within KeepalivedStatsMaxAge.
+func isKeepalivedStatsStale(modTime time.Time) bool {
+	return modTime.Before(time.Now().Add(-KeepalivedStatsMaxAge))
+}
+
+// describeVRRPPriority returns the reason for a VRRP priority of the generated keepalived config.
+// The priority is 100, envoy adds 100 and the track file of the yawollet subtracts 50.
+func describeVRRPPriority(priority int) string {
+	switch priority {
+	case 200:
+		return "envoy is running and has healthy backends"
+	case 150:
+		return "envoy is running but has no healthy backends"
+	case 100:
+		return "envoy is not running"
+	case 50:
+		return "envoy is not running and has no healthy backends"
+	case 0:
+		return "keepalived is stopped or in fault state"
+	default:
+		return "unknown priority"
+	}
 }
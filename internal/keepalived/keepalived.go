@@ -1,13 +1,27 @@
 package keepalived
 
 import (
+	"bytes"
+	"fmt"
 	"os"
+	"os/exec"
+	"path/filepath"
 	"strings"
 	"time"
 
 	"gopkg.in/yaml.v2"
 )
 
+// ConfigFile is the keepalived config that is written by cloud-init and kept up to date by the yawollet
+const ConfigFile = "/etc/keepalived/keepalived.conf"
+
+// DefaultInterface is used for the vrrp instance until the yawollet has detected the interface of the machine
+const DefaultInterface = "eth0"
+
+// TrackFile is written by the yawollet and tracked by the vrrp instance. It contains 0 if the machine is healthy
+// and 1 if the VRRP priority of the machine has to be lowered.
+const TrackFile = "/etc/keepalived/yawollet.track"
+
 type StatsEntry struct {
 	VRRPInstance `yaml:"VRRP Instance"`
 }
@@ -98,3 +112,45 @@ func ReadStatsForInstanceName(instanceName, filename string) (VRRPInstance, time
 
 	return stats[instanceName], modTime, nil
 }
+
+// WriteFile replaces the file atomically if the content has changed.
+// Returns true if the file was written.
+func WriteFile(file, content string) (bool, error) {
+	if current, err := os.ReadFile(file); err == nil && string(current) == content {
+		return false, nil
+	}
+
+	// the temporary file is created in the same directory, so that the rename is atomic
+	tmpFile, err := os.CreateTemp(filepath.Dir(file), filepath.Base(file)+".*")
+	if err != nil {
+		return false, err
+	}
+	defer os.Remove(tmpFile.Name()) //nolint:errcheck // file is already renamed on success
+
+	if _, err = tmpFile.WriteString(content); err != nil {
+		_ = tmpFile.Close()
+		return false, err
+	}
+	if err = tmpFile.Close(); err != nil {
+		return false, err
+	}
+	if err = os.Rename(tmpFile.Name(), file); err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// Reload runs the command that makes keepalived reload its config
+func Reload(command string) error {
+	args := strings.Fields(command)
+	if len(args) == 0 {
+		return nil
+	}
+	var stderr bytes.Buffer
+	cmd := exec.Command(args[0], args[1:]...)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
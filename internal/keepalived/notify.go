@@ -0,0 +1,133 @@
+package keepalived
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// NotifyFIFO is the fifo keepalived writes the state transitions of the vrrp instances to.
+// It is created by cloud-init and read by the yawollet.
+const NotifyFIFO = "/etc/keepalived/yawollet.fifo"
+
+// Notification is a state transition that keepalived writes to the notify fifo
+type Notification struct {
+	// Time is the time the notification was read by the yawollet
+	Time time.Time
+	// Type is INSTANCE or GROUP
+	Type string
+	// Name is the name of the vrrp instance or group
+	Name string
+	// State is MASTER, BACKUP, FAULT or STOP
+	State    string
+	Priority int
+}
+
+// IsMaster returns true if the instance became master
+func (n Notification) IsMaster() bool {
+	return n.State == "MASTER"
+}
+
+// ParseNotification parses a line of the notify fifo e.g. INSTANCE "ENVOY" MASTER 200
+func ParseNotification(line string, t time.Time) (Notification, error) {
+	fields := strings.Fields(line)
+	if len(fields) != 4 {
+		return Notification{}, fmt.Errorf("unexpected keepalived notification: %s", line)
+	}
+	priority, err := strconv.Atoi(fields[3])
+	if err != nil {
+		return Notification{}, fmt.Errorf("%w: unexpected keepalived notification: %s", err, line)
+	}
+	return Notification{
+		Time:     t,
+		Type:     fields[0],
+		Name:     strings.Trim(fields[1], `"`),
+		State:    fields[2],
+		Priority: priority,
+	}, nil
+}
+
+// Notifications holds the notifications that are read from the notify fifo
+type Notifications struct {
+	mu          sync.Mutex
+	last        map[string]Notification
+	transitions map[string]int
+	pending     []Notification
+}
+
+// Add stores the notification as the last notification of the instance
+func (n *Notifications) Add(notification Notification) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	if n.last == nil {
+		n.last = make(map[string]Notification)
+		n.transitions = make(map[string]int)
+	}
+	n.last[notification.Name] = notification
+	n.transitions[notification.Name]++
+	n.pending = append(n.pending, notification)
+}
+
+// Last returns the last notification of the instance and the number of transitions since the yawollet is running.
+// Returns false if there was no notification for the instance.
+func (n *Notifications) Last(name string) (Notification, int, bool) {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	notification, ok := n.last[name]
+	return notification, n.transitions[name], ok
+}
+
+// Pending returns the notifications that were added since the last call
+func (n *Notifications) Pending() []Notification {
+	n.mu.Lock()
+	defer n.mu.Unlock()
+	pending := n.pending
+	n.pending = nil
+	return pending
+}
+
+// ReadNotifyFIFO creates the fifo if it does not exist and adds the notifications that keepalived writes to it
+// until the context is done. onNotification is called after every notification.
+// Lines that are not a notification of a vrrp instance or group are ignored.
+func ReadNotifyFIFO(
+	ctx context.Context,
+	fifo string,
+	notifications *Notifications,
+	onNotification func(Notification),
+) error {
+	if err := syscall.Mkfifo(fifo, 0600); err != nil && !errors.Is(err, os.ErrExist) {
+		return err
+	}
+
+	// opening the fifo for reading and writing does not block until keepalived opens it
+	// and does not return EOF if keepalived closes it during a restart
+	file, err := os.OpenFile(fifo, os.O_RDWR, 0)
+	if err != nil {
+		return err
+	}
+	go func() {
+		<-ctx.Done()
+		_ = file.Close()
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		notification, err := ParseNotification(scanner.Text(), time.Now())
+		if err != nil || (notification.Type != "INSTANCE" && notification.Type != "GROUP") {
+			continue
+		}
+		notifications.Add(notification)
+		onNotification(notification)
+	}
+	if ctx.Err() != nil {
+		return nil
+	}
+	return scanner.Err()
+}
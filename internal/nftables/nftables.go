@@ -0,0 +1,73 @@
+// Package nftables enforces the source ranges of the UDP ports on the LoadBalancer machine
+package nftables
+
+import (
+	"bytes"
+	"fmt"
+	"net"
+	"os/exec"
+	"strings"
+)
+
+// TableName is the name of the nftables table that is owned by the yawollet
+const TableName = "yawol_udp_source_ranges"
+
+// UDPSourceRangesRuleset returns a ruleset that replaces the yawollet table and drops packets to the
+// UDP ports from addresses outside of the source ranges. Without ports or source ranges the table is removed.
+// Invalid source ranges are ignored, if no source range of an IP family is left all packets of the family are dropped.
+func UDPSourceRangesRuleset(ports []int32, sourceRanges []string) string {
+	// declaring the table first makes the delete work if the table does not exist yet
+	ruleset := "table inet " + TableName + "\n" +
+		"delete table inet " + TableName + "\n"
+	if len(ports) == 0 || len(sourceRanges) == 0 {
+		return ruleset
+	}
+
+	var ipv4Ranges, ipv6Ranges []string
+	for _, sourceRange := range sourceRanges {
+		_, ipNet, err := net.ParseCIDR(sourceRange)
+		if err != nil {
+			continue
+		}
+		if ipNet.IP.To4() != nil {
+			ipv4Ranges = append(ipv4Ranges, ipNet.String())
+		} else {
+			ipv6Ranges = append(ipv6Ranges, ipNet.String())
+		}
+	}
+
+	portList := make([]string, 0, len(ports))
+	for _, port := range ports {
+		portList = append(portList, fmt.Sprint(port))
+	}
+	dport := "udp dport { " + strings.Join(portList, ", ") + " }"
+
+	return ruleset +
+		"table inet " + TableName + " {\n" +
+		"\tchain input {\n" +
+		"\t\ttype filter hook input priority 0; policy accept;\n" +
+		"\t\t" + dropRule("ipv4", "ip", dport, ipv4Ranges) + "\n" +
+		"\t\t" + dropRule("ipv6", "ip6", dport, ipv6Ranges) + "\n" +
+		"\t}\n" +
+		"}\n"
+}
+
+func dropRule(family, addressType, dport string, sourceRanges []string) string {
+	if len(sourceRanges) == 0 {
+		return "meta nfproto " + family + " " + dport + " drop"
+	}
+	return "meta nfproto " + family + " " + dport + " " + addressType +
+		" saddr != { " + strings.Join(sourceRanges, ", ") + " } drop"
+}
+
+// Apply loads the ruleset with the nft command
+func Apply(command, ruleset string) error {
+	var stderr bytes.Buffer
+	cmd := exec.Command(command, "-f", "-")
+	cmd.Stdin = strings.NewReader(ruleset)
+	cmd.Stderr = &stderr
+	if err := cmd.Run(); err != nil {
+		return fmt.Errorf("%w: %s", err, strings.TrimSpace(stderr.String()))
+	}
+	return nil
+}
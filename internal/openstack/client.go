@@ -19,6 +19,7 @@ import (
 type OSClient struct {
 	networkV2   *gophercloud.ServiceClient
 	computeV2   *gophercloud.ServiceClient
+	imageV2     *gophercloud.ServiceClient
 	ini         []byte
 	timeout     time.Duration
 	promCounter *prometheus.CounterVec
@@ -139,6 +140,54 @@ func (r *OSClient) KeyPairClient(ctx context.Context) (KeyPairClient, error) {
 	return client.Configure(r.computeV2, r.timeout, r.promCounter), nil
 }
 
+// Returns a configured OSImageClient as ImageClient.
+// Make sure that you invoked Configure() before this.
+func (r *OSClient) ImageClient(ctx context.Context) (ImageClient, error) {
+	if r.imageV2 == nil {
+		var sc *gophercloud.ServiceClient
+		sc, err := createImageV2FromIni(ctx, r.ini, r.timeout)
+		if err != nil {
+			return nil, err
+		}
+		r.imageV2 = sc
+	}
+
+	client := &OSImageClient{}
+	return client.Configure(r.imageV2, r.timeout, r.promCounter), nil
+}
+
+// Returns a configured OSFlavorClient as FlavorClient.
+// Make sure that you invoked Configure() before this.
+func (r *OSClient) FlavorClient(ctx context.Context) (FlavorClient, error) {
+	if r.computeV2 == nil {
+		var sc *gophercloud.ServiceClient
+		sc, err := createComputeV2FromIni(ctx, r.ini, r.timeout)
+		if err != nil {
+			return nil, err
+		}
+		r.computeV2 = sc
+	}
+
+	client := &OSFlavorClient{}
+	return client.Configure(r.computeV2, r.timeout, r.promCounter), nil
+}
+
+// Returns a configured OSSubnetClient as SubnetClient.
+// Make sure that you invoked Configure() before this.
+func (r *OSClient) SubnetClient(ctx context.Context) (SubnetClient, error) {
+	if r.networkV2 == nil {
+		var sc *gophercloud.ServiceClient
+		sc, err := createNetworkV2FromIni(ctx, r.ini, r.timeout)
+		if err != nil {
+			return nil, err
+		}
+		r.networkV2 = sc
+	}
+
+	client := &OSSubnetClient{}
+	return client.Configure(r.networkV2, r.timeout, r.promCounter), nil
+}
+
 func createNetworkV2FromIni(ctx context.Context, iniData []byte, timeout time.Duration) (*gophercloud.ServiceClient, error) {
 	provider, opts, err := getProvider(ctx, iniData, timeout)
 	if err != nil {
@@ -167,6 +216,20 @@ func createComputeV2FromIni(ctx context.Context, iniData []byte, timeout time.Du
 	return client, nil
 }
 
+func createImageV2FromIni(ctx context.Context, iniData []byte, timeout time.Duration) (*gophercloud.ServiceClient, error) {
+	provider, opts, err := getProvider(ctx, iniData, timeout)
+	if err != nil {
+		return nil, err
+	}
+
+	client, err := openstack.NewImageServiceV2(provider, *opts)
+	if err != nil {
+		return nil, err
+	}
+
+	return client, nil
+}
+
 func getProvider(
 	ctx context.Context,
 	iniData []byte,
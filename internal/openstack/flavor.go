@@ -0,0 +1,79 @@
+package openstack
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
+)
+
+// The OSFlavorClient is a implementation for FlavorClient. When you want to use this struct be sure to call
+// Configure() before calling any other method. Otherwise it will result in errors.
+//
+// As an easier abstraction you can use OSClient in this package, where you can insert data from an ini
+// file to automatically initialize all modules you want to use.
+type OSFlavorClient struct {
+	computeV2   *gophercloud.ServiceClient
+	timeout     time.Duration
+	promCounter *prometheus.CounterVec
+}
+
+// Configure takes ComputeV2 ServiceClient to receive endpoints and auth info for further calls against openstack.
+func (r *OSFlavorClient) Configure(
+	computeV2 *gophercloud.ServiceClient,
+	timeout time.Duration,
+	promCounter *prometheus.CounterVec,
+) *OSFlavorClient {
+	r.computeV2 = computeV2
+	r.timeout = timeout
+	r.promCounter = promCounter
+	return r
+}
+
+// Invokes flavors.ListDetail() in gophercloud's flavors package. Uses the computeV2 client provided in Configure().
+func (r *OSFlavorClient) List(ctx context.Context, opts flavors.ListOptsBuilder) ([]flavors.Flavor, error) {
+	increasePromCounter(r.promCounter, MetricAPINova, MetricObjectFlavor, MetricOperationList)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.computeV2.Context = tctx
+	defer func() {
+		r.computeV2.Context = nil
+	}()
+
+	page, err := flavors.ListDetail(r.computeV2, opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return flavors.ExtractFlavors(page)
+}
+
+// Invokes flavors.Get() in gophercloud's flavors package. Uses the computeV2 client provided in Configure().
+func (r *OSFlavorClient) Get(ctx context.Context, id string) (*flavors.Flavor, error) {
+	increasePromCounter(r.promCounter, MetricAPINova, MetricObjectFlavor, MetricOperationGet)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.computeV2.Context = tctx
+	defer func() {
+		r.computeV2.Context = nil
+	}()
+
+	flavor, err := flavors.Get(r.computeV2, id).Extract()
+	return flavor, err
+}
+
+// Invokes flavors.ListExtraSpecs() in gophercloud's flavors package. Uses the computeV2 client provided in Configure().
+func (r *OSFlavorClient) ListExtraSpecs(ctx context.Context, id string) (map[string]string, error) {
+	increasePromCounter(r.promCounter, MetricAPINova, MetricObjectFlavorExtraSpec, MetricOperationList)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.computeV2.Context = tctx
+	defer func() {
+		r.computeV2.Context = nil
+	}()
+
+	extraSpecs, err := flavors.ListExtraSpecs(r.computeV2, id).Extract()
+	return extraSpecs, err
+}
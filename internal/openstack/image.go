@@ -0,0 +1,65 @@
+package openstack
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
+)
+
+// The OSImageClient is a implementation for ImageClient. When you want to use this struct be sure to call
+// Configure() before calling any other method. Otherwise it will result in errors.
+//
+// As an easier abstraction you can use OSClient in this package, where you can insert data from an ini
+// file to automatically initialize all modules you want to use.
+type OSImageClient struct {
+	imageV2     *gophercloud.ServiceClient
+	timeout     time.Duration
+	promCounter *prometheus.CounterVec
+}
+
+// Configure takes ImageV2 ServiceClient to receive endpoints and auth info for further calls against openstack.
+func (r *OSImageClient) Configure(
+	imageV2 *gophercloud.ServiceClient,
+	timeout time.Duration,
+	promCounter *prometheus.CounterVec,
+) *OSImageClient {
+	r.imageV2 = imageV2
+	r.timeout = timeout
+	r.promCounter = promCounter
+	return r
+}
+
+// Invokes images.List() in gophercloud's images package. Uses the imageV2 client provided in Configure().
+func (r *OSImageClient) List(ctx context.Context, opts images.ListOptsBuilder) ([]images.Image, error) {
+	increasePromCounter(r.promCounter, MetricAPIGlance, MetricObjectImage, MetricOperationList)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.imageV2.Context = tctx
+	defer func() {
+		r.imageV2.Context = nil
+	}()
+
+	page, err := images.List(r.imageV2, opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return images.ExtractImages(page)
+}
+
+// Invokes images.Get() in gophercloud's images package. Uses the imageV2 client provided in Configure().
+func (r *OSImageClient) Get(ctx context.Context, id string) (*images.Image, error) {
+	increasePromCounter(r.promCounter, MetricAPIGlance, MetricObjectImage, MetricOperationGet)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.imageV2.Context = tctx
+	defer func() {
+		r.imageV2.Context = nil
+	}()
+
+	image, err := images.Get(r.imageV2, id).Extract()
+	return image, err
+}
@@ -33,11 +33,14 @@ import (
 	"github.com/prometheus/client_golang/prometheus"
 
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
 )
 
 // Client provides a interface to configure and use different OpenStack clients.
@@ -56,6 +59,12 @@ type Client interface {
 	ServerClient(ctx context.Context) (ServerClient, error)
 	// Returns the KeyPairClient created from the configured ini
 	KeyPairClient(ctx context.Context) (KeyPairClient, error)
+	// Returns the ImageClient created from the configured ini
+	ImageClient(ctx context.Context) (ImageClient, error)
+	// Returns the FlavorClient created from the configured ini
+	FlavorClient(ctx context.Context) (FlavorClient, error)
+	// Returns the SubnetClient created from the configured ini
+	SubnetClient(ctx context.Context) (SubnetClient, error)
 }
 
 // FipClient is used to modify FloatingIPs in an OpenStack environment.
@@ -116,3 +125,25 @@ type KeyPairClient interface {
 	Get(ctx context.Context, name string) (*keypairs.KeyPair, error)
 	Delete(ctx context.Context, name string) error
 }
+
+// ImageClient is used to look up images in an OpenStack environment.
+// It provides read-only methods, since images are managed outside of yawol.
+type ImageClient interface {
+	List(ctx context.Context, opts images.ListOptsBuilder) ([]images.Image, error)
+	Get(ctx context.Context, id string) (*images.Image, error)
+}
+
+// FlavorClient is used to look up flavors and their extra specs in an OpenStack environment.
+// It provides read-only methods, since flavors are managed outside of yawol.
+type FlavorClient interface {
+	List(ctx context.Context, opts flavors.ListOptsBuilder) ([]flavors.Flavor, error)
+	Get(ctx context.Context, id string) (*flavors.Flavor, error)
+	ListExtraSpecs(ctx context.Context, id string) (map[string]string, error)
+}
+
+// SubnetClient is used to look up subnets in an OpenStack environment.
+// It provides read-only methods, since subnets are managed outside of yawol.
+type SubnetClient interface {
+	List(ctx context.Context, opts subnets.ListOptsBuilder) ([]subnets.Subnet, error)
+	Get(ctx context.Context, id string) (*subnets.Subnet, error)
+}
@@ -9,15 +9,20 @@ type MetricOperation string
 const (
 	MetricAPINova    MetricAPI = "nova"
 	MetricAPINeutron MetricAPI = "neutron"
+	MetricAPIGlance  MetricAPI = "glance"
 )
 
 const (
-	MetricObjectFloatingIP MetricObject = "floatingip"
-	MetricObjectGroup      MetricObject = "group"
-	MetricObjectKeyPair    MetricObject = "keypair"
-	MetricObjectPort       MetricObject = "port"
-	MetricObjectRule       MetricObject = "rule"
-	MetricObjectServer     MetricObject = "server"
+	MetricObjectFlavor          MetricObject = "flavor"
+	MetricObjectFlavorExtraSpec MetricObject = "flavorextraspec"
+	MetricObjectFloatingIP      MetricObject = "floatingip"
+	MetricObjectGroup           MetricObject = "group"
+	MetricObjectImage           MetricObject = "image"
+	MetricObjectKeyPair         MetricObject = "keypair"
+	MetricObjectPort            MetricObject = "port"
+	MetricObjectRule            MetricObject = "rule"
+	MetricObjectServer          MetricObject = "server"
+	MetricObjectSubnet          MetricObject = "subnet"
 )
 
 const (
@@ -0,0 +1,66 @@
+package openstack
+
+import (
+	"context"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
+)
+
+// The OSSubnetClient is a implementation for SubnetClient. When you want to use this struct be sure to call
+// Configure() before calling any other method. Otherwise it will result in errors.
+//
+// As an easier abstraction you can use OSClient in this package, where you can insert data from an ini
+// file to automatically initialize all modules you want to use.
+type OSSubnetClient struct {
+	networkV2   *gophercloud.ServiceClient
+	timeout     time.Duration
+	promCounter *prometheus.CounterVec
+}
+
+// Configure takes NetworkV2 ServiceClient to receive endpoints and auth info for further calls against openstack.
+func (r *OSSubnetClient) Configure(
+	networkClient *gophercloud.ServiceClient,
+	timeout time.Duration,
+	promCounter *prometheus.CounterVec,
+) *OSSubnetClient {
+	r.networkV2 = networkClient
+	r.timeout = timeout
+	r.promCounter = promCounter
+	return r
+}
+
+// Invokes subnets.List() in gophercloud's subnets package and extracts all subnets.
+// Uses the networkV2 client provided in Configure().
+func (r *OSSubnetClient) List(ctx context.Context, opts subnets.ListOptsBuilder) ([]subnets.Subnet, error) {
+	increasePromCounter(r.promCounter, MetricAPINeutron, MetricObjectSubnet, MetricOperationList)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.networkV2.Context = tctx
+	defer func() {
+		r.networkV2.Context = nil
+	}()
+
+	page, err := subnets.List(r.networkV2, opts).AllPages()
+	if err != nil {
+		return nil, err
+	}
+	return subnets.ExtractSubnets(page)
+}
+
+// Invokes subnets.Get() in gophercloud's subnets package. Uses the networkV2 client provided in Configure().
+func (r *OSSubnetClient) Get(ctx context.Context, id string) (*subnets.Subnet, error) {
+	increasePromCounter(r.promCounter, MetricAPINeutron, MetricObjectSubnet, MetricOperationGet)
+	tctx, cancel := context.WithTimeout(ctx, r.timeout)
+	defer cancel()
+	r.networkV2.Context = tctx
+	defer func() {
+		r.networkV2.Context = nil
+	}()
+
+	subnet, err := subnets.Get(r.networkV2, id).Extract()
+	return subnet, err
+}
@@ -4,11 +4,14 @@ import (
 	"context"
 
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/extensions/keypairs"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
 )
 
 type CallbackGroupClient struct { //nolint:dupl // no dupl
@@ -146,3 +149,43 @@ func (r *CallbackKeypairClient) Get(ctx context.Context, name string) (*keypairs
 func (r *CallbackKeypairClient) Delete(ctx context.Context, name string) error {
 	return r.DeleteFunc(ctx, name)
 }
+
+type CallbackImageClient struct {
+	ListFunc func(ctx context.Context, opts images.ListOptsBuilder) ([]images.Image, error)
+	GetFunc  func(ctx context.Context, id string) (*images.Image, error)
+}
+
+func (r *CallbackImageClient) List(ctx context.Context, opts images.ListOptsBuilder) ([]images.Image, error) {
+	return r.ListFunc(ctx, opts)
+}
+func (r *CallbackImageClient) Get(ctx context.Context, id string) (*images.Image, error) {
+	return r.GetFunc(ctx, id)
+}
+
+type CallbackFlavorClient struct {
+	ListFunc           func(ctx context.Context, opts flavors.ListOptsBuilder) ([]flavors.Flavor, error)
+	GetFunc            func(ctx context.Context, id string) (*flavors.Flavor, error)
+	ListExtraSpecsFunc func(ctx context.Context, id string) (map[string]string, error)
+}
+
+func (r *CallbackFlavorClient) List(ctx context.Context, opts flavors.ListOptsBuilder) ([]flavors.Flavor, error) {
+	return r.ListFunc(ctx, opts)
+}
+func (r *CallbackFlavorClient) Get(ctx context.Context, id string) (*flavors.Flavor, error) {
+	return r.GetFunc(ctx, id)
+}
+func (r *CallbackFlavorClient) ListExtraSpecs(ctx context.Context, id string) (map[string]string, error) {
+	return r.ListExtraSpecsFunc(ctx, id)
+}
+
+type CallbackSubnetClient struct {
+	ListFunc func(ctx context.Context, opts subnets.ListOptsBuilder) ([]subnets.Subnet, error)
+	GetFunc  func(ctx context.Context, id string) (*subnets.Subnet, error)
+}
+
+func (r *CallbackSubnetClient) List(ctx context.Context, opts subnets.ListOptsBuilder) ([]subnets.Subnet, error) {
+	return r.ListFunc(ctx, opts)
+}
+func (r *CallbackSubnetClient) Get(ctx context.Context, id string) (*subnets.Subnet, error) {
+	return r.GetFunc(ctx, id)
+}
@@ -36,6 +36,9 @@ type MockClient struct {
 	RuleClientObj    openstack.RuleClient
 	ServerClientObj  openstack.ServerClient
 	KeyPairClientObj openstack.KeyPairClient
+	ImageClientObj   openstack.ImageClient
+	FlavorClientObj  openstack.FlavorClient
+	SubnetClientObj  openstack.SubnetClient
 }
 
 func (r *MockClient) Configure(ini []byte, timeout time.Duration, promCounter *prometheus.CounterVec) error {
@@ -60,3 +63,12 @@ func (r *MockClient) ServerClient(ctx context.Context) (openstack.ServerClient,
 func (r *MockClient) KeyPairClient(ctx context.Context) (openstack.KeyPairClient, error) {
 	return r.KeyPairClientObj, nil
 }
+func (r *MockClient) ImageClient(ctx context.Context) (openstack.ImageClient, error) {
+	return r.ImageClientObj, nil
+}
+func (r *MockClient) FlavorClient(ctx context.Context) (openstack.FlavorClient, error) {
+	return r.FlavorClientObj, nil
+}
+func (r *MockClient) SubnetClient(ctx context.Context) (openstack.SubnetClient, error) {
+	return r.SubnetClientObj, nil
+}
@@ -9,11 +9,14 @@ import (
 	"time"
 
 	"github.com/gophercloud/gophercloud"
+	"github.com/gophercloud/gophercloud/openstack/compute/v2/flavors"
 	"github.com/gophercloud/gophercloud/openstack/compute/v2/servers"
+	"github.com/gophercloud/gophercloud/openstack/imageservice/v2/images"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/layer3/floatingips"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/groups"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/extensions/security/rules"
 	"github.com/gophercloud/gophercloud/openstack/networking/v2/ports"
+	"github.com/gophercloud/gophercloud/openstack/networking/v2/subnets"
 )
 
 //nolint:gocyclo // complicated test function
@@ -28,6 +31,11 @@ func GetFakeClient() *MockClient {
 		"fips":    make(map[string]*floatingips.FloatingIP),
 		"ports":   make(map[string]*ports.Port),
 		"servers": make(map[string]*servers.Server),
+		"images":  make(map[string]*images.Image),
+		"flavors": make(map[string]*flavors.Flavor),
+		"subnets": make(map[string]*subnets.Subnet),
+		// extra specs of the flavors keyed by flavor id
+		"extraspecs": make(map[string]map[string]string),
 	}
 
 	client.GroupClientObj = &CallbackGroupClient{
@@ -206,11 +214,14 @@ func GetFakeClient() *MockClient {
 			opts := optsBuilder.(floatingips.UpdateOpts)
 
 			fip, _ := client.FipClientObj.Get(ctx, id)
-			if fip.PortID != *opts.PortID {
-				// for now, we only ever update the portID
+			if opts.PortID != nil {
 				fip.PortID = *opts.PortID
 			}
 
+			if opts.Description != nil {
+				fip.Description = *opts.Description
+			}
+
 			client.StoredValues["fips"].(map[string]*floatingips.FloatingIP)[id] = fip
 			return fip, nil
 		},
@@ -224,7 +235,12 @@ func GetFakeClient() *MockClient {
 			items := make([]ports.Port, 0)
 			for _, v := range prts {
 				if opts.Name != "" && opts.Name != v.Name {
-					// filter by name is the only thing we use right now
+					// filter by name
+					continue
+				}
+
+				if len(opts.FixedIPs) > 0 && !hasFixedIPs(v.FixedIPs, opts.FixedIPs) {
+					// filter by fixed ips
 					continue
 				}
 
@@ -243,6 +259,16 @@ func GetFakeClient() *MockClient {
 				FixedIPs:  []ports.IP{{IPAddress: generateIP()}},
 			}
 
+			if fixedIPs, ok := opts.FixedIPs.([]ports.IP); ok {
+				port.FixedIPs = make([]ports.IP, len(fixedIPs))
+				for i, fixedIP := range fixedIPs {
+					if fixedIP.IPAddress == "" {
+						fixedIP.IPAddress = generateIP()
+					}
+					port.FixedIPs[i] = fixedIP
+				}
+			}
+
 			if opts.SecurityGroups != nil {
 				port.SecurityGroups = *opts.SecurityGroups
 			}
@@ -279,6 +305,10 @@ func GetFakeClient() *MockClient {
 				port.AllowedAddressPairs = *opts.AllowedAddressPairs
 			}
 
+			if fixedIPs, ok := opts.FixedIPs.([]ports.IP); ok {
+				port.FixedIPs = fixedIPs
+			}
+
 			client.StoredValues["ports"].(map[string]*ports.Port)[id] = port
 			return port, nil
 		},
@@ -309,6 +339,7 @@ func GetFakeClient() *MockClient {
 				Name:    opts.Name,
 				Status:  "ACTIVE",
 				Created: time.Now(),
+				Image:   map[string]interface{}{"id": opts.ImageRef},
 			}
 
 			srvs := client.StoredValues["servers"]
@@ -336,6 +367,101 @@ func GetFakeClient() *MockClient {
 		},
 	}
 
+	client.ImageClientObj = &CallbackImageClient{
+		ListFunc: func(ctx context.Context, optsBuilder images.ListOptsBuilder) ([]images.Image, error) {
+			opts := optsBuilder.(images.ListOpts)
+			imgs := client.StoredValues["images"].(map[string]*images.Image)
+
+			items := make([]images.Image, 0)
+			for _, v := range imgs {
+				if opts.Name != "" && opts.Name != v.Name {
+					// filter by name
+					continue
+				}
+
+				if opts.Status != "" && opts.Status != v.Status {
+					// filter by status
+					continue
+				}
+
+				items = append(items, *v)
+			}
+
+			return items, nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*images.Image, error) {
+			imgs := client.StoredValues["images"]
+			image, found := imgs.(map[string]*images.Image)[id]
+			if !found {
+				return nil, gophercloud.ErrDefault404{}
+			}
+
+			return image, nil
+		},
+	}
+
+	client.FlavorClientObj = &CallbackFlavorClient{
+		ListFunc: func(ctx context.Context, optsBuilder flavors.ListOptsBuilder) ([]flavors.Flavor, error) {
+			flvs := client.StoredValues["flavors"].(map[string]*flavors.Flavor)
+
+			items := make([]flavors.Flavor, 0)
+			for _, v := range flvs {
+				items = append(items, *v)
+			}
+
+			return items, nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*flavors.Flavor, error) {
+			flvs := client.StoredValues["flavors"]
+			flavor, found := flvs.(map[string]*flavors.Flavor)[id]
+			if !found {
+				return nil, gophercloud.ErrDefault404{}
+			}
+
+			return flavor, nil
+		},
+		ListExtraSpecsFunc: func(ctx context.Context, id string) (map[string]string, error) {
+			if _, found := client.StoredValues["flavors"].(map[string]*flavors.Flavor)[id]; !found {
+				return nil, gophercloud.ErrDefault404{}
+			}
+
+			extraSpecs := client.StoredValues["extraspecs"].(map[string]map[string]string)[id]
+			if extraSpecs == nil {
+				extraSpecs = map[string]string{}
+			}
+
+			return extraSpecs, nil
+		},
+	}
+
+	client.SubnetClientObj = &CallbackSubnetClient{
+		ListFunc: func(ctx context.Context, optsBuilder subnets.ListOptsBuilder) ([]subnets.Subnet, error) {
+			opts := optsBuilder.(subnets.ListOpts)
+			snts := client.StoredValues["subnets"].(map[string]*subnets.Subnet)
+
+			items := make([]subnets.Subnet, 0)
+			for _, v := range snts {
+				if opts.NetworkID != "" && opts.NetworkID != v.NetworkID {
+					// filter by network
+					continue
+				}
+
+				items = append(items, *v)
+			}
+
+			return items, nil
+		},
+		GetFunc: func(ctx context.Context, id string) (*subnets.Subnet, error) {
+			snts := client.StoredValues["subnets"]
+			subnet, found := snts.(map[string]*subnets.Subnet)[id]
+			if !found {
+				return nil, gophercloud.ErrDefault404{}
+			}
+
+			return subnet, nil
+		},
+	}
+
 	return &client
 }
 
@@ -353,3 +479,21 @@ func generateIP() string {
 
 	return strings.Join([]string{gen(), gen(), gen(), gen()}, ".")
 }
+
+// hasFixedIPs returns true if all fixed ip filters match one of the fixed ips.
+func hasFixedIPs(fixedIPs []ports.IP, filters []ports.FixedIPOpts) bool {
+	for _, filter := range filters {
+		found := false
+		for _, fixedIP := range fixedIPs {
+			if (filter.IPAddress == "" || filter.IPAddress == fixedIP.IPAddress) &&
+				(filter.SubnetID == "" || filter.SubnetID == fixedIP.SubnetID) {
+				found = true
+				break
+			}
+		}
+		if !found {
+			return false
+		}
+	}
+	return true
+}